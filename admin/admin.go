@@ -0,0 +1,221 @@
+package admin
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	stderrors "errors"
+	"net/http"
+	"net/http/pprof"
+	"strings"
+	"time"
+
+	"github.com/medbai2/common-go/errors"
+	"github.com/medbai2/common-go/health"
+	"github.com/medbai2/common-go/logger"
+	"github.com/medbai2/common-go/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	// DefaultAddr is the admin server address when Config.Addr is empty
+	DefaultAddr = ":9090"
+	// DefaultShutdownTimeout bounds graceful shutdown when Config.ShutdownTimeout is zero
+	DefaultShutdownTimeout = 10 * time.Second
+)
+
+// Config configures the admin server
+type Config struct {
+	Addr            string              // Listen address (default: DefaultAddr)
+	Gatherer        prometheus.Gatherer // Served on /metrics (default: prometheus.DefaultGatherer)
+	Health          *health.Registry    // Served on /livez, /readyz and /healthz (omitted when nil)
+	BuildInfo       BuildInfo           // Served on /buildinfo, completed from the binary's build info
+	Username        string              // Basic auth user; requires Password
+	Password        string              // Basic auth password; requires Username
+	BearerToken     string              // Accepted as "Authorization: Bearer <token>"
+	DisablePprof    bool                // Do not serve /debug/pprof
+	ShutdownTimeout time.Duration       // Graceful shutdown bound (default: DefaultShutdownTimeout)
+}
+
+// Server serves operational endpoints on their own port, away from the public router:
+//   - GET /metrics: Prometheus metrics
+//   - GET /livez, /readyz, /healthz: health checks (when Config.Health is set)
+//   - /debug/pprof/*: runtime profiles (unless Config.DisablePprof)
+//   - GET /buildinfo: version, commit and Go version
+//   - GET, PUT and DELETE /loglevel: runtime log levels per component (see logger.Levels)
+//
+// When Username/Password or BearerToken is set, every endpoint except the health checks
+// requires credentials; either is accepted when both are set. Health checks stay open so
+// Kubernetes probes need no secret. Without credentials the port must not be exposed
+// outside the cluster.
+type Server struct {
+	server          *http.Server
+	shutdownTimeout time.Duration
+}
+
+// NewServer returns an admin server for cfg. It panics when only one of Username and
+// Password is set.
+//
+// Usage:
+//
+//	adminServer := admin.NewServer(admin.Config{Health: registry, BearerToken: os.Getenv("ADMIN_TOKEN")})
+//	mainServer := &http.Server{Addr: ":8080", Handler: router}
+//	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+//	defer stop()
+//	if err := adminServer.RunWith(ctx, mainServer); err != nil {
+//		log.Fatal("server failed", err)
+//	}
+func NewServer(cfg Config) *Server {
+	if (cfg.Username == "") != (cfg.Password == "") {
+		panic("admin: Username and Password must be set together")
+	}
+	if cfg.Addr == "" {
+		cfg.Addr = DefaultAddr
+	}
+	if cfg.Gatherer == nil {
+		cfg.Gatherer = prometheus.DefaultGatherer
+	}
+	if cfg.ShutdownTimeout <= 0 {
+		cfg.ShutdownTimeout = DefaultShutdownTimeout
+	}
+
+	return &Server{
+		server: &http.Server{
+			Addr:              cfg.Addr,
+			Handler:           newRouter(cfg),
+			ReadHeaderTimeout: 10 * time.Second,
+		},
+		shutdownTimeout: cfg.ShutdownTimeout,
+	}
+}
+
+// Handler returns the admin router, e.g. to serve it from a test server
+func (s *Server) Handler() http.Handler {
+	return s.server.Handler
+}
+
+// Run serves the admin endpoints until ctx is done, then shuts down gracefully. It
+// returns nil after a graceful shutdown.
+func (s *Server) Run(ctx context.Context) error {
+	return s.run(ctx, s.server)
+}
+
+// RunWith serves main and the admin endpoints until ctx is done or either server fails,
+// then shuts both down gracefully: main first, so metrics and health stay available
+// while in-flight requests drain, then the admin server. It returns the first serve or
+// shutdown error, or nil after a graceful shutdown.
+func (s *Server) RunWith(ctx context.Context, main *http.Server) error {
+	return s.run(ctx, main, s.server)
+}
+
+// run starts servers and shuts them down in order once ctx is done or one of them fails
+func (s *Server) run(ctx context.Context, servers ...*http.Server) error {
+	log := logger.NewContextLogger(ctx, "admin-server")
+
+	errs := make(chan error, len(servers))
+	for _, server := range servers {
+		log.Info("Server listening", map[string]interface{}{"addr": server.Addr})
+		go func(server *http.Server) {
+			if err := server.ListenAndServe(); err != nil && !stderrors.Is(err, http.ErrServerClosed) {
+				errs <- err
+				return
+			}
+			errs <- nil
+		}(server)
+	}
+
+	running := len(servers)
+	var err error
+	select {
+	case <-ctx.Done():
+	case err = <-errs:
+		running--
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+	for _, server := range servers {
+		if shutdownErr := server.Shutdown(shutdownCtx); shutdownErr != nil {
+			log.Error("Server shutdown failed", shutdownErr, map[string]interface{}{"addr": server.Addr})
+			if err == nil {
+				err = shutdownErr
+			}
+		}
+	}
+	for ; running > 0; running-- {
+		if serveErr := <-errs; serveErr != nil && err == nil {
+			err = serveErr
+		}
+	}
+	return err
+}
+
+// newRouter registers the admin endpoints for cfg
+func newRouter(cfg Config) *gin.Engine {
+	router := gin.New()
+	router.Use(gin.Recovery())
+
+	if cfg.Health != nil {
+		cfg.Health.RegisterRoutes(router)
+	}
+
+	protected := router.Group("/")
+	if cfg.Username != "" || cfg.BearerToken != "" {
+		protected.Use(authenticate(cfg))
+	}
+	protected.GET("/metrics", gin.WrapH(promhttp.HandlerFor(cfg.Gatherer, promhttp.HandlerOpts{})))
+	protected.GET("/buildinfo", BuildInfoHandler(cfg.BuildInfo))
+	protected.GET("/loglevel", GetLogLevelHandler())
+	protected.PUT("/loglevel", SetLogLevelHandler())
+	protected.DELETE("/loglevel", ResetLogLevelHandler())
+
+	if !cfg.DisablePprof {
+		profiles := protected.Group("/debug/pprof")
+		profiles.GET("/", gin.WrapF(pprof.Index))
+		profiles.GET("/cmdline", gin.WrapF(pprof.Cmdline))
+		profiles.GET("/profile", gin.WrapF(pprof.Profile))
+		profiles.GET("/symbol", gin.WrapF(pprof.Symbol))
+		profiles.POST("/symbol", gin.WrapF(pprof.Symbol))
+		profiles.GET("/trace", gin.WrapF(pprof.Trace))
+		profiles.GET("/:profile", gin.WrapF(pprof.Index))
+	}
+
+	return router
+}
+
+// authenticate rejects requests without the configured basic auth or bearer credentials
+func authenticate(cfg Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if cfg.Username != "" {
+			if username, password, ok := c.Request.BasicAuth(); ok &&
+				secureCompare(username, cfg.Username) && secureCompare(password, cfg.Password) {
+				c.Next()
+				return
+			}
+		}
+		if cfg.BearerToken != "" {
+			if token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok &&
+				secureCompare(token, cfg.BearerToken) {
+				c.Next()
+				return
+			}
+		}
+
+		if cfg.Username != "" {
+			c.Header("WWW-Authenticate", `Basic realm="admin"`)
+		} else {
+			c.Header("WWW-Authenticate", `Bearer realm="admin"`)
+		}
+		response.Error(c, errors.NewUnauthorized(""))
+		c.Abort()
+	}
+}
+
+// secureCompare compares a and b in constant time, without leaking their lengths
+func secureCompare(a, b string) bool {
+	aSum, bSum := sha256.Sum256([]byte(a)), sha256.Sum256([]byte(b))
+	return subtle.ConstantTimeCompare(aSum[:], bSum[:]) == 1
+}
@@ -0,0 +1,223 @@
+package admin
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/medbai2/common-go/health"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newTestServer returns an admin server with an isolated registry exposing test_total
+func newTestServer(t *testing.T, cfg Config) *Server {
+	registry := prometheus.NewRegistry()
+	counter := prometheus.NewCounter(prometheus.CounterOpts{Name: "test_total", Help: "Test counter."})
+	registry.MustRegister(counter)
+	counter.Inc()
+	cfg.Gatherer = registry
+
+	healthRegistry, err := health.NewRegistry(health.Config{})
+	require.NoError(t, err)
+	cfg.Health = healthRegistry
+	return NewServer(cfg)
+}
+
+// serve sends a request to the admin router
+func serve(server *Server, method, path string, setAuth func(req *http.Request)) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if setAuth != nil {
+		setAuth(req)
+	}
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, req)
+	return w
+}
+
+func TestServer_Routes(t *testing.T) {
+	server := newTestServer(t, Config{})
+
+	testCases := []struct {
+		Name           string
+		Path           string
+		ExpectedStatus int
+		ExpectedBody   string
+	}{
+		{Name: "metrics", Path: "/metrics", ExpectedStatus: http.StatusOK, ExpectedBody: "test_total 1"},
+		{Name: "liveness", Path: "/livez", ExpectedStatus: http.StatusOK, ExpectedBody: `"status":"ok"`},
+		{Name: "readiness", Path: "/readyz", ExpectedStatus: http.StatusOK, ExpectedBody: `"status":"ok"`},
+		{Name: "build info", Path: "/buildinfo", ExpectedStatus: http.StatusOK, ExpectedBody: `"goVersion"`},
+		{Name: "log level", Path: "/loglevel", ExpectedStatus: http.StatusOK, ExpectedBody: `"level"`},
+		{Name: "pprof index", Path: "/debug/pprof/", ExpectedStatus: http.StatusOK, ExpectedBody: "goroutine"},
+		{Name: "pprof profile", Path: "/debug/pprof/goroutine?debug=1", ExpectedStatus: http.StatusOK, ExpectedBody: "goroutine profile"},
+		{Name: "pprof cmdline", Path: "/debug/pprof/cmdline", ExpectedStatus: http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			w := serve(server, http.MethodGet, tc.Path, nil)
+			assert.Equal(t, tc.ExpectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tc.ExpectedBody)
+		})
+	}
+}
+
+func TestServer_DisablePprof(t *testing.T) {
+	server := newTestServer(t, Config{DisablePprof: true})
+
+	assert.Equal(t, http.StatusNotFound, serve(server, http.MethodGet, "/debug/pprof/", nil).Code)
+}
+
+func TestServer_Authentication(t *testing.T) {
+	basic := func(username, password string) func(req *http.Request) {
+		return func(req *http.Request) { req.SetBasicAuth(username, password) }
+	}
+	bearer := func(token string) func(req *http.Request) {
+		return func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+token) }
+	}
+
+	testCases := []struct {
+		Name                    string
+		Config                  Config
+		Path                    string
+		Auth                    func(req *http.Request)
+		ExpectedStatus          int
+		ExpectedWWWAuthenticate string
+	}{
+		{
+			Name:           "no credentials configured",
+			Path:           "/metrics",
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:                    "basic auth missing",
+			Config:                  Config{Username: "admin", Password: "secret"},
+			Path:                    "/metrics",
+			ExpectedStatus:          http.StatusUnauthorized,
+			ExpectedWWWAuthenticate: `Basic realm="admin"`,
+		},
+		{
+			Name:                    "basic auth wrong password",
+			Config:                  Config{Username: "admin", Password: "secret"},
+			Path:                    "/metrics",
+			Auth:                    basic("admin", "wrong"),
+			ExpectedStatus:          http.StatusUnauthorized,
+			ExpectedWWWAuthenticate: `Basic realm="admin"`,
+		},
+		{
+			Name:           "basic auth valid",
+			Config:         Config{Username: "admin", Password: "secret"},
+			Path:           "/metrics",
+			Auth:           basic("admin", "secret"),
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:                    "bearer token wrong",
+			Config:                  Config{BearerToken: "token"},
+			Path:                    "/debug/pprof/",
+			Auth:                    bearer("other"),
+			ExpectedStatus:          http.StatusUnauthorized,
+			ExpectedWWWAuthenticate: `Bearer realm="admin"`,
+		},
+		{
+			Name:           "bearer token valid",
+			Config:         Config{BearerToken: "token"},
+			Path:           "/debug/pprof/",
+			Auth:           bearer("token"),
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "either credential accepted",
+			Config:         Config{Username: "admin", Password: "secret", BearerToken: "token"},
+			Path:           "/loglevel",
+			Auth:           bearer("token"),
+			ExpectedStatus: http.StatusOK,
+		},
+		{
+			Name:           "health checks stay open",
+			Config:         Config{BearerToken: "token"},
+			Path:           "/readyz",
+			ExpectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			server := newTestServer(t, tc.Config)
+
+			w := serve(server, http.MethodGet, tc.Path, tc.Auth)
+			assert.Equal(t, tc.ExpectedStatus, w.Code)
+			assert.Equal(t, tc.ExpectedWWWAuthenticate, w.Header().Get("WWW-Authenticate"))
+		})
+	}
+}
+
+func TestNewServer_PanicsOnPartialBasicAuth(t *testing.T) {
+	assert.Panics(t, func() { NewServer(Config{Username: "admin"}) })
+	assert.Panics(t, func() { NewServer(Config{Password: "secret"}) })
+}
+
+func TestServer_Run(t *testing.T) {
+	server := newTestServer(t, Config{Addr: "127.0.0.1:0"})
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() { done <- server.Run(ctx) }()
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after the context was cancelled")
+	}
+}
+
+func TestServer_RunWith(t *testing.T) {
+	t.Run("stops both servers when the context is done", func(t *testing.T) {
+		server := newTestServer(t, Config{Addr: "127.0.0.1:0"})
+		main := &http.Server{Addr: "127.0.0.1:0", Handler: http.NotFoundHandler()}
+		ctx, cancel := context.WithCancel(context.Background())
+
+		done := make(chan error, 1)
+		go func() { done <- server.RunWith(ctx, main) }()
+		cancel()
+
+		select {
+		case err := <-done:
+			assert.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("RunWith did not return after the context was cancelled")
+		}
+	})
+
+	t.Run("stops the admin server when the main server fails", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer listener.Close()
+
+		server := newTestServer(t, Config{Addr: "127.0.0.1:0"})
+		main := &http.Server{Addr: listener.Addr().String(), Handler: http.NotFoundHandler()}
+
+		done := make(chan error, 1)
+		go func() { done <- server.RunWith(context.Background(), main) }()
+
+		select {
+		case err := <-done:
+			assert.Error(t, err, "the main server cannot bind an address in use")
+		case <-time.After(5 * time.Second):
+			t.Fatal("RunWith did not return after the main server failed")
+		}
+	})
+}
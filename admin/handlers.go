@@ -0,0 +1,173 @@
+package admin
+
+import (
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/medbai2/common-go/errors"
+	"github.com/medbai2/common-go/logger"
+	"github.com/medbai2/common-go/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BuildInfo describes the running binary. Empty fields are filled from the build info
+// embedded by the Go toolchain where available.
+type BuildInfo struct {
+	Version   string `json:"version"`   // Release version, usually set with -ldflags
+	Commit    string `json:"commit"`    // VCS revision (default: vcs.revision)
+	BuildTime string `json:"buildTime"` // Build or commit time (default: vcs.time)
+	Module    string `json:"module"`    // Main module path
+	GoVersion string `json:"goVersion"` // Go toolchain version
+}
+
+// resolve fills the empty fields of info from the embedded build info
+func (info BuildInfo) resolve() BuildInfo {
+	info.GoVersion = runtime.Version()
+	embedded, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	if info.Module == "" {
+		info.Module = embedded.Main.Path
+	}
+	if info.Version == "" && embedded.Main.Version != "(devel)" {
+		info.Version = embedded.Main.Version
+	}
+	for _, setting := range embedded.Settings {
+		switch {
+		case setting.Key == "vcs.revision" && info.Commit == "":
+			info.Commit = setting.Value
+		case setting.Key == "vcs.time" && info.BuildTime == "":
+			info.BuildTime = setting.Value
+		}
+	}
+	return info
+}
+
+// BuildInfoHandler serves info, completed from the embedded build info
+func BuildInfoHandler(info BuildInfo) gin.HandlerFunc {
+	resolved := info.resolve()
+	return func(c *gin.Context) {
+		response.Success(c, resolved)
+	}
+}
+
+// LogLevel is the body of the log level endpoints
+type LogLevel struct {
+	Component  string     `json:"component,omitempty"` // Service/component; empty for every logger
+	Level      string     `json:"level"`
+	TTL        string     `json:"ttl,omitempty"`       // Request only: revert after this duration, e.g. "10m"
+	Overridden bool       `json:"overridden"`          // Whether the level was set at runtime
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"` // When the runtime level reverts
+}
+
+// LogLevels is the process-wide log level with the component levels set at runtime
+type LogLevels struct {
+	LogLevel
+	Components []LogLevel `json:"components"`
+}
+
+// componentLogLevel returns the level of component's loggers ("" for the process-wide
+// level): its runtime override, or the process-wide level
+func componentLogLevel(component string) LogLevel {
+	if override, ok := logger.Levels.Get(component); ok {
+		return overrideLogLevel(override)
+	}
+	// Components without their own level follow the process-wide override
+	if override, ok := logger.Levels.Get(""); ok {
+		level := overrideLogLevel(override)
+		level.Component = component
+		return level
+	}
+	level, _ := logger.GetLevel()
+	return LogLevel{Component: component, Level: levelName(level)}
+}
+
+// overrideLogLevel returns the body describing override
+func overrideLogLevel(override logger.LevelOverride) LogLevel {
+	level := LogLevel{Component: override.Component, Level: levelName(override.Level), Overridden: true}
+	if !override.ExpiresAt.IsZero() {
+		expiresAt := override.ExpiresAt.UTC()
+		level.ExpiresAt = &expiresAt
+	}
+	return level
+}
+
+// levelName returns the lowercase name of level
+func levelName(level logger.LogLevel) string {
+	return strings.ToLower(level.String())
+}
+
+// GetLogLevelHandler returns the level of the component query parameter, or the
+// process-wide level with every component override when it is absent
+func GetLogLevelHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if component := c.Query("component"); component != "" {
+			response.Success(c, componentLogLevel(component))
+			return
+		}
+
+		levels := LogLevels{LogLevel: componentLogLevel(""), Components: []LogLevel{}}
+		for _, override := range logger.Levels.Overrides() {
+			if override.Component != "" {
+				levels.Components = append(levels.Components, overrideLogLevel(override))
+			}
+		}
+		response.Success(c, levels)
+	}
+}
+
+// SetLogLevelHandler sets a log level from a {"component": "auth0-middleware", "level":
+// "debug", "ttl": "10m"} body. Without component the level applies to every logger;
+// without ttl it stays until reset.
+func SetLogLevelHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body LogLevel
+		if err := c.ShouldBindJSON(&body); err != nil {
+			response.Error(c, errors.NewInvalidInput("invalid request body"))
+			return
+		}
+		if body.Level == "" {
+			response.Error(c, errors.NewMissingField("level"))
+			return
+		}
+		level, ok := logger.LookupLogLevel(body.Level)
+		if !ok {
+			response.Error(c, errors.NewInvalidInput("unknown log level: "+body.Level))
+			return
+		}
+		var ttl time.Duration
+		if body.TTL != "" {
+			parsed, err := time.ParseDuration(body.TTL)
+			if err != nil || parsed <= 0 {
+				response.Error(c, errors.NewInvalidInput("ttl must be a positive duration, e.g. 10m"))
+				return
+			}
+			ttl = parsed
+		}
+
+		logger.Levels.Set(body.Component, level, ttl)
+		logger.NewContextLogger(c.Request.Context(), "admin-server").Warn("Log level changed", map[string]interface{}{
+			"component": body.Component,
+			"level":     levelName(level),
+			"ttl":       ttl.String(),
+		})
+		response.Success(c, componentLogLevel(body.Component))
+	}
+}
+
+// ResetLogLevelHandler removes the runtime level of the component query parameter, or
+// the process-wide runtime level when it is absent
+func ResetLogLevelHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		component := c.Query("component")
+		logger.Levels.Reset(component)
+		logger.NewContextLogger(c.Request.Context(), "admin-server").Warn("Log level reset", map[string]interface{}{
+			"component": component,
+		})
+		response.Success(c, componentLogLevel(component))
+	}
+}
@@ -0,0 +1,194 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/medbai2/common-go/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// decodeData decodes the data field of an APIResponse body into v
+func decodeData(t *testing.T, w *httptest.ResponseRecorder, v interface{}) {
+	var body struct {
+		Data json.RawMessage `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	require.NoError(t, json.Unmarshal(body.Data, v))
+}
+
+// sendJSON sends a request with a JSON body to the admin router
+func sendJSON(server *Server, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, req)
+	return w
+}
+
+// initLogger sets a fresh root logger at info level, restoring the previous one after the test
+func initLogger(t *testing.T) {
+	previous := logger.Default()
+	t.Cleanup(func() { logger.SetDefault(previous) })
+	logger.Init("info", "test")
+}
+
+func TestBuildInfoHandler(t *testing.T) {
+	server := NewServer(Config{BuildInfo: BuildInfo{Version: "1.2.3", Commit: "abc123"}})
+
+	w := serve(server, http.MethodGet, "/buildinfo", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var info BuildInfo
+	decodeData(t, w, &info)
+	assert.Equal(t, "1.2.3", info.Version)
+	assert.Equal(t, "abc123", info.Commit)
+	assert.Equal(t, runtime.Version(), info.GoVersion)
+}
+
+func TestLogLevelHandlers(t *testing.T) {
+	t.Cleanup(logger.ResetLevel)
+	initLogger(t)
+	server := NewServer(Config{})
+	send := func(method, body string) *httptest.ResponseRecorder {
+		return sendJSON(server, method, "/loglevel", body)
+	}
+
+	testCases := []struct {
+		Name           string
+		Method         string
+		Body           string
+		ExpectedStatus int
+		ExpectedLevel  LogLevel
+	}{
+		{
+			Name:           "reports the configured level",
+			Method:         http.MethodGet,
+			ExpectedStatus: http.StatusOK,
+			ExpectedLevel:  LogLevel{Level: "info"},
+		},
+		{
+			Name:           "sets the level",
+			Method:         http.MethodPut,
+			Body:           `{"level":"DEBUG"}`,
+			ExpectedStatus: http.StatusOK,
+			ExpectedLevel:  LogLevel{Level: "debug", Overridden: true},
+		},
+		{
+			Name:           "rejects unknown levels",
+			Method:         http.MethodPut,
+			Body:           `{"level":"verbose"}`,
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedLevel:  LogLevel{Level: "debug", Overridden: true},
+		},
+		{
+			Name:           "rejects a missing level",
+			Method:         http.MethodPut,
+			Body:           `{}`,
+			ExpectedStatus: http.StatusBadRequest,
+			ExpectedLevel:  LogLevel{Level: "debug", Overridden: true},
+		},
+		{
+			Name:           "resets the level",
+			Method:         http.MethodDelete,
+			ExpectedStatus: http.StatusOK,
+			ExpectedLevel:  LogLevel{Level: "info"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			w := send(tc.Method, tc.Body)
+			assert.Equal(t, tc.ExpectedStatus, w.Code)
+
+			var level LogLevel
+			decodeData(t, send(http.MethodGet, ""), &level)
+			assert.Equal(t, tc.ExpectedLevel, level)
+		})
+	}
+}
+
+func TestLogLevelHandlers_Components(t *testing.T) {
+	t.Cleanup(func() {
+		logger.Levels.Reset("auth0-middleware")
+		logger.Levels.Reset("rbac")
+	})
+	initLogger(t)
+	server := NewServer(Config{})
+
+	w := sendJSON(server, http.MethodPut, "/loglevel", `{"component":"auth0-middleware","level":"debug","ttl":"10m"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	var level LogLevel
+	decodeData(t, w, &level)
+	assert.Equal(t, "auth0-middleware", level.Component)
+	assert.Equal(t, "debug", level.Level)
+	assert.True(t, level.Overridden)
+	require.NotNil(t, level.ExpiresAt)
+	assert.WithinDuration(t, time.Now().Add(10*time.Minute), *level.ExpiresAt, time.Minute)
+
+	require.Equal(t, http.StatusOK, sendJSON(server, http.MethodPut, "/loglevel", `{"component":"rbac","level":"warn"}`).Code)
+
+	testCases := []struct {
+		Name           string
+		Body           string
+		ExpectedStatus int
+	}{
+		{Name: "invalid ttl", Body: `{"component":"rbac","level":"debug","ttl":"soon"}`, ExpectedStatus: http.StatusBadRequest},
+		{Name: "negative ttl", Body: `{"component":"rbac","level":"debug","ttl":"-1m"}`, ExpectedStatus: http.StatusBadRequest},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert.Equal(t, tc.ExpectedStatus, sendJSON(server, http.MethodPut, "/loglevel", tc.Body).Code)
+		})
+	}
+
+	var levels LogLevels
+	decodeData(t, sendJSON(server, http.MethodGet, "/loglevel", ""), &levels)
+	assert.Equal(t, "info", levels.Level)
+	assert.False(t, levels.Overridden)
+	require.Len(t, levels.Components, 2)
+	assert.Equal(t, "auth0-middleware", levels.Components[0].Component)
+	assert.Equal(t, LogLevel{Component: "rbac", Level: "warn", Overridden: true}, levels.Components[1])
+
+	w = sendJSON(server, http.MethodDelete, "/loglevel?component=auth0-middleware", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var reset LogLevel
+	decodeData(t, w, &reset)
+	assert.Equal(t, LogLevel{Component: "auth0-middleware", Level: "info"}, reset)
+
+	var rbac LogLevel
+	decodeData(t, sendJSON(server, http.MethodGet, "/loglevel?component=rbac", ""), &rbac)
+	assert.Equal(t, LogLevel{Component: "rbac", Level: "warn", Overridden: true}, rbac)
+}
+
+func TestLogLevelHandlers_InheritedOverride(t *testing.T) {
+	t.Cleanup(func() {
+		logger.ResetLevel()
+		logger.Levels.Reset("rbac")
+	})
+	initLogger(t)
+	server := NewServer(Config{})
+
+	require.Equal(t, http.StatusOK, sendJSON(server, http.MethodPut, "/loglevel", `{"level":"debug","ttl":"10m"}`).Code)
+
+	// A component without its own level reports the process-wide override
+	var inherited LogLevel
+	decodeData(t, sendJSON(server, http.MethodGet, "/loglevel?component=rbac", ""), &inherited)
+	assert.Equal(t, "rbac", inherited.Component)
+	assert.Equal(t, "debug", inherited.Level)
+	assert.True(t, inherited.Overridden)
+	require.NotNil(t, inherited.ExpiresAt)
+	assert.WithinDuration(t, time.Now().Add(10*time.Minute), *inherited.ExpiresAt, time.Minute)
+
+	require.Equal(t, http.StatusOK, sendJSON(server, http.MethodPut, "/loglevel", `{"component":"rbac","level":"warn"}`).Code)
+	var own LogLevel
+	decodeData(t, sendJSON(server, http.MethodGet, "/loglevel?component=rbac", ""), &own)
+	assert.Equal(t, LogLevel{Component: "rbac", Level: "warn", Overridden: true}, own)
+}
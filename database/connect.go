@@ -0,0 +1,220 @@
+package database
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/medbai2/common-go/errors"
+	"github.com/medbai2/common-go/internal/backoff"
+	"github.com/medbai2/common-go/logger"
+
+	"gorm.io/gorm"
+)
+
+// Default startup retry settings
+const (
+	DefaultConnectInitialBackoff = 500 * time.Millisecond
+	DefaultConnectMaxBackoff     = 30 * time.Second
+)
+
+// connectorPluginName is the GORM plugin name under which the background connector is registered
+const connectorPluginName = "common-go:connector"
+
+// ConnectRetryConfig configures how the initial connection is retried when the database
+// is not reachable yet (e.g. Postgres starting slower than the application)
+type ConnectRetryConfig struct {
+	MaxAttempts    int           // Total connection attempts (0 or 1 tries once; negative retries until the context is done)
+	InitialBackoff time.Duration // Delay before the first retry (default: 500ms)
+	MaxBackoff     time.Duration // Upper bound for the delay between retries (default: 30s)
+	DisableJitter  bool          // Use exact exponential delays instead of randomising them
+	Async          bool          // Return the handle immediately and connect in the background (see WaitForConnection)
+}
+
+// delay returns the wait before retry number attempt (0-based)
+func (r ConnectRetryConfig) delay(attempt int) time.Duration {
+	initial, max := r.InitialBackoff, r.MaxBackoff
+	if initial <= 0 {
+		initial = DefaultConnectInitialBackoff
+	}
+	if max <= 0 {
+		max = DefaultConnectMaxBackoff
+	}
+	if r.DisableJitter {
+		return backoff.Exponential(attempt, initial, max)
+	}
+	return backoff.Jitter(attempt, initial, max)
+}
+
+// connect pings the database until it answers, the attempts are exhausted or ctx is done.
+// Every failed attempt is logged with the delay before the next one.
+func connect(ctx context.Context, ping func(ctx context.Context) error, retry ConnectRetryConfig, log logger.Logger) error {
+	maxAttempts := retry.MaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := ping(ctx)
+		if err == nil {
+			if attempt > 1 {
+				log.Info("Connected to database", map[string]interface{}{"attempts": attempt})
+			}
+			return nil
+		}
+
+		if ctx.Err() != nil {
+			return errors.Wrap(err, errors.ErrCodeTimeout, "database connection aborted")
+		}
+		if maxAttempts > 0 && attempt >= maxAttempts {
+			if maxAttempts > 1 {
+				log.Error("Giving up connecting to database", err, map[string]interface{}{"attempts": attempt})
+			}
+			return errors.Wrap(err, errors.ErrCodeDatabaseError, "failed to ping database")
+		}
+
+		delay := retry.delay(attempt - 1)
+		log.Warn("Database connection attempt failed", map[string]interface{}{
+			"attempt":     attempt,
+			"maxAttempts": retry.MaxAttempts,
+			"retryIn":     delay.String(),
+			"error":       err.Error(),
+		})
+
+		select {
+		case <-ctx.Done():
+			return errors.Wrap(err, errors.ErrCodeTimeout, "database connection aborted")
+		case <-time.After(delay):
+		}
+	}
+}
+
+// connector is a GORM plugin tracking a connection made in the background, so health
+// checks can report not-ready until it succeeds
+type connector struct {
+	cancel      context.CancelFunc
+	onConnected func() // Run once, when the connection is first established
+	done        chan struct{}
+
+	mu        sync.RWMutex
+	once      sync.Once
+	connected bool
+	err       error
+}
+
+// newConnector creates a connector whose background attempts stop when cancel is called.
+// onConnected (may be nil) runs once the database is first reachable.
+func newConnector(cancel context.CancelFunc, onConnected func()) *connector {
+	return &connector{cancel: cancel, onConnected: onConnected, done: make(chan struct{})}
+}
+
+// Name implements gorm.Plugin
+func (c *connector) Name() string {
+	return connectorPluginName
+}
+
+// Initialize implements gorm.Plugin; the connector registers no callbacks
+func (c *connector) Initialize(db *gorm.DB) error {
+	return nil
+}
+
+// run connects in the background and records the outcome
+func (c *connector) run(connectFn func() error) {
+	go func() {
+		defer close(c.done)
+		if err := connectFn(); err != nil {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			c.err = err
+			return
+		}
+		c.markConnected()
+	}()
+}
+
+// markConnected records that the database is reachable, clearing an earlier failure
+func (c *connector) markConnected() {
+	c.mu.Lock()
+	c.connected = true
+	c.err = nil
+	c.mu.Unlock()
+
+	c.once.Do(func() {
+		if c.onConnected != nil {
+			c.onConnected()
+		}
+	})
+}
+
+// state returns whether the connection is established and, if it failed, why
+func (c *connector) state() (bool, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.connected, c.err
+}
+
+// ready returns nil once the database is connected. After the background attempts gave
+// up or were cancelled, it pings the database itself, so the handle becomes ready as
+// soon as the database is reachable instead of failing for the life of the process.
+func (c *connector) ready(ctx context.Context, ping func(ctx context.Context) error) error {
+	connected, err := c.state()
+	if connected {
+		return nil
+	}
+	if err == nil {
+		// Background attempts still running
+		return c.notReadyError()
+	}
+	if pingErr := ping(ctx); pingErr != nil {
+		return c.notReadyError()
+	}
+	c.markConnected()
+	return nil
+}
+
+// notReadyError describes why the database cannot serve traffic yet
+func (c *connector) notReadyError() error {
+	connected, err := c.state()
+	if connected {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, errors.ErrCodeDatabaseError, "database connection failed")
+	}
+	return errors.New(errors.ErrCodeDatabaseError, "database connection not established yet")
+}
+
+// close stops pending attempts and waits for the background connection to finish
+func (c *connector) close() {
+	c.cancel()
+	<-c.done
+}
+
+// getConnector returns the background connector attached to db, if any
+func getConnector(db *gorm.DB) *connector {
+	if db == nil || db.Config == nil {
+		return nil
+	}
+	if plugin, ok := db.Config.Plugins[connectorPluginName]; ok {
+		if c, ok := plugin.(*connector); ok {
+			return c
+		}
+	}
+	return nil
+}
+
+// WaitForConnection blocks until a handle created with ConnectRetry.Async is connected,
+// the background attempts give up (returning their error) or ctx is done.
+// It returns nil immediately for handles connected synchronously.
+func WaitForConnection(ctx context.Context, db *gorm.DB) error {
+	c := getConnector(db)
+	if c == nil {
+		return nil
+	}
+	select {
+	case <-c.done:
+		return c.notReadyError()
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), errors.ErrCodeTimeout, "timed out waiting for database connection")
+	}
+}
@@ -0,0 +1,258 @@
+package database
+
+import (
+	"context"
+	stderrors "errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/medbai2/common-go/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyPing fails the first failures calls and counts every call
+func flakyPing(failures int32, calls *atomic.Int32) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		if calls.Add(1) <= failures {
+			return stderrors.New("connection refused")
+		}
+		return nil
+	}
+}
+
+func TestConnect(t *testing.T) {
+	testCases := []struct {
+		Name             string
+		Failures         int32
+		MaxAttempts      int
+		ExpectedCalls    int32
+		ExpectedErrCode  errors.ErrorCode
+		ExpectedWarnings int
+	}{
+		{
+			Name:          "First Attempt Succeeds",
+			Failures:      0,
+			MaxAttempts:   3,
+			ExpectedCalls: 1,
+		},
+		{
+			Name:             "Succeeds After Retries",
+			Failures:         2,
+			MaxAttempts:      3,
+			ExpectedCalls:    3,
+			ExpectedWarnings: 2,
+		},
+		{
+			Name:             "Gives Up After Max Attempts",
+			Failures:         5,
+			MaxAttempts:      3,
+			ExpectedCalls:    3,
+			ExpectedErrCode:  errors.ErrCodeDatabaseError,
+			ExpectedWarnings: 2,
+		},
+		{
+			Name:            "No Retry By Default",
+			Failures:        1,
+			MaxAttempts:     0,
+			ExpectedCalls:   1,
+			ExpectedErrCode: errors.ErrCodeDatabaseError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var calls atomic.Int32
+			log := newRecordingLogger()
+			retry := ConnectRetryConfig{MaxAttempts: tc.MaxAttempts, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+			err := connect(context.Background(), flakyPing(tc.Failures, &calls), retry, log)
+
+			assert.Equal(t, tc.ExpectedCalls, calls.Load())
+			if tc.ExpectedErrCode != "" {
+				appErr := errors.GetAppError(err)
+				require.NotNil(t, appErr)
+				assert.Equal(t, tc.ExpectedErrCode, appErr.Code)
+			} else {
+				require.NoError(t, err)
+			}
+
+			warnings := 0
+			for _, entry := range log.Entries() {
+				if entry.Level == "warn" {
+					warnings++
+					assert.Equal(t, "Database connection attempt failed", entry.Message)
+					assert.Equal(t, warnings, entry.Fields["attempt"])
+					assert.Equal(t, "connection refused", entry.Fields["error"])
+					assert.NotEmpty(t, entry.Fields["retryIn"])
+				}
+			}
+			assert.Equal(t, tc.ExpectedWarnings, warnings)
+		})
+	}
+}
+
+func TestConnect_ContextDeadline(t *testing.T) {
+	var calls atomic.Int32
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// Unlimited attempts: the context is the only bound
+	retry := ConnectRetryConfig{MaxAttempts: -1, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+	err := connect(ctx, flakyPing(1000, &calls), retry, newRecordingLogger())
+
+	appErr := errors.GetAppError(err)
+	require.NotNil(t, appErr)
+	assert.Equal(t, errors.ErrCodeTimeout, appErr.Code)
+	assert.Greater(t, calls.Load(), int32(1))
+}
+
+func TestConnectRetryConfig_Delay(t *testing.T) {
+	retry := ConnectRetryConfig{InitialBackoff: 10 * time.Millisecond, MaxBackoff: 50 * time.Millisecond, DisableJitter: true}
+
+	assert.Equal(t, 10*time.Millisecond, retry.delay(0))
+	assert.Equal(t, 20*time.Millisecond, retry.delay(1))
+	assert.Equal(t, 40*time.Millisecond, retry.delay(2))
+	assert.Equal(t, 50*time.Millisecond, retry.delay(3))
+
+	// With jitter the delay stays within the upper half of the interval
+	retry.DisableJitter = false
+	for attempt := 0; attempt < 5; attempt++ {
+		delay := retry.delay(attempt)
+		assert.GreaterOrEqual(t, delay, 5*time.Millisecond)
+		assert.LessOrEqual(t, delay, 50*time.Millisecond)
+	}
+
+	// Zero values fall back to defaults
+	assert.Equal(t, DefaultConnectInitialBackoff, ConnectRetryConfig{DisableJitter: true}.delay(0))
+}
+
+// connectTestConfig returns a password configuration pointing at the stub
+func connectTestConfig(stub *pgStub, retry ConnectRetryConfig) Config {
+	return Config{
+		Host:         "127.0.0.1",
+		Port:         stub.port(),
+		Name:         "app",
+		User:         "app",
+		Password:     "secret",
+		SSLMode:      "disable",
+		Logger:       newRecordingLogger(),
+		ConnectRetry: retry,
+	}
+}
+
+func TestNew_ConnectRetry(t *testing.T) {
+	// The server rejects the first two connections, as while it is still starting up
+	var attempts atomic.Int32
+	stub := newPGStub(t, func(string) bool { return attempts.Add(1) > 2 })
+
+	db, err := New(connectTestConfig(stub, ConnectRetryConfig{MaxAttempts: 5, InitialBackoff: time.Millisecond}))
+	require.NoError(t, err)
+	defer Close(db)
+
+	assert.Equal(t, int32(3), attempts.Load())
+	assert.NoError(t, WaitForConnection(context.Background(), db))
+	assert.NoError(t, HealthCheck(db))
+}
+
+func TestNew_ConnectRetryExhausted(t *testing.T) {
+	stub := newPGStub(t, func(string) bool { return false })
+
+	db, err := New(connectTestConfig(stub, ConnectRetryConfig{MaxAttempts: 2, InitialBackoff: time.Millisecond}))
+
+	assert.Nil(t, db)
+	require.Error(t, err)
+	assert.Len(t, stub.seenPasswords(), 2)
+}
+
+func TestNewWithContext_Deadline(t *testing.T) {
+	stub := newPGStub(t, func(string) bool { return false })
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	db, err := NewWithContext(ctx, connectTestConfig(stub, ConnectRetryConfig{MaxAttempts: -1, InitialBackoff: time.Millisecond}))
+
+	assert.Nil(t, db)
+	appErr := errors.GetAppError(err)
+	require.NotNil(t, appErr)
+	assert.Equal(t, errors.ErrCodeTimeout, appErr.Code)
+}
+
+func TestNew_ConnectAsync(t *testing.T) {
+	var ready atomic.Bool
+	stub := newPGStub(t, func(string) bool { return ready.Load() })
+
+	db, err := New(connectTestConfig(stub, ConnectRetryConfig{MaxAttempts: -1, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond, Async: true}))
+	require.NoError(t, err)
+	defer Close(db)
+
+	// Not ready while the server rejects connections
+	assert.Error(t, HealthCheck(db))
+	result := Check(context.Background(), db, nil)
+	assert.Equal(t, HealthStatusUnhealthy, result.Status)
+	assert.Contains(t, result.Problems[0], "not established")
+
+	ready.Store(true)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, WaitForConnection(ctx, db))
+	assert.NoError(t, HealthCheck(db))
+}
+
+func TestNew_ConnectAsyncFailure(t *testing.T) {
+	stub := newPGStub(t, func(string) bool { return false })
+
+	db, err := New(connectTestConfig(stub, ConnectRetryConfig{MaxAttempts: 2, InitialBackoff: time.Millisecond, Async: true}))
+	require.NoError(t, err)
+	defer Close(db)
+
+	err = WaitForConnection(context.Background(), db)
+	require.Error(t, err)
+	assert.Contains(t, HealthCheck(db).Error(), "database connection failed")
+}
+
+func TestNew_ConnectAsyncRecoversAfterGivingUp(t *testing.T) {
+	var ready atomic.Bool
+	stub := newPGStub(t, func(string) bool { return ready.Load() })
+
+	db, err := New(connectTestConfig(stub, ConnectRetryConfig{MaxAttempts: 2, InitialBackoff: time.Millisecond, Async: true}))
+	require.NoError(t, err)
+	defer Close(db)
+
+	require.Error(t, WaitForConnection(context.Background(), db))
+	assert.Error(t, HealthCheck(db))
+
+	// The database comes up after the background attempts gave up
+	ready.Store(true)
+	assert.NoError(t, HealthCheck(db))
+	assert.NoError(t, WaitForConnection(context.Background(), db))
+	assert.True(t, Check(context.Background(), db, nil).Healthy())
+}
+
+func TestClose_StopsBackgroundConnect(t *testing.T) {
+	stub := newPGStub(t, func(string) bool { return false })
+
+	db, err := New(connectTestConfig(stub, ConnectRetryConfig{MaxAttempts: -1, InitialBackoff: time.Hour, Async: true}))
+	require.NoError(t, err)
+
+	done := make(chan error, 1)
+	go func() { done <- Close(db) }()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close blocked on the background connection")
+	}
+}
+
+func TestWaitForConnection_Synchronous(t *testing.T) {
+	stub := newPGStub(t, func(string) bool { return true })
+
+	db, err := New(connectTestConfig(stub, ConnectRetryConfig{}))
+	require.NoError(t, err)
+	defer Close(db)
+
+	assert.NoError(t, WaitForConnection(context.Background(), db))
+}
@@ -0,0 +1,183 @@
+package database
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/medbai2/common-go/errors"
+)
+
+// CursorDirection tells whether a cursor continues after or before its position
+type CursorDirection string
+
+const (
+	CursorNext CursorDirection = "next" // Rows after the cursor position
+	CursorPrev CursorDirection = "prev" // Rows before the cursor position
+)
+
+// Cursor is a position in a keyset-paginated result: the sort column values of the
+// boundary row and the direction to continue in
+type Cursor struct {
+	Key       string          // Identifies the sort order the cursor was created for
+	Values    []interface{}   // Sort column values of the boundary row
+	Direction CursorDirection // default: CursorNext
+}
+
+// cursorPayload is the serialized form of a Cursor. Values carry their type so they
+// decode back to the same Go type (JSON alone would turn int64 IDs into float64).
+type cursorPayload struct {
+	Key       string          `json:"k"`
+	Values    []cursorValue   `json:"v"`
+	Direction CursorDirection `json:"d"`
+}
+
+// cursorValue is a typed cursor value
+type cursorValue struct {
+	Type  string `json:"t"`
+	Value string `json:"v"`
+}
+
+// CursorCodec encodes cursors as opaque, HMAC-signed strings so clients cannot forge
+// positions or tamper with them
+type CursorCodec struct {
+	secret []byte
+}
+
+// NewCursorCodec creates a codec signing cursors with secret. Services running several
+// instances must share the secret, and rotating it invalidates outstanding cursors.
+func NewCursorCodec(secret []byte) (*CursorCodec, error) {
+	if len(secret) == 0 {
+		return nil, errors.NewMissingField("secret")
+	}
+	return &CursorCodec{secret: append([]byte(nil), secret...)}, nil
+}
+
+// Encode returns the opaque representation of cursor
+func (c *CursorCodec) Encode(cursor Cursor) (string, error) {
+	payload := cursorPayload{Key: cursor.Key, Direction: cursor.Direction, Values: make([]cursorValue, 0, len(cursor.Values))}
+	if payload.Direction == "" {
+		payload.Direction = CursorNext
+	}
+	for i, value := range cursor.Values {
+		encoded, err := encodeCursorValue(value)
+		if err != nil {
+			return "", errors.Wrap(err, errors.ErrCodeInternal, fmt.Sprintf("failed to encode cursor value %d", i))
+		}
+		payload.Values = append(payload.Values, encoded)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", errors.Wrap(err, errors.ErrCodeInternal, "failed to encode cursor")
+	}
+	body := base64.RawURLEncoding.EncodeToString(data)
+	return body + "." + base64.RawURLEncoding.EncodeToString(c.sign(body)), nil
+}
+
+// Decode verifies and decodes a cursor produced by Encode. Malformed, tampered or
+// foreign cursors are rejected with ErrCodeInvalidInput.
+func (c *CursorCodec) Decode(encoded string) (Cursor, error) {
+	body, signature, ok := strings.Cut(encoded, ".")
+	if !ok {
+		return Cursor{}, invalidCursorError()
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, c.sign(body)) {
+		return Cursor{}, invalidCursorError()
+	}
+	data, err := base64.RawURLEncoding.DecodeString(body)
+	if err != nil {
+		return Cursor{}, invalidCursorError()
+	}
+
+	var payload cursorPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return Cursor{}, invalidCursorError()
+	}
+	if payload.Direction != CursorNext && payload.Direction != CursorPrev {
+		return Cursor{}, invalidCursorError()
+	}
+
+	cursor := Cursor{Key: payload.Key, Direction: payload.Direction, Values: make([]interface{}, 0, len(payload.Values))}
+	for _, encodedValue := range payload.Values {
+		value, err := decodeCursorValue(encodedValue)
+		if err != nil {
+			return Cursor{}, invalidCursorError()
+		}
+		cursor.Values = append(cursor.Values, value)
+	}
+	return cursor, nil
+}
+
+// sign returns the HMAC-SHA256 of body
+func (c *CursorCodec) sign(body string) []byte {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write([]byte(body))
+	return mac.Sum(nil)
+}
+
+// invalidCursorError is returned for any cursor that fails to decode. It gives no
+// detail so clients cannot probe the format.
+func invalidCursorError() *errors.AppError {
+	return errors.NewInvalidInput("invalid cursor")
+}
+
+// encodeCursorValue converts a sort column value into its typed string form.
+// NULL is not supported: keyset comparisons never match NULLs.
+func encodeCursorValue(value interface{}) (cursorValue, error) {
+	switch v := value.(type) {
+	case string:
+		return cursorValue{Type: "string", Value: v}, nil
+	case bool:
+		return cursorValue{Type: "bool", Value: strconv.FormatBool(v)}, nil
+	case int:
+		return cursorValue{Type: "int", Value: strconv.FormatInt(int64(v), 10)}, nil
+	case int32:
+		return cursorValue{Type: "int", Value: strconv.FormatInt(int64(v), 10)}, nil
+	case int64:
+		return cursorValue{Type: "int", Value: strconv.FormatInt(v, 10)}, nil
+	case uint:
+		return cursorValue{Type: "uint", Value: strconv.FormatUint(uint64(v), 10)}, nil
+	case uint32:
+		return cursorValue{Type: "uint", Value: strconv.FormatUint(uint64(v), 10)}, nil
+	case uint64:
+		return cursorValue{Type: "uint", Value: strconv.FormatUint(v, 10)}, nil
+	case float32:
+		return cursorValue{Type: "float", Value: strconv.FormatFloat(float64(v), 'g', -1, 32)}, nil
+	case float64:
+		return cursorValue{Type: "float", Value: strconv.FormatFloat(v, 'g', -1, 64)}, nil
+	case time.Time:
+		return cursorValue{Type: "time", Value: v.Format(time.RFC3339Nano)}, nil
+	case fmt.Stringer:
+		// UUIDs and similar identifier types compare as their text form
+		return cursorValue{Type: "string", Value: v.String()}, nil
+	default:
+		return cursorValue{}, fmt.Errorf("unsupported cursor value type %T", value)
+	}
+}
+
+// decodeCursorValue converts a typed string form back into a Go value
+func decodeCursorValue(value cursorValue) (interface{}, error) {
+	switch value.Type {
+	case "string":
+		return value.Value, nil
+	case "bool":
+		return strconv.ParseBool(value.Value)
+	case "int":
+		return strconv.ParseInt(value.Value, 10, 64)
+	case "uint":
+		return strconv.ParseUint(value.Value, 10, 64)
+	case "float":
+		return strconv.ParseFloat(value.Value, 64)
+	case "time":
+		return time.Parse(time.RFC3339Nano, value.Value)
+	default:
+		return nil, fmt.Errorf("unknown cursor value type %q", value.Type)
+	}
+}
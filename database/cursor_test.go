@@ -0,0 +1,107 @@
+package database
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/medbai2/common-go/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCursorCodec(t *testing.T) *CursorCodec {
+	codec, err := NewCursorCodec([]byte("test-secret"))
+	require.NoError(t, err)
+	return codec
+}
+
+func TestCursorCodec_RoundTrip(t *testing.T) {
+	codec := newTestCursorCodec(t)
+	createdAt := time.Date(2024, 3, 1, 12, 30, 0, 123456789, time.UTC)
+
+	cursor := Cursor{
+		Key:       "orders",
+		Values:    []interface{}{createdAt, int64(9007199254740993), "name", 1.5, true, uint64(7)},
+		Direction: CursorPrev,
+	}
+
+	encoded, err := codec.Encode(cursor)
+	require.NoError(t, err)
+	assert.NotContains(t, encoded, "name", "cursor must be opaque")
+
+	decoded, err := codec.Decode(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, cursor.Key, decoded.Key)
+	assert.Equal(t, CursorPrev, decoded.Direction)
+	require.Len(t, decoded.Values, len(cursor.Values))
+	assert.True(t, createdAt.Equal(decoded.Values[0].(time.Time)))
+	assert.Equal(t, int64(9007199254740993), decoded.Values[1], "int64 values keep full precision")
+	assert.Equal(t, cursor.Values[2:], decoded.Values[2:])
+}
+
+func TestCursorCodec_DefaultDirection(t *testing.T) {
+	codec := newTestCursorCodec(t)
+
+	encoded, err := codec.Encode(Cursor{Values: []interface{}{1}})
+	require.NoError(t, err)
+	decoded, err := codec.Decode(encoded)
+	require.NoError(t, err)
+
+	assert.Equal(t, CursorNext, decoded.Direction)
+	assert.Equal(t, []interface{}{int64(1)}, decoded.Values)
+}
+
+func TestCursorCodec_Decode_Invalid(t *testing.T) {
+	codec := newTestCursorCodec(t)
+	valid, err := codec.Encode(Cursor{Key: "k", Values: []interface{}{int64(42)}})
+	require.NoError(t, err)
+	body, signature, _ := strings.Cut(valid, ".")
+
+	other, err := NewCursorCodec([]byte("other-secret"))
+	require.NoError(t, err)
+	foreign, err := other.Encode(Cursor{Key: "k", Values: []interface{}{int64(42)}})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		Name   string
+		Cursor string
+	}{
+		{Name: "Empty", Cursor: ""},
+		{Name: "No Signature", Cursor: body},
+		{Name: "Tampered Body", Cursor: body + "x." + signature},
+		{Name: "Tampered Signature", Cursor: body + "." + signature[1:]},
+		{Name: "Signed With Another Secret", Cursor: foreign},
+		{Name: "Garbage", Cursor: "%%%.%%%"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			_, err := codec.Decode(tc.Cursor)
+
+			appErr := errors.GetAppError(err)
+			require.NotNil(t, appErr)
+			assert.Equal(t, errors.ErrCodeInvalidInput, appErr.Code)
+			assert.Equal(t, "invalid cursor", appErr.Message)
+		})
+	}
+}
+
+func TestCursorCodec_Encode_UnsupportedValue(t *testing.T) {
+	codec := newTestCursorCodec(t)
+
+	_, err := codec.Encode(Cursor{Values: []interface{}{nil}})
+	assert.Error(t, err)
+
+	_, err = codec.Encode(Cursor{Values: []interface{}{[]int{1}}})
+	assert.Error(t, err)
+}
+
+func TestNewCursorCodec_MissingSecret(t *testing.T) {
+	_, err := NewCursorCodec(nil)
+
+	appErr := errors.GetAppError(err)
+	require.NotNil(t, appErr)
+	assert.Equal(t, errors.ErrCodeMissingField, appErr.Code)
+}
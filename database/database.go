@@ -1,17 +1,17 @@
 package database
 
 import (
-	"fmt"
-	"log"
-	"net/url"
-	"strings"
+	"context"
+	"database/sql"
 	"time"
 
 	"github.com/medbai2/common-go/errors"
+	"github.com/medbai2/common-go/logger"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
 // AuthType represents the authentication method
@@ -19,147 +19,195 @@ type AuthType string
 
 const (
 	AuthTypePassword AuthType = "password" // Password-based authentication (Onebox)
-	AuthTypeIAM     AuthType = "iam"       // IAM-based authentication (GCP)
+	AuthTypeIAM     AuthType = "iam"       // IAM-based authentication (GCP): Cloud SQL Proxy, or TokenSource when set
 )
 
 // Config represents database configuration
 type Config struct {
-	Driver          string
-	Host            string
-	Port            int
-	Name            string
-	User            string
-	Password        string
-	AuthType        AuthType // Explicit authentication type: "password" or "iam"
-	SSLMode         string
-	SchemaAutoApply bool
-	MaxOpenConns    int
-	MaxIdleConns    int
-	ConnMaxLifetime time.Duration
-	ConnMaxIdleTime time.Duration
-}
+	Driver           string
+	Host             string
+	Port             int
+	Name             string
+	User             string
+	Password         string
+	PasswordProvider SecretProvider // Fetched for each new connection, so rotated passwords apply without a restart; overrides Password
+	AuthType         AuthType       // Explicit authentication type: "password" or "iam"
+	TokenSource      TokenSource    // IAM auth without the proxy: each new connection uses a fresh token as its password
+	SSLMode          string
+	SchemaAutoApply  bool
+	MaxOpenConns     int
+	MaxIdleConns     int
+	ConnMaxLifetime  time.Duration
+	ConnMaxIdleTime  time.Duration
+
+	// Connection string options
+	Hosts              []HostPort        // Additional hosts tried in order after Host (see TargetSessionAttrs)
+	ApplicationName    string            // Reported in pg_stat_activity
+	StatementTimeout   time.Duration     // Server-side statement timeout (0 uses the server default)
+	SearchPath         []string          // Schemas searched for unqualified names
+	SSLRootCert        string            // Path to the CA certificate (sslmode verify-ca/verify-full)
+	SSLCert            string            // Path to the client certificate
+	SSLKey             string            // Path to the client key
+	TargetSessionAttrs string            // "any", "read-write", "read-only", "primary", "standby" or "prefer-standby"
+	ExtraParams        map[string]string // Other connection parameters; the fields above take precedence
+
+	// Logging: connection events and SQL statements go through Logger (default: logger.NewFromEnv("database"))
+	Logger   logger.Logger
+	QueryLog QueryLogConfig
 
-// buildDSN builds the PostgreSQL DSN string
-// Handles both password-based and IAM-based authentication based on AuthType
-func buildDSN(cfg Config) string {
-	password := cfg.Password
-	if cfg.AuthType == AuthTypeIAM {
-		// IAM authentication: explicitly empty password
-		// Cloud SQL Proxy will handle IAM token exchange
-		password = ""
-	}
-	// Debug: Log the config values being used
-	log.Printf("buildDSN - Host: %s, Port: %d, User: %s, Name: %s, SSLMode: %s",
-		cfg.Host, cfg.Port, cfg.User, cfg.Name, cfg.SSLMode)
-	
-	// PostgreSQL DSN format: Use postgres:// URL format for better special character handling
-	// The postgres:// URL format properly handles special characters in username/password
-	// Format: postgres://[user[:password]@][host][:port][/database][?parameters]
-	userName := cfg.User
-	dbName := cfg.Name
-	
-	// URL-encode special characters for postgres:// URL format
-	// The postgres:// parser will decode these before sending to PostgreSQL
-	if strings.Contains(userName, "@") {
-		userName = strings.ReplaceAll(userName, "@", "%40")
-	}
-	if strings.Contains(userName, ":") {
-		userName = strings.ReplaceAll(userName, ":", "%3A")
-	}
-	if strings.Contains(userName, "/") {
-		userName = strings.ReplaceAll(userName, "/", "%2F")
-	}
-	if strings.Contains(userName, "?") {
-		userName = strings.ReplaceAll(userName, "?", "%3F")
-	}
-	if strings.Contains(userName, "#") {
-		userName = strings.ReplaceAll(userName, "#", "%23")
-	}
-	if strings.Contains(userName, "[") {
-		userName = strings.ReplaceAll(userName, "[", "%5B")
-	}
-	if strings.Contains(userName, "]") {
-		userName = strings.ReplaceAll(userName, "]", "%5D")
-	}
-	if strings.Contains(userName, " ") {
-		userName = strings.ReplaceAll(userName, " ", "%20")
-	}
-	
-	// URL-encode database name if needed
-	if strings.Contains(dbName, "?") {
-		dbName = strings.ReplaceAll(dbName, "?", "%3F")
-	}
-	if strings.Contains(dbName, "#") {
-		dbName = strings.ReplaceAll(dbName, "#", "%23")
-	}
-	
-	// Build postgres:// URL format DSN
-	// For IAM auth, password is empty, so format is: postgres://user@host:port/db?sslmode=...
-	// Use url.QueryEscape for proper URL encoding
-	var dsn string
-	if password == "" {
-		dsn = fmt.Sprintf("postgres://%s@%s:%d/%s?sslmode=%s",
-			userName, cfg.Host, cfg.Port, dbName, cfg.SSLMode)
-	} else {
-		// URL-encode password using proper URL encoding
-		encodedPassword := url.QueryEscape(password)
-		dsn = fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=%s",
-			userName, encodedPassword, cfg.Host, cfg.Port, dbName, cfg.SSLMode)
-	}
-	
-	log.Printf("buildDSN - URL-encoded username: %s", userName)
-	
-	// Security: never log DSNs because they can include secrets (passwords/tokens).
-	log.Printf("buildDSN - dbname in DSN: '%s' (length: %d)", dbName, len(dbName))
-	
-	return dsn
+	// Startup: retry the initial connection, optionally in the background
+	ConnectRetry ConnectRetryConfig
+
+	// Read replicas: reads are routed to healthy replicas, writes and transactions to the primary
+	Replicas             []ReplicaConfig // Dialed with the primary's settings, minus TargetSessionAttrs and host-selection ExtraParams
+	ReplicaCheckInterval time.Duration   // How often replica health and lag are checked (default: 10s)
+	MaxReplicaLag        time.Duration   // Replicas lagging more than this are skipped (0 disables the lag check)
 }
 
 // New creates a new GORM database connection
 // Supports both password-based (Onebox) and IAM-based (GCP) authentication
 func New(cfg Config) (*gorm.DB, error) {
-	// Validate AuthType
+	return NewWithContext(context.Background(), cfg)
+}
+
+// NewWithContext creates a new GORM database connection, retrying the initial connection
+// as configured by cfg.ConnectRetry until it succeeds or ctx is done, so ctx sets the overall
+// startup deadline.
+//
+// With cfg.ConnectRetry.Async the handle is returned immediately and the attempts continue in
+// the background: HealthCheck and Check report the database as unhealthy until they succeed,
+// and WaitForConnection blocks until then. Background attempts stop when ctx is done or the
+// handle is closed, so ctx must outlive the call. Once they have given up, HealthCheck and
+// Check ping the database themselves and mark the handle connected when it is reachable.
+func NewWithContext(ctx context.Context, cfg Config) (*gorm.DB, error) {
+	if cfg.Logger == nil {
+		cfg.Logger = logger.NewFromEnv("database")
+	}
+
 	if cfg.AuthType == "" {
 		// Default to password auth if not specified (backward compatibility)
 		cfg.AuthType = AuthTypePassword
 	}
-	if cfg.AuthType != AuthTypePassword && cfg.AuthType != AuthTypeIAM {
-		return nil, errors.Wrap(fmt.Errorf("invalid auth type: %s (must be 'password' or 'iam')", cfg.AuthType), errors.ErrCodeDatabaseError, "invalid authentication configuration")
-	}
 
-	// Validate configuration based on auth type
+	// Report every configuration problem at once
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
 	if cfg.AuthType == AuthTypeIAM && cfg.Password != "" {
-		log.Printf("Warning: Password provided but using IAM authentication. Password will be ignored.")
+		cfg.Logger.Warn("Password provided but using IAM authentication. Password will be ignored.")
 	}
-	if cfg.AuthType == AuthTypePassword && cfg.Password == "" {
-		return nil, errors.Wrap(fmt.Errorf("password authentication requires a password"), errors.ErrCodeDatabaseError, "invalid authentication configuration")
+	if cfg.AuthType == AuthTypeIAM && cfg.TokenSource != nil {
+		// Tokens are cached and refreshed shortly before they expire
+		cfg.TokenSource = NewCachingTokenSource(cfg.TokenSource, 0)
 	}
 
-	dsn := buildDSN(cfg)
+	dsn := cfg.DSN()
 
-	// Log authentication mode for debugging
-	if cfg.AuthType == AuthTypeIAM {
-		log.Printf("Using IAM authentication for user: %s", cfg.User)
-	} else {
-		log.Printf("Using password authentication for user: %s", cfg.User)
-	}
+	// Log the redacted DSN and authentication mode for debugging
+	cfg.Logger.Info("Connecting to database", map[string]interface{}{
+		"dsn":      dsn.String(),
+		"authType": string(cfg.AuthType),
+		"iamToken": cfg.AuthType == AuthTypeIAM && cfg.TokenSource != nil,
+	})
 
-	// Configure GORM
+	// Configure GORM; the connection is tested below with retries
 	gormConfig := &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+		Logger:               NewGormLogger(cfg.Logger, cfg.QueryLog),
+		DisableAutomaticPing: true,
 	}
 
-	db, err := gorm.Open(postgres.Open(dsn), gormConfig)
+	sqlDB, err := openPool(dsn.URL(), cfg)
 	if err != nil {
 		return nil, errors.Wrap(err, errors.ErrCodeDatabaseError, "failed to connect to database")
 	}
 
-	// Get underlying sql.DB for connection pool configuration
-	sqlDB, err := db.DB()
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), gormConfig)
 	if err != nil {
-		return nil, errors.Wrap(err, errors.ErrCodeDatabaseError, "failed to get underlying sql.DB")
+		sqlDB.Close()
+		return nil, errors.Wrap(err, errors.ErrCodeDatabaseError, "failed to connect to database")
+	}
+
+	// Translate driver errors into AppErrors for every statement
+	if err := RegisterErrorTranslator(db); err != nil {
+		sqlDB.Close()
+		return nil, errors.Wrap(err, errors.ErrCodeDatabaseError, "failed to register error translator")
+	}
+
+	// Route reads to replicas when configured; replicas are checked once the primary is up
+	var router *replicaRouter
+	if len(cfg.Replicas) > 0 {
+		replicas, err := openReplicas(cfg)
+		if err != nil {
+			sqlDB.Close()
+			return nil, err
+		}
+		router = newReplicaRouter(replicas, cfg.MaxReplicaLag, cfg.ReplicaCheckInterval)
+		if err := db.Use(router); err != nil {
+			router.close()
+			sqlDB.Close()
+			return nil, errors.Wrap(err, errors.ErrCodeDatabaseError, "failed to register replica router")
+		}
+	}
+	closeAll := func() {
+		if router != nil {
+			router.close()
+		}
+		sqlDB.Close()
 	}
 
+	// Test connection
+	startRouter := func() {
+		if router != nil {
+			router.start()
+		}
+	}
+	connectFn := func(ctx context.Context) error {
+		return connect(ctx, sqlDB.PingContext, cfg.ConnectRetry, cfg.Logger)
+	}
+
+	if cfg.ConnectRetry.Async {
+		bgCtx, cancel := context.WithCancel(ctx)
+		c := newConnector(cancel, startRouter)
+		if err := db.Use(c); err != nil {
+			cancel()
+			closeAll()
+			return nil, errors.Wrap(err, errors.ErrCodeDatabaseError, "failed to register connector")
+		}
+		c.run(func() error {
+			err := connectFn(bgCtx)
+			if err != nil && bgCtx.Err() == nil {
+				cfg.Logger.Error("Background database connection failed", err)
+			}
+			return err
+		})
+		return db, nil
+	}
+
+	if err := connectFn(ctx); err != nil {
+		closeAll()
+		return nil, err
+	}
+	startRouter()
+
+	return db, nil
+}
+
+// openPool opens a connection pool for dsn with the pool limits from cfg
+func openPool(dsn string, cfg Config) (*sql.DB, error) {
+	connConfig, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	var opts []stdlib.OptionOpenDB
+	switch {
+	case cfg.AuthType == AuthTypeIAM && cfg.TokenSource != nil:
+		opts = append(opts, iamBeforeConnect(cfg.TokenSource))
+	case cfg.AuthType != AuthTypeIAM && cfg.PasswordProvider != nil:
+		opts = append(opts, passwordBeforeConnect(cfg.PasswordProvider))
+	}
+	sqlDB := stdlib.OpenDB(*connConfig, opts...)
+
 	// Configure connection pool
 	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
 	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
@@ -174,25 +222,35 @@ func New(cfg Config) (*gorm.DB, error) {
 		sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
 	}
 
-	// Test connection
-	if err := sqlDB.Ping(); err != nil {
-		return nil, errors.Wrap(err, errors.ErrCodeDatabaseError, "failed to ping database")
+	return sqlDB, nil
+}
+
+// Close stops background work attached to db (such as replica health checks)
+// and closes the primary and replica connection pools
+func Close(db *gorm.DB) error {
+	if db == nil {
+		return nil
 	}
 
-	return db, nil
-}
+	// Stop a background connection first so it cannot start replica checks after close
+	if c := getConnector(db); c != nil {
+		c.close()
+	}
+
+	var firstErr error
+	if router := getReplicaRouter(db); router != nil {
+		firstErr = router.close()
+	}
 
-// HealthCheck performs a simple health check on the database
-func HealthCheck(db *gorm.DB) error {
 	sqlDB, err := db.DB()
 	if err != nil {
 		return errors.Wrap(err, errors.ErrCodeDatabaseError, "failed to get underlying sql.DB")
 	}
-
-	var result int
-	err = sqlDB.QueryRow("SELECT 1").Scan(&result)
-	if err != nil {
-		return errors.Wrap(err, errors.ErrCodeDatabaseError, "health check failed")
+	if err := sqlDB.Close(); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	if firstErr != nil {
+		return errors.Wrap(firstErr, errors.ErrCodeDatabaseError, "failed to close database")
 	}
 	return nil
 }
@@ -0,0 +1,201 @@
+package database
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/medbai2/common-go/errors"
+)
+
+// DefaultPort is used when Config.Port (or a HostPort's Port) is zero
+const DefaultPort = 5432
+
+// validSSLModes are the sslmode values understood by libpq and pgx
+var validSSLModes = map[string]bool{
+	"disable": true, "allow": true, "prefer": true, "require": true, "verify-ca": true, "verify-full": true,
+}
+
+// validTargetSessionAttrs are the target_session_attrs values understood by pgx
+var validTargetSessionAttrs = map[string]bool{
+	"any": true, "read-write": true, "read-only": true, "primary": true, "standby": true, "prefer-standby": true,
+}
+
+// HostPort is an additional host for multi-host connection strings
+type HostPort struct {
+	Host string
+	Port int
+}
+
+// DSN is a PostgreSQL connection URL built from Config. String redacts the password,
+// so a DSN can be logged safely; URL returns the full connection string.
+type DSN struct {
+	url url.URL
+}
+
+// URL returns the complete connection string, including credentials
+func (d DSN) URL() string {
+	return d.url.String()
+}
+
+// String returns the connection string with the password redacted
+func (d DSN) String() string {
+	return d.url.Redacted()
+}
+
+// DSN builds the connection URL for cfg. Every component is escaped with net/url, so user
+// names, passwords and database names may contain any character.
+//
+// Hosts starting with "/" are treated as Unix socket directories (e.g. /cloudsql/project:region:instance).
+func (cfg Config) DSN() DSN {
+	u := url.URL{Scheme: "postgres", Path: "/" + cfg.Name}
+
+	password := cfg.Password
+	if cfg.AuthType == AuthTypeIAM {
+		// IAM authentication: no static password; the proxy or TokenSource supplies credentials
+		password = ""
+	}
+	if password != "" {
+		u.User = url.UserPassword(cfg.User, password)
+	} else if cfg.User != "" {
+		u.User = url.User(cfg.User)
+	}
+
+	query := url.Values{}
+	for key, value := range cfg.ExtraParams {
+		query.Set(key, value)
+	}
+
+	hosts := append([]HostPort{{Host: cfg.Host, Port: cfg.Port}}, cfg.Hosts...)
+	if strings.HasPrefix(cfg.Host, "/") {
+		// Unix socket: pgx reads the socket directory from the host parameter
+		query.Set("host", cfg.Host)
+		query.Set("port", strconv.Itoa(portOrDefault(cfg.Port)))
+	} else {
+		addresses := make([]string, 0, len(hosts))
+		for _, h := range hosts {
+			addresses = append(addresses, net.JoinHostPort(h.Host, strconv.Itoa(portOrDefault(h.Port))))
+		}
+		u.Host = strings.Join(addresses, ",")
+	}
+
+	setIfNotEmpty(query, "sslmode", cfg.SSLMode)
+	setIfNotEmpty(query, "sslrootcert", cfg.SSLRootCert)
+	setIfNotEmpty(query, "sslcert", cfg.SSLCert)
+	setIfNotEmpty(query, "sslkey", cfg.SSLKey)
+	setIfNotEmpty(query, "application_name", cfg.ApplicationName)
+	setIfNotEmpty(query, "target_session_attrs", cfg.TargetSessionAttrs)
+	if cfg.StatementTimeout > 0 {
+		// Sent as a run-time parameter in the startup message
+		query.Set("statement_timeout", strconv.FormatInt(cfg.StatementTimeout.Milliseconds(), 10))
+	}
+	if len(cfg.SearchPath) > 0 {
+		query.Set("search_path", strings.Join(cfg.SearchPath, ","))
+	}
+
+	u.RawQuery = query.Encode()
+	return DSN{url: u}
+}
+
+// Validate checks the configuration and reports every problem at once as an AppError
+// with code DATABASE_ERROR; the problems are listed in Details.
+func (cfg Config) Validate() error {
+	var problems []string
+	addProblem := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if cfg.Host == "" {
+		addProblem("host is required")
+	}
+	if cfg.Port < 0 || cfg.Port > 65535 {
+		addProblem("port %d is out of range", cfg.Port)
+	}
+	for i, h := range cfg.Hosts {
+		if h.Host == "" {
+			addProblem("hosts[%d]: host is required", i)
+		}
+		if h.Port < 0 || h.Port > 65535 {
+			addProblem("hosts[%d]: port %d is out of range", i, h.Port)
+		}
+	}
+	if len(cfg.Hosts) > 0 && strings.HasPrefix(cfg.Host, "/") {
+		addProblem("additional hosts cannot be combined with a Unix socket host")
+	}
+	if cfg.User == "" {
+		addProblem("user is required")
+	}
+
+	switch cfg.AuthType {
+	case "", AuthTypePassword:
+		if cfg.Password == "" && cfg.PasswordProvider == nil {
+			addProblem("password authentication requires a password or password provider")
+		}
+	case AuthTypeIAM:
+	default:
+		addProblem("invalid auth type %q (must be 'password' or 'iam')", cfg.AuthType)
+	}
+
+	if cfg.SSLMode != "" && !validSSLModes[cfg.SSLMode] {
+		addProblem("invalid sslmode %q", cfg.SSLMode)
+	}
+	if (cfg.SSLCert == "") != (cfg.SSLKey == "") {
+		addProblem("sslcert and sslkey must be set together")
+	}
+	if cfg.TargetSessionAttrs != "" && !validTargetSessionAttrs[cfg.TargetSessionAttrs] {
+		addProblem("invalid target_session_attrs %q", cfg.TargetSessionAttrs)
+	}
+	if cfg.StatementTimeout < 0 {
+		addProblem("statement timeout must not be negative")
+	}
+	if cfg.StatementTimeout > 0 && cfg.StatementTimeout < time.Millisecond {
+		addProblem("statement timeout must be at least 1ms")
+	}
+
+	if cfg.MaxOpenConns < 0 {
+		addProblem("max open connections must not be negative")
+	}
+	if cfg.MaxIdleConns < 0 {
+		addProblem("max idle connections must not be negative")
+	}
+	if cfg.ConnMaxLifetime < 0 || cfg.ConnMaxIdleTime < 0 {
+		addProblem("connection lifetimes must not be negative")
+	}
+	for i, rc := range cfg.Replicas {
+		if rc.Host == "" {
+			addProblem("replicas[%d]: host is required", i)
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return newConfigError(problems)
+}
+
+// newConfigError builds the validation error; the problems also form the wrapped error so
+// they appear in Error() output and logs
+func newConfigError(problems []string) *errors.AppError {
+	details := strings.Join(problems, "; ")
+	appErr := errors.NewWithDetails(errors.ErrCodeDatabaseError, errors.MsgConfigurationError, details)
+	appErr.Err = fmt.Errorf("invalid database configuration: %s", details)
+	return appErr
+}
+
+// portOrDefault returns port, or DefaultPort when it is zero
+func portOrDefault(port int) int {
+	if port == 0 {
+		return DefaultPort
+	}
+	return port
+}
+
+// setIfNotEmpty sets a query parameter when value is not empty
+func setIfNotEmpty(query url.Values, key, value string) {
+	if value != "" {
+		query.Set(key, value)
+	}
+}
@@ -0,0 +1,226 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/medbai2/common-go/errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_DSN_Escaping(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		User     string
+		Password string
+		DBName   string
+	}{
+		{Name: "Email User", User: "svc@project.iam", Password: "p"},
+		{Name: "Password With Space", User: "app", Password: "pass word"},
+		{Name: "Password With Plus", User: "app", Password: "a+b"},
+		{Name: "Password With Reserved Characters", User: "app", Password: `p@ss:w/rd?#[]%&=`},
+		{Name: "Database With Reserved Characters", User: "app", Password: "p", DBName: "my db?#"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			cfg := Config{Host: "db.internal", Port: 5432, User: tc.User, Password: tc.Password, Name: tc.DBName, SSLMode: "disable"}
+
+			// pgx must decode exactly what was configured
+			parsed, err := pgx.ParseConfig(cfg.DSN().URL())
+			require.NoError(t, err)
+			assert.Equal(t, tc.User, parsed.User)
+			assert.Equal(t, tc.Password, parsed.Password)
+			assert.Equal(t, tc.DBName, parsed.Database)
+			assert.Equal(t, "db.internal", parsed.Host)
+		})
+	}
+}
+
+func TestConfig_DSN_Parameters(t *testing.T) {
+	cfg := Config{
+		Host:               "primary.internal",
+		Port:               5433,
+		Hosts:              []HostPort{{Host: "standby.internal"}, {Host: "::1", Port: 6432}},
+		Name:               "app",
+		User:               "app",
+		Password:           "secret",
+		SSLMode:            "verify-full",
+		SSLRootCert:        "/certs/ca.pem",
+		SSLCert:            "/certs/client.pem",
+		SSLKey:             "/certs/client.key",
+		ApplicationName:    "orders-api",
+		StatementTimeout:   5 * time.Second,
+		SearchPath:         []string{"app", "public"},
+		TargetSessionAttrs: "read-write",
+		ExtraParams:        map[string]string{"connect_timeout": "10", "application_name": "overridden"},
+	}
+
+	dsn := cfg.DSN().URL()
+
+	assert.Contains(t, dsn, "@primary.internal:5433,standby.internal:5432,[::1]:6432/app?")
+	assert.Contains(t, dsn, "application_name=orders-api")
+	assert.Contains(t, dsn, "statement_timeout=5000")
+	assert.Contains(t, dsn, "search_path=app%2Cpublic")
+	assert.Contains(t, dsn, "target_session_attrs=read-write")
+	assert.Contains(t, dsn, "sslrootcert=%2Fcerts%2Fca.pem")
+	assert.Contains(t, dsn, "sslcert=%2Fcerts%2Fclient.pem")
+	assert.Contains(t, dsn, "sslkey=%2Fcerts%2Fclient.key")
+	assert.Contains(t, dsn, "connect_timeout=10")
+}
+
+func TestConfig_DSN_MultiHostParses(t *testing.T) {
+	cfg := Config{
+		Host:               "primary.internal",
+		Hosts:              []HostPort{{Host: "standby.internal", Port: 5433}},
+		Name:               "app",
+		User:               "app",
+		Password:           "secret",
+		SSLMode:            "disable",
+		TargetSessionAttrs: "read-write",
+		StatementTimeout:   time.Second,
+	}
+
+	parsed, err := pgx.ParseConfig(cfg.DSN().URL())
+	require.NoError(t, err)
+	assert.Equal(t, "primary.internal", parsed.Host)
+	assert.Equal(t, uint16(DefaultPort), parsed.Port)
+	require.Len(t, parsed.Fallbacks, 1)
+	assert.Equal(t, "standby.internal", parsed.Fallbacks[0].Host)
+	assert.Equal(t, uint16(5433), parsed.Fallbacks[0].Port)
+	assert.Equal(t, "1000", parsed.RuntimeParams["statement_timeout"])
+}
+
+func TestConfig_DSN_UnixSocket(t *testing.T) {
+	cfg := Config{Host: "/cloudsql/project:region:instance", Name: "app", User: "app", AuthType: AuthTypeIAM}
+
+	parsed, err := pgx.ParseConfig(cfg.DSN().URL())
+	require.NoError(t, err)
+	assert.Equal(t, "/cloudsql/project:region:instance", parsed.Host)
+	assert.Empty(t, parsed.Password)
+}
+
+func TestDSN_String_Redacted(t *testing.T) {
+	cfg := Config{Host: "localhost", Port: 5432, Name: "app", User: "app", Password: "super secret", SSLMode: "disable"}
+	dsn := cfg.DSN()
+
+	assert.NotContains(t, dsn.String(), "super")
+	assert.Contains(t, dsn.String(), "app:xxxxx@localhost:5432/app")
+	assert.Contains(t, dsn.URL(), "super%20secret")
+}
+
+func TestConfig_DSN_IAMOmitsPassword(t *testing.T) {
+	cfg := Config{Host: "localhost", User: "svc@project.iam", Password: "ignored", AuthType: AuthTypeIAM}
+
+	assert.NotContains(t, cfg.DSN().URL(), "ignored")
+}
+
+func TestConfig_Validate(t *testing.T) {
+	valid := Config{Host: "localhost", Port: 5432, User: "app", Password: "secret", SSLMode: "require"}
+
+	testCases := []struct {
+		Name             string
+		Modify           func(cfg *Config)
+		ExpectedProblems []string
+	}{
+		{
+			Name:   "Valid",
+			Modify: func(cfg *Config) {},
+		},
+		{
+			Name:   "IAM Without Password",
+			Modify: func(cfg *Config) { cfg.AuthType = AuthTypeIAM; cfg.Password = "" },
+		},
+		{
+			Name: "Password Provider Instead Of Password",
+			Modify: func(cfg *Config) {
+				cfg.Password = ""
+				cfg.PasswordProvider = NewEnvSecretProvider("DB_PASSWORD")
+			},
+		},
+		{
+			Name: "All Problems Reported At Once",
+			Modify: func(cfg *Config) {
+				cfg.Host = ""
+				cfg.Port = 70000
+				cfg.User = ""
+				cfg.Password = ""
+				cfg.SSLMode = "sometimes"
+			},
+			ExpectedProblems: []string{
+				"host is required",
+				"port 70000 is out of range",
+				"user is required",
+				"requires a password",
+				`invalid sslmode "sometimes"`,
+			},
+		},
+		{
+			Name:             "Invalid Auth Type",
+			Modify:           func(cfg *Config) { cfg.AuthType = "kerberos" },
+			ExpectedProblems: []string{`invalid auth type "kerberos"`},
+		},
+		{
+			Name:             "Client Cert Without Key",
+			Modify:           func(cfg *Config) { cfg.SSLCert = "/certs/client.pem" },
+			ExpectedProblems: []string{"sslcert and sslkey must be set together"},
+		},
+		{
+			Name:             "Invalid Target Session Attrs",
+			Modify:           func(cfg *Config) { cfg.TargetSessionAttrs = "primary-please" },
+			ExpectedProblems: []string{"invalid target_session_attrs"},
+		},
+		{
+			Name:             "Negative Pool Settings",
+			Modify:           func(cfg *Config) { cfg.MaxOpenConns = -1; cfg.ConnMaxLifetime = -time.Second },
+			ExpectedProblems: []string{"max open connections", "connection lifetimes"},
+		},
+		{
+			Name:             "Sub-Millisecond Statement Timeout",
+			Modify:           func(cfg *Config) { cfg.StatementTimeout = time.Microsecond },
+			ExpectedProblems: []string{"at least 1ms"},
+		},
+		{
+			Name: "Invalid Hosts And Replicas",
+			Modify: func(cfg *Config) {
+				cfg.Hosts = []HostPort{{Port: 5432}}
+				cfg.Replicas = []ReplicaConfig{{Port: 5432}}
+			},
+			ExpectedProblems: []string{"hosts[0]: host is required", "replicas[0]: host is required"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			cfg := valid
+			tc.Modify(&cfg)
+
+			err := cfg.Validate()
+			if len(tc.ExpectedProblems) == 0 {
+				require.NoError(t, err)
+				return
+			}
+
+			appErr := errors.GetAppError(err)
+			require.NotNil(t, appErr)
+			assert.Equal(t, errors.ErrCodeDatabaseError, appErr.Code)
+			assert.Equal(t, errors.MsgConfigurationError, appErr.Message)
+			for _, problem := range tc.ExpectedProblems {
+				assert.Contains(t, appErr.Details, problem)
+				assert.Contains(t, err.Error(), problem)
+			}
+		})
+	}
+}
+
+func TestConfig_Validate_NeverLeaksPassword(t *testing.T) {
+	cfg := Config{Password: "super-secret", SSLMode: "bogus"}
+
+	err := cfg.Validate()
+
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "super-secret")
+}
@@ -0,0 +1,163 @@
+package database
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/medbai2/common-go/logger"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// Default query logging settings
+const (
+	DefaultQueryLogLevel      = "warn"
+	DefaultSlowQueryThreshold = 200 * time.Millisecond
+)
+
+// QueryLogConfig configures how SQL statements are logged
+type QueryLogConfig struct {
+	Level              string        // "silent", "error", "warn" (errors and slow queries) or "info" (every query); default "warn"
+	SlowQueryThreshold time.Duration // Queries slower than this are logged as warnings (default: 200ms)
+	RedactParams       bool          // Log SQL with $n placeholders instead of interpolated parameter values
+	SampleRate         float64       // Fraction (0-1] of successful, fast queries logged at "info" level; 0 logs all
+	LogRecordNotFound  bool          // Log gorm.ErrRecordNotFound as an error (ignored by default)
+}
+
+// GormLogger adapts the common Logger to GORM's logger interface, so SQL logs join the
+// structured JSON log stream with the request ID taken from the statement's context
+type GormLogger struct {
+	log                  logger.Logger
+	level                gormlogger.LogLevel
+	slowThreshold        time.Duration
+	redactParams         bool
+	sampleRate           float64
+	ignoreRecordNotFound bool
+}
+
+// NewGormLogger creates a GORM logger writing through appLogger
+func NewGormLogger(appLogger logger.Logger, cfg QueryLogConfig) *GormLogger {
+	slowThreshold := cfg.SlowQueryThreshold
+	if slowThreshold <= 0 {
+		slowThreshold = DefaultSlowQueryThreshold
+	}
+
+	return &GormLogger{
+		log:                  appLogger,
+		level:                parseGormLogLevel(cfg.Level),
+		slowThreshold:        slowThreshold,
+		redactParams:         cfg.RedactParams,
+		sampleRate:           cfg.SampleRate,
+		ignoreRecordNotFound: !cfg.LogRecordNotFound,
+	}
+}
+
+// LogMode implements gormlogger.Interface
+func (l *GormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	clone := *l
+	clone.level = level
+	return &clone
+}
+
+// Info implements gormlogger.Interface
+func (l *GormLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= gormlogger.Info {
+		l.contextLogger(ctx).Info(fmt.Sprintf(msg, data...))
+	}
+}
+
+// Warn implements gormlogger.Interface
+func (l *GormLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= gormlogger.Warn {
+		l.contextLogger(ctx).Warn(fmt.Sprintf(msg, data...))
+	}
+}
+
+// Error implements gormlogger.Interface
+func (l *GormLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= gormlogger.Error {
+		l.contextLogger(ctx).Error(fmt.Sprintf(msg, data...), nil)
+	}
+}
+
+// Trace implements gormlogger.Interface and logs a single SQL statement
+func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if l.level <= gormlogger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	isError := err != nil && !(l.ignoreRecordNotFound && stderrors.Is(err, gorm.ErrRecordNotFound))
+	isSlow := l.slowThreshold > 0 && elapsed > l.slowThreshold
+
+	switch {
+	case isError && l.level >= gormlogger.Error:
+		l.contextLogger(ctx).Error("SQL query failed", err, l.traceFields(fc, elapsed))
+	case isSlow && l.level >= gormlogger.Warn:
+		fields := l.traceFields(fc, elapsed)
+		fields["slowThresholdMs"] = l.slowThreshold.Milliseconds()
+		l.contextLogger(ctx).Warn("Slow SQL query", fields)
+	case l.level >= gormlogger.Info && l.sampled():
+		l.contextLogger(ctx).Info("SQL query", l.traceFields(fc, elapsed))
+	}
+}
+
+// ParamsFilter implements gorm.ParamsFilter; with redaction enabled the logged SQL keeps
+// its placeholders so parameter values (emails, tokens, ...) never reach the logs
+func (l *GormLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	if l.redactParams {
+		return sql, nil
+	}
+	return sql, params
+}
+
+// traceFields builds the structured fields for a traced statement
+func (l *GormLogger) traceFields(fc func() (string, int64), elapsed time.Duration) map[string]interface{} {
+	sql, rows := fc()
+	return map[string]interface{}{
+		"sql":          sql,
+		"rowsAffected": rows,
+		"durationMs":   float64(elapsed.Microseconds()) / 1000,
+	}
+}
+
+// sampled reports whether a routine query should be logged
+func (l *GormLogger) sampled() bool {
+	if l.sampleRate <= 0 || l.sampleRate >= 1 {
+		return true
+	}
+	return rand.Float64() < l.sampleRate
+}
+
+// contextLogger returns the logger enriched with the request ID and trace carried by ctx
+func (l *GormLogger) contextLogger(ctx context.Context) logger.Logger {
+	log := l.log
+	if ctx != nil {
+		if requestID := logger.GetRequestID(ctx); requestID != "" {
+			log = log.WithRequestID(requestID)
+		}
+		if traceFields := logger.TraceFields(ctx); traceFields != nil {
+			log = log.WithFields(traceFields)
+		}
+	}
+	return log
+}
+
+// parseGormLogLevel converts a level name to a GORM log level
+func parseGormLogLevel(level string) gormlogger.LogLevel {
+	switch strings.ToLower(level) {
+	case "silent":
+		return gormlogger.Silent
+	case "error":
+		return gormlogger.Error
+	case "info", "debug":
+		return gormlogger.Info
+	default:
+		return gormlogger.Warn
+	}
+}
@@ -0,0 +1,300 @@
+package database
+
+import (
+	"context"
+	stderrors "errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/medbai2/common-go/logger"
+	"github.com/medbai2/common-go/tracing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// logEntry is a single entry captured by recordingLogger
+type logEntry struct {
+	Level     string
+	Message   string
+	Err       error
+	Fields    map[string]interface{}
+	RequestID string
+}
+
+// recordingLogger is a logger.Logger that captures entries for assertions
+type recordingLogger struct {
+	mu        *sync.Mutex
+	entries   *[]logEntry
+	requestID string
+	fields    map[string]interface{}
+}
+
+func newRecordingLogger() *recordingLogger {
+	return &recordingLogger{mu: &sync.Mutex{}, entries: &[]logEntry{}}
+}
+
+func (r *recordingLogger) record(level, msg string, err error, fields ...map[string]interface{}) {
+	merged := map[string]interface{}{}
+	for k, v := range r.fields {
+		merged[k] = v
+	}
+	for _, f := range fields {
+		for k, v := range f {
+			merged[k] = v
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	*r.entries = append(*r.entries, logEntry{Level: level, Message: msg, Err: err, Fields: merged, RequestID: r.requestID})
+}
+
+func (r *recordingLogger) Debug(msg string, fields ...map[string]interface{}) {
+	r.record("debug", msg, nil, fields...)
+}
+func (r *recordingLogger) Info(msg string, fields ...map[string]interface{}) {
+	r.record("info", msg, nil, fields...)
+}
+func (r *recordingLogger) Warn(msg string, fields ...map[string]interface{}) {
+	r.record("warn", msg, nil, fields...)
+}
+func (r *recordingLogger) Error(msg string, err error, fields ...map[string]interface{}) {
+	r.record("error", msg, err, fields...)
+}
+func (r *recordingLogger) Fatal(msg string, err error, fields ...map[string]interface{}) {
+	r.record("fatal", msg, err, fields...)
+}
+func (r *recordingLogger) WithFields(fields map[string]interface{}) logger.Logger {
+	clone := *r
+	clone.fields = map[string]interface{}{}
+	for k, v := range r.fields {
+		clone.fields[k] = v
+	}
+	for k, v := range fields {
+		clone.fields[k] = v
+	}
+	return &clone
+}
+func (r *recordingLogger) WithRequestID(requestID string) logger.Logger {
+	clone := *r
+	clone.requestID = requestID
+	return &clone
+}
+func (r *recordingLogger) WithService(service string) logger.Logger { return r }
+
+// Entries returns a copy of the captured entries
+func (r *recordingLogger) Entries() []logEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]logEntry(nil), *r.entries...)
+}
+
+// traceFunc returns a Trace callback yielding a fixed statement
+func traceFunc(sql string, rows int64) func() (string, int64) {
+	return func() (string, int64) { return sql, rows }
+}
+
+func TestGormLogger_Trace(t *testing.T) {
+	testCases := []struct {
+		Name            string
+		Config          QueryLogConfig
+		Elapsed         time.Duration
+		Err             error
+		ExpectedLevel   string
+		ExpectedMessage string
+	}{
+		{
+			Name:            "Error Is Logged At Default Level",
+			Err:             stderrors.New("relation does not exist"),
+			ExpectedLevel:   "error",
+			ExpectedMessage: "SQL query failed",
+		},
+		{
+			Name:            "Slow Query Is Logged At Default Level",
+			Elapsed:         time.Second,
+			ExpectedLevel:   "warn",
+			ExpectedMessage: "Slow SQL query",
+		},
+		{
+			Name:    "Fast Query Is Not Logged At Default Level",
+			Elapsed: time.Millisecond,
+		},
+		{
+			Name:            "Fast Query Is Logged At Info Level",
+			Config:          QueryLogConfig{Level: "info"},
+			Elapsed:         time.Millisecond,
+			ExpectedLevel:   "info",
+			ExpectedMessage: "SQL query",
+		},
+		{
+			Name:    "Record Not Found Is Ignored By Default",
+			Err:     gorm.ErrRecordNotFound,
+			Elapsed: time.Millisecond,
+		},
+		{
+			Name:            "Record Not Found Can Be Logged",
+			Config:          QueryLogConfig{LogRecordNotFound: true},
+			Err:             gorm.ErrRecordNotFound,
+			ExpectedLevel:   "error",
+			ExpectedMessage: "SQL query failed",
+		},
+		{
+			Name:    "Silent Level Logs Nothing",
+			Config:  QueryLogConfig{Level: "silent"},
+			Err:     stderrors.New("boom"),
+			Elapsed: time.Second,
+		},
+		{
+			Name:            "Custom Slow Threshold",
+			Config:          QueryLogConfig{SlowQueryThreshold: 10 * time.Millisecond},
+			Elapsed:         50 * time.Millisecond,
+			ExpectedLevel:   "warn",
+			ExpectedMessage: "Slow SQL query",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			rec := newRecordingLogger()
+			gl := NewGormLogger(rec, tc.Config)
+
+			gl.Trace(context.Background(), time.Now().Add(-tc.Elapsed), traceFunc("SELECT 1", 1), tc.Err)
+
+			entries := rec.Entries()
+			if tc.ExpectedLevel == "" {
+				assert.Empty(t, entries)
+				return
+			}
+			require.Len(t, entries, 1)
+			assert.Equal(t, tc.ExpectedLevel, entries[0].Level)
+			assert.Equal(t, tc.ExpectedMessage, entries[0].Message)
+			assert.Equal(t, "SELECT 1", entries[0].Fields["sql"])
+			assert.Equal(t, int64(1), entries[0].Fields["rowsAffected"])
+			assert.Contains(t, entries[0].Fields, "durationMs")
+		})
+	}
+}
+
+func TestGormLogger_RequestIDFromContext(t *testing.T) {
+	rec := newRecordingLogger()
+	gl := NewGormLogger(rec, QueryLogConfig{Level: "info"})
+
+	ctx := logger.WithRequestID(context.Background(), "req-123")
+	gl.Trace(ctx, time.Now(), traceFunc("SELECT 1", 1), nil)
+	gl.Info(ctx, "migrated %d tables", 3)
+
+	entries := rec.Entries()
+	require.Len(t, entries, 2)
+	assert.Equal(t, "req-123", entries[0].RequestID)
+	assert.Equal(t, "migrated 3 tables", entries[1].Message)
+	assert.Equal(t, "req-123", entries[1].RequestID)
+}
+
+func TestGormLogger_TraceFromContext(t *testing.T) {
+	rec := newRecordingLogger()
+	gl := NewGormLogger(rec, QueryLogConfig{Level: "info"})
+
+	tp, _ := newTracingTestProvider(t)
+	ctx, span := tracing.Tracer(tp).Start(context.Background(), "GET /users")
+	defer span.End()
+	gl.Trace(ctx, time.Now(), traceFunc("SELECT 1", 1), nil)
+
+	entries := rec.Entries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, span.SpanContext().TraceID().String(), entries[0].Fields["traceId"])
+	assert.Equal(t, span.SpanContext().SpanID().String(), entries[0].Fields["spanId"])
+}
+
+func TestGormLogger_Sampling(t *testing.T) {
+	rec := newRecordingLogger()
+	gl := NewGormLogger(rec, QueryLogConfig{Level: "info", SampleRate: 0.1})
+
+	for i := 0; i < 1000; i++ {
+		gl.Trace(context.Background(), time.Now(), traceFunc("SELECT 1", 1), nil)
+	}
+	// Errors are never sampled away
+	gl.Trace(context.Background(), time.Now(), traceFunc("SELECT 1", 0), stderrors.New("boom"))
+
+	entries := rec.Entries()
+	assert.Greater(t, len(entries), 1)
+	assert.Less(t, len(entries), 500)
+	assert.Equal(t, "error", entries[len(entries)-1].Level)
+}
+
+func TestGormLogger_LogMode(t *testing.T) {
+	rec := newRecordingLogger()
+	gl := NewGormLogger(rec, QueryLogConfig{})
+
+	verbose := gl.LogMode(gormlogger.Info)
+	verbose.Trace(context.Background(), time.Now(), traceFunc("SELECT 1", 1), nil)
+	gl.Trace(context.Background(), time.Now(), traceFunc("SELECT 1", 1), nil)
+
+	// Only the verbose copy logs; the original keeps its level
+	assert.Len(t, rec.Entries(), 1)
+}
+
+func TestGormLogger_RedactParams(t *testing.T) {
+	testCases := []struct {
+		Name         string
+		Redact       bool
+		ExpectedSQL  string
+		NotInLogLine string
+	}{
+		{
+			Name:         "Redacted",
+			Redact:       true,
+			ExpectedSQL:  `SELECT * FROM "users" WHERE email = $1`,
+			NotInLogLine: "secret@example.com",
+		},
+		{
+			Name:        "Interpolated",
+			Redact:      false,
+			ExpectedSQL: `SELECT * FROM "users" WHERE email = 'secret@example.com'`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			rec := newRecordingLogger()
+
+			mockDB, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer mockDB.Close()
+
+			db, err := gorm.Open(postgres.New(postgres.Config{Conn: mockDB}), &gorm.Config{
+				Logger: NewGormLogger(rec, QueryLogConfig{Level: "info", RedactParams: tc.Redact}),
+			})
+			require.NoError(t, err)
+
+			mock.ExpectQuery(`SELECT`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+			var ids []int
+			require.NoError(t, db.Table("users").Where("email = ?", "secret@example.com").Pluck("id", &ids).Error)
+
+			entries := rec.Entries()
+			require.NotEmpty(t, entries)
+			sql := entries[len(entries)-1].Fields["sql"].(string)
+			assert.Contains(t, sql, "email = ")
+			if tc.Redact {
+				assert.NotContains(t, sql, tc.NotInLogLine)
+				assert.Contains(t, sql, "$1")
+			} else {
+				assert.Contains(t, sql, "secret@example.com")
+			}
+		})
+	}
+}
+
+func TestParseGormLogLevel(t *testing.T) {
+	assert.Equal(t, gormlogger.Silent, parseGormLogLevel("silent"))
+	assert.Equal(t, gormlogger.Error, parseGormLogLevel("ERROR"))
+	assert.Equal(t, gormlogger.Warn, parseGormLogLevel("warn"))
+	assert.Equal(t, gormlogger.Info, parseGormLogLevel("info"))
+	assert.Equal(t, gormlogger.Info, parseGormLogLevel("debug"))
+	assert.Equal(t, gormlogger.Warn, parseGormLogLevel(""))
+}
@@ -0,0 +1,310 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/medbai2/common-go/errors"
+
+	"github.com/jackc/pgx/v5"
+	"gorm.io/gorm"
+)
+
+// HealthStatus is the overall outcome of a database health check
+type HealthStatus string
+
+const (
+	HealthStatusHealthy   HealthStatus = "healthy"   // Everything within thresholds
+	HealthStatusDegraded  HealthStatus = "degraded"  // Reachable, but a threshold was exceeded
+	HealthStatusUnhealthy HealthStatus = "unhealthy" // Unreachable or unusable
+)
+
+// Default health check settings
+const (
+	DefaultHealthCheckTimeout  = 2 * time.Second
+	DefaultMaxHealthLatency    = 500 * time.Millisecond
+	DefaultMaxPoolUtilization  = 0.9
+	DefaultMaxHealthReplicaLag = 30 * time.Second
+	DefaultMigrationsTable     = "schema_migrations"
+)
+
+// HealthOptions configures Check; nil or zero fields use the Default* settings
+type HealthOptions struct {
+	Timeout            time.Duration // Upper bound for the whole check (default: 2s)
+	MaxLatency         time.Duration // Ping latency above this is degraded (default: 500ms)
+	MaxPoolUtilization float64       // In-use/max-open ratio above this is degraded (default: 0.9)
+	MaxReplicationLag  time.Duration // Replication lag above this is degraded (default: 30s)
+
+	// Migrations: when ExpectedMigrationVersion is set, the version recorded in MigrationsTable
+	// (golang-migrate layout: version, dirty) is compared with it. A lower version is degraded,
+	// a dirty migration is unhealthy.
+	ExpectedMigrationVersion uint
+	MigrationsTable          string // default: "schema_migrations"
+}
+
+// PoolHealth summarises connection pool saturation
+type PoolHealth struct {
+	MaxOpen      int           `json:"maxOpen"`
+	Open         int           `json:"open"`
+	InUse        int           `json:"inUse"`
+	Idle         int           `json:"idle"`
+	WaitCount    int64         `json:"waitCount"`
+	WaitDuration time.Duration `json:"waitDuration"`
+	Utilization  float64       `json:"utilization"` // InUse / MaxOpen; 0 when the pool is unbounded
+}
+
+// MigrationHealth reports the applied schema migration version
+type MigrationHealth struct {
+	Version         uint `json:"version"`
+	ExpectedVersion uint `json:"expectedVersion"`
+	Dirty           bool `json:"dirty"`
+	Pending         bool `json:"pending"`
+}
+
+// HealthResult is the structured outcome of Check
+type HealthResult struct {
+	Status         HealthStatus     `json:"status"`
+	Latency        time.Duration    `json:"latency"`
+	ServerVersion  string           `json:"serverVersion,omitempty"`
+	IsReplica      bool             `json:"isReplica"`
+	ReplicationLag time.Duration    `json:"replicationLag,omitempty"`
+	Pool           PoolHealth       `json:"pool"`
+	Migrations     *MigrationHealth `json:"migrations,omitempty"`
+	Replicas       []ReplicaState   `json:"replicas,omitempty"`
+	Problems       []string         `json:"problems,omitempty"`
+}
+
+// Healthy reports whether the database can serve traffic (healthy or degraded)
+func (r HealthResult) Healthy() bool {
+	return r.Status != HealthStatusUnhealthy
+}
+
+// ToMap converts the result into an entry for the checks map of response.Health
+func (r HealthResult) ToMap() map[string]interface{} {
+	check := map[string]interface{}{
+		"status":    string(r.Status),
+		"latencyMs": float64(r.Latency.Microseconds()) / 1000,
+		"isReplica": r.IsReplica,
+		"pool": map[string]interface{}{
+			"maxOpen":        r.Pool.MaxOpen,
+			"open":           r.Pool.Open,
+			"inUse":          r.Pool.InUse,
+			"idle":           r.Pool.Idle,
+			"waitCount":      r.Pool.WaitCount,
+			"waitDurationMs": r.Pool.WaitDuration.Milliseconds(),
+			"utilization":    r.Pool.Utilization,
+		},
+	}
+	if r.ServerVersion != "" {
+		check["serverVersion"] = r.ServerVersion
+	}
+	if r.IsReplica {
+		check["replicationLagMs"] = r.ReplicationLag.Milliseconds()
+	}
+	if r.Migrations != nil {
+		check["migrations"] = map[string]interface{}{
+			"version":         r.Migrations.Version,
+			"expectedVersion": r.Migrations.ExpectedVersion,
+			"dirty":           r.Migrations.Dirty,
+			"pending":         r.Migrations.Pending,
+		}
+	}
+	if len(r.Replicas) > 0 {
+		check["replicas"] = r.Replicas
+	}
+	if len(r.Problems) > 0 {
+		check["problems"] = r.Problems
+	}
+	return check
+}
+
+// degrade lowers the status to degraded (never raising an unhealthy result) and records why
+func (r *HealthResult) degrade(problem string) {
+	if r.Status == HealthStatusHealthy {
+		r.Status = HealthStatusDegraded
+	}
+	r.Problems = append(r.Problems, problem)
+}
+
+// fail marks the result unhealthy and records why
+func (r *HealthResult) fail(problem string) {
+	r.Status = HealthStatusUnhealthy
+	r.Problems = append(r.Problems, problem)
+}
+
+// withDefaults fills zero-valued options with defaults
+func (o *HealthOptions) withDefaults() HealthOptions {
+	opts := HealthOptions{}
+	if o != nil {
+		opts = *o
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultHealthCheckTimeout
+	}
+	if opts.MaxLatency <= 0 {
+		opts.MaxLatency = DefaultMaxHealthLatency
+	}
+	if opts.MaxPoolUtilization <= 0 {
+		opts.MaxPoolUtilization = DefaultMaxPoolUtilization
+	}
+	if opts.MaxReplicationLag <= 0 {
+		opts.MaxReplicationLag = DefaultMaxHealthReplicaLag
+	}
+	if opts.MigrationsTable == "" {
+		opts.MigrationsTable = DefaultMigrationsTable
+	}
+	return opts
+}
+
+// Check runs a context-aware health check against db and reports latency, pool saturation,
+// server version, replication lag (when connected to a replica), pending migrations and the
+// state of routed read replicas. It never blocks longer than opts.Timeout.
+//
+// Usage:
+//
+//	result := database.Check(c.Request.Context(), db, nil)
+//	response.Health(c, string(result.Status), map[string]interface{}{"database": result.ToMap()})
+func Check(ctx context.Context, db *gorm.DB, opts *HealthOptions) HealthResult {
+	options := opts.withDefaults()
+	result := HealthResult{Status: HealthStatusHealthy}
+
+	if db == nil {
+		result.fail("database is nil")
+		return result
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		result.fail(fmt.Sprintf("failed to get underlying sql.DB: %v", err))
+		return result
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, options.Timeout)
+	defer cancel()
+
+	if c := getConnector(db); c != nil {
+		if err := c.ready(ctx, sqlDB.PingContext); err != nil {
+			result.fail(err.Error())
+			return result
+		}
+	}
+
+	result.Pool = poolHealth(sqlDB.Stats())
+
+	start := time.Now()
+	err = sqlDB.PingContext(ctx)
+	result.Latency = time.Since(start)
+	if err != nil {
+		result.fail(fmt.Sprintf("ping failed: %s", TranslateError(err).Error()))
+		return result
+	}
+	if result.Latency > options.MaxLatency {
+		result.degrade(fmt.Sprintf("latency %s exceeds %s", result.Latency, options.MaxLatency))
+	}
+	if result.Pool.Utilization > options.MaxPoolUtilization {
+		result.degrade(fmt.Sprintf("pool utilization %.2f exceeds %.2f", result.Pool.Utilization, options.MaxPoolUtilization))
+	}
+
+	if err := sqlDB.QueryRowContext(ctx, "SELECT current_setting('server_version'), pg_is_in_recovery()").
+		Scan(&result.ServerVersion, &result.IsReplica); err != nil {
+		result.degrade(fmt.Sprintf("server status query failed: %s", TranslateError(err).Error()))
+	} else if result.IsReplica {
+		var seconds float64
+		if err := sqlDB.QueryRowContext(ctx, replicaLagQuery).Scan(&seconds); err != nil {
+			result.degrade(fmt.Sprintf("replication lag query failed: %s", TranslateError(err).Error()))
+		} else {
+			result.ReplicationLag = time.Duration(seconds * float64(time.Second))
+			if result.ReplicationLag > options.MaxReplicationLag {
+				result.degrade(fmt.Sprintf("replication lag %s exceeds %s", result.ReplicationLag, options.MaxReplicationLag))
+			}
+		}
+	}
+
+	if options.ExpectedMigrationVersion > 0 {
+		checkMigrations(ctx, sqlDB, options, &result)
+	}
+
+	result.Replicas = ReplicaStates(db)
+	for _, state := range result.Replicas {
+		if !state.Healthy {
+			result.degrade(fmt.Sprintf("replica %s is unhealthy", state.Host))
+		}
+	}
+
+	return result
+}
+
+// checkMigrations compares the applied migration version with the expected one
+func checkMigrations(ctx context.Context, sqlDB *sql.DB, options HealthOptions, result *HealthResult) {
+	migrations := &MigrationHealth{ExpectedVersion: options.ExpectedMigrationVersion}
+	result.Migrations = migrations
+
+	query := fmt.Sprintf("SELECT version, dirty FROM %s LIMIT 1", quoteIdentifier(options.MigrationsTable))
+	err := sqlDB.QueryRowContext(ctx, query).Scan(&migrations.Version, &migrations.Dirty)
+	if err != nil && err != sql.ErrNoRows {
+		result.degrade(fmt.Sprintf("migration status query failed: %s", TranslateError(err).Error()))
+		return
+	}
+
+	migrations.Pending = migrations.Version < migrations.ExpectedVersion
+	switch {
+	case migrations.Dirty:
+		result.fail(fmt.Sprintf("migration %d is dirty", migrations.Version))
+	case migrations.Pending:
+		result.degrade(fmt.Sprintf("migrations pending: at version %d, expected %d", migrations.Version, migrations.ExpectedVersion))
+	}
+}
+
+// quoteIdentifier quotes a possibly schema-qualified table name
+func quoteIdentifier(name string) string {
+	return pgx.Identifier(strings.Split(name, ".")).Sanitize()
+}
+
+// poolHealth derives saturation figures from connection pool statistics
+func poolHealth(stats sql.DBStats) PoolHealth {
+	pool := PoolHealth{
+		MaxOpen:      stats.MaxOpenConnections,
+		Open:         stats.OpenConnections,
+		InUse:        stats.InUse,
+		Idle:         stats.Idle,
+		WaitCount:    stats.WaitCount,
+		WaitDuration: stats.WaitDuration,
+	}
+	if stats.MaxOpenConnections > 0 {
+		pool.Utilization = float64(stats.InUse) / float64(stats.MaxOpenConnections)
+	}
+	return pool
+}
+
+// HealthCheck pings the database, bounded by DefaultHealthCheckTimeout.
+// Use Check for latency, pool and replication details.
+func HealthCheck(db *gorm.DB) error {
+	return HealthCheckContext(context.Background(), db)
+}
+
+// HealthCheckContext pings the database, bounded by ctx and DefaultHealthCheckTimeout
+func HealthCheckContext(ctx context.Context, db *gorm.DB) error {
+	if db == nil {
+		return errors.New(errors.ErrCodeDatabaseError, "database is nil")
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return errors.Wrap(err, errors.ErrCodeDatabaseError, "failed to get underlying sql.DB")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, DefaultHealthCheckTimeout)
+	defer cancel()
+
+	if c := getConnector(db); c != nil {
+		if err := c.ready(ctx, sqlDB.PingContext); err != nil {
+			return err
+		}
+	}
+
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return errors.Wrap(err, errors.ErrCodeDatabaseError, "health check failed")
+	}
+	return nil
+}
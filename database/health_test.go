@@ -0,0 +1,247 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/medbai2/common-go/errors"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// newHealthTestDB creates a GORM handle whose pings are checked by sqlmock
+func newHealthTestDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	mockDB, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+	t.Cleanup(func() { mockDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: mockDB}), &gorm.Config{
+		Logger:                 gormlogger.Discard,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+	})
+	require.NoError(t, err)
+	return db, mock
+}
+
+// expectServerStatus sets up the server version/recovery query
+func expectServerStatus(mock sqlmock.Sqlmock, isReplica bool) {
+	mock.ExpectQuery(`current_setting\('server_version'\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"server_version", "pg_is_in_recovery"}).AddRow("16.2", isReplica))
+}
+
+func TestCheck(t *testing.T) {
+	testCases := []struct {
+		Name            string
+		Options         *HealthOptions
+		Setup           func(mock sqlmock.Sqlmock)
+		ExpectedStatus  HealthStatus
+		ExpectedProblem string
+		ValidateResult  func(t *testing.T, result HealthResult)
+	}{
+		{
+			Name: "Healthy Primary",
+			Setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectPing()
+				expectServerStatus(mock, false)
+			},
+			ExpectedStatus: HealthStatusHealthy,
+			ValidateResult: func(t *testing.T, result HealthResult) {
+				assert.Equal(t, "16.2", result.ServerVersion)
+				assert.False(t, result.IsReplica)
+				assert.Nil(t, result.Migrations)
+				assert.Empty(t, result.Problems)
+			},
+		},
+		{
+			Name: "Ping Failure",
+			Setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectPing().WillReturnError(driver.ErrBadConn)
+			},
+			ExpectedStatus:  HealthStatusUnhealthy,
+			ExpectedProblem: "ping failed",
+		},
+		{
+			Name:    "Slow Ping Is Degraded",
+			Options: &HealthOptions{MaxLatency: time.Millisecond},
+			Setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectPing().WillDelayFor(5 * time.Millisecond)
+				expectServerStatus(mock, false)
+			},
+			ExpectedStatus:  HealthStatusDegraded,
+			ExpectedProblem: "latency",
+		},
+		{
+			Name: "Replica Within Lag",
+			Setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectPing()
+				expectServerStatus(mock, true)
+				mock.ExpectQuery(`pg_last_xact_replay_timestamp`).WillReturnRows(sqlmock.NewRows([]string{"lag"}).AddRow(1.5))
+			},
+			ExpectedStatus: HealthStatusHealthy,
+			ValidateResult: func(t *testing.T, result HealthResult) {
+				assert.True(t, result.IsReplica)
+				assert.Equal(t, 1500*time.Millisecond, result.ReplicationLag)
+			},
+		},
+		{
+			Name:    "Replica Lagging Is Degraded",
+			Options: &HealthOptions{MaxReplicationLag: 10 * time.Second},
+			Setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectPing()
+				expectServerStatus(mock, true)
+				mock.ExpectQuery(`pg_last_xact_replay_timestamp`).WillReturnRows(sqlmock.NewRows([]string{"lag"}).AddRow(60.0))
+			},
+			ExpectedStatus:  HealthStatusDegraded,
+			ExpectedProblem: "replication lag",
+		},
+		{
+			Name:    "Migrations Up To Date",
+			Options: &HealthOptions{ExpectedMigrationVersion: 2},
+			Setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectPing()
+				expectServerStatus(mock, false)
+				mock.ExpectQuery(`SELECT version, dirty FROM "schema_migrations"`).
+					WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(2, false))
+			},
+			ExpectedStatus: HealthStatusHealthy,
+			ValidateResult: func(t *testing.T, result HealthResult) {
+				require.NotNil(t, result.Migrations)
+				assert.Equal(t, uint(2), result.Migrations.Version)
+				assert.False(t, result.Migrations.Pending)
+			},
+		},
+		{
+			Name:    "Pending Migrations Are Degraded",
+			Options: &HealthOptions{ExpectedMigrationVersion: 3, MigrationsTable: "rbac.schema_migrations"},
+			Setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectPing()
+				expectServerStatus(mock, false)
+				mock.ExpectQuery(`SELECT version, dirty FROM "rbac"."schema_migrations"`).
+					WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(2, false))
+			},
+			ExpectedStatus:  HealthStatusDegraded,
+			ExpectedProblem: "migrations pending",
+		},
+		{
+			Name:    "Dirty Migration Is Unhealthy",
+			Options: &HealthOptions{ExpectedMigrationVersion: 2},
+			Setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectPing()
+				expectServerStatus(mock, false)
+				mock.ExpectQuery(`SELECT version, dirty FROM "schema_migrations"`).
+					WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(2, true))
+			},
+			ExpectedStatus:  HealthStatusUnhealthy,
+			ExpectedProblem: "dirty",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			db, mock := newHealthTestDB(t)
+			tc.Setup(mock)
+
+			result := Check(context.Background(), db, tc.Options)
+
+			assert.Equal(t, tc.ExpectedStatus, result.Status)
+			if tc.ExpectedProblem != "" {
+				require.NotEmpty(t, result.Problems)
+				assert.Contains(t, result.Problems[0], tc.ExpectedProblem)
+			}
+			if tc.ValidateResult != nil {
+				tc.ValidateResult(t, result)
+			}
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCheck_Timeout(t *testing.T) {
+	db, mock := newHealthTestDB(t)
+	mock.ExpectPing().WillDelayFor(time.Second)
+
+	start := time.Now()
+	result := Check(context.Background(), db, &HealthOptions{Timeout: 20 * time.Millisecond})
+
+	assert.Equal(t, HealthStatusUnhealthy, result.Status)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestCheck_NilDatabase(t *testing.T) {
+	result := Check(context.Background(), nil, nil)
+
+	assert.Equal(t, HealthStatusUnhealthy, result.Status)
+	assert.False(t, result.Healthy())
+	assert.Equal(t, []string{"database is nil"}, result.Problems)
+}
+
+func TestCheck_UnhealthyReplicaIsDegraded(t *testing.T) {
+	db, mock := newHealthTestDB(t)
+
+	replicaDB, _, err := sqlmock.New()
+	require.NoError(t, err)
+	router := newReplicaRouter([]*replica{{host: "replica-1", port: 5432, pool: replicaDB}}, 0, time.Hour)
+	require.NoError(t, db.Use(router))
+	t.Cleanup(func() { router.close() })
+
+	mock.ExpectPing()
+	expectServerStatus(mock, false)
+
+	result := Check(context.Background(), db, nil)
+
+	assert.Equal(t, HealthStatusDegraded, result.Status)
+	assert.True(t, result.Healthy())
+	require.Len(t, result.Replicas, 1)
+	assert.Contains(t, result.Problems[0], "replica-1")
+}
+
+func TestHealthResult_ToMap(t *testing.T) {
+	result := HealthResult{
+		Status:         HealthStatusDegraded,
+		Latency:        1500 * time.Microsecond,
+		ServerVersion:  "16.2",
+		IsReplica:      true,
+		ReplicationLag: 2 * time.Second,
+		Pool:           PoolHealth{MaxOpen: 10, InUse: 9, Utilization: 0.9},
+		Migrations:     &MigrationHealth{Version: 1, ExpectedVersion: 2, Pending: true},
+		Problems:       []string{"migrations pending"},
+	}
+
+	check := result.ToMap()
+
+	assert.Equal(t, "degraded", check["status"])
+	assert.Equal(t, 1.5, check["latencyMs"])
+	assert.Equal(t, "16.2", check["serverVersion"])
+	assert.Equal(t, int64(2000), check["replicationLagMs"])
+	assert.Equal(t, 0.9, check["pool"].(map[string]interface{})["utilization"])
+	assert.Equal(t, true, check["migrations"].(map[string]interface{})["pending"])
+	assert.Equal(t, []string{"migrations pending"}, check["problems"])
+}
+
+func TestPoolHealth(t *testing.T) {
+	db, _ := newHealthTestDB(t)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(4)
+
+	pool := poolHealth(sqlDB.Stats())
+	assert.Equal(t, 4, pool.MaxOpen)
+	assert.Zero(t, pool.Utilization)
+}
+
+func TestHealthCheckContext_NilDatabase(t *testing.T) {
+	err := HealthCheckContext(context.Background(), nil)
+
+	appErr := errors.GetAppError(err)
+	require.NotNil(t, appErr)
+	assert.Equal(t, errors.ErrCodeDatabaseError, appErr.Code)
+	assert.Contains(t, err.Error(), "database is nil")
+}
@@ -0,0 +1,102 @@
+package database
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/medbai2/common-go/errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// DefaultTokenRefreshBefore is how long before expiry a cached IAM token is replaced
+const DefaultTokenRefreshBefore = 5 * time.Minute
+
+// Token is a short-lived access token used as the database password
+type Token struct {
+	Value  string
+	Expiry time.Time // Zero means the token does not expire
+}
+
+// valid reports whether the token can still be used at now, leaving margin before expiry
+func (t Token) valid(now time.Time, margin time.Duration) bool {
+	if t.Value == "" {
+		return false
+	}
+	return t.Expiry.IsZero() || t.Expiry.Sub(now) > margin
+}
+
+// TokenSource supplies IAM access tokens, e.g. from the GCE metadata server or
+// a Google credentials library. Implementations must be safe for concurrent use.
+type TokenSource interface {
+	Token(ctx context.Context) (Token, error)
+}
+
+// TokenSourceFunc adapts a function to a TokenSource
+type TokenSourceFunc func(ctx context.Context) (Token, error)
+
+// Token implements TokenSource
+func (f TokenSourceFunc) Token(ctx context.Context) (Token, error) {
+	return f(ctx)
+}
+
+// cachingTokenSource reuses a token until it is about to expire
+type cachingTokenSource struct {
+	source        TokenSource
+	refreshBefore time.Duration
+
+	mu    sync.Mutex
+	token Token
+}
+
+// NewCachingTokenSource wraps source so a token is fetched only when the cached one is within
+// refreshBefore of its expiry (default: 5m). If a refresh fails while the cached token has not
+// yet expired, the cached token is returned.
+func NewCachingTokenSource(source TokenSource, refreshBefore time.Duration) TokenSource {
+	if cached, ok := source.(*cachingTokenSource); ok {
+		return cached
+	}
+	if refreshBefore <= 0 {
+		refreshBefore = DefaultTokenRefreshBefore
+	}
+	return &cachingTokenSource{source: source, refreshBefore: refreshBefore}
+}
+
+// Token implements TokenSource
+func (c *cachingTokenSource) Token(ctx context.Context) (Token, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if c.token.valid(now, c.refreshBefore) {
+		return c.token, nil
+	}
+
+	token, err := c.source.Token(ctx)
+	if err != nil {
+		if c.token.valid(now, 0) {
+			return c.token, nil
+		}
+		return Token{}, errors.Wrap(err, errors.ErrCodeDatabaseError, "failed to obtain database IAM token")
+	}
+	if token.Value == "" {
+		return Token{}, errors.New(errors.ErrCodeDatabaseError, "database IAM token source returned an empty token")
+	}
+
+	c.token = token
+	return token, nil
+}
+
+// iamBeforeConnect returns a pgx hook that authenticates every new connection with a token
+func iamBeforeConnect(source TokenSource) stdlib.OptionOpenDB {
+	return stdlib.OptionBeforeConnect(func(ctx context.Context, connConfig *pgx.ConnConfig) error {
+		token, err := source.Token(ctx)
+		if err != nil {
+			return err
+		}
+		connConfig.Password = token.Value
+		return nil
+	})
+}
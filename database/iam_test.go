@@ -0,0 +1,318 @@
+package database
+
+import (
+	"context"
+	stderrors "errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/medbai2/common-go/errors"
+
+	"github.com/jackc/pgx/v5/pgproto3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTokenSource hands out numbered tokens and counts fetches
+type fakeTokenSource struct {
+	ttl   time.Duration
+	err   error
+	calls atomic.Int32
+}
+
+func (f *fakeTokenSource) Token(ctx context.Context) (Token, error) {
+	n := f.calls.Add(1)
+	if f.err != nil {
+		return Token{}, f.err
+	}
+	return Token{Value: "token-" + string(rune('0'+n)), Expiry: time.Now().Add(f.ttl)}, nil
+}
+
+// pgStub is a minimal PostgreSQL wire-protocol server that requires cleartext password
+// authentication and answers every simple query with an empty result
+type pgStub struct {
+	listener net.Listener
+	accept   func(password string) bool
+
+	mu        sync.Mutex
+	passwords []string
+	queries   []string
+	wg        sync.WaitGroup
+}
+
+func newPGStub(t *testing.T, accept func(password string) bool) *pgStub {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	stub := &pgStub{listener: listener, accept: accept}
+	go stub.serve()
+	t.Cleanup(func() {
+		listener.Close()
+		stub.wg.Wait()
+	})
+	return stub
+}
+
+func (s *pgStub) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *pgStub) seenPasswords() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.passwords...)
+}
+
+func (s *pgStub) seenQueries() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.queries...)
+}
+
+func (s *pgStub) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer conn.Close()
+			s.handle(conn)
+		}()
+	}
+}
+
+func (s *pgStub) handle(conn net.Conn) {
+	backend := pgproto3.NewBackend(conn, conn)
+
+	startup, err := backend.ReceiveStartupMessage()
+	if err != nil {
+		return
+	}
+	if _, ok := startup.(*pgproto3.SSLRequest); ok {
+		if _, err := conn.Write([]byte("N")); err != nil {
+			return
+		}
+		if _, err := backend.ReceiveStartupMessage(); err != nil {
+			return
+		}
+	}
+
+	backend.Send(&pgproto3.AuthenticationCleartextPassword{})
+	if err := backend.Flush(); err != nil {
+		return
+	}
+	backend.SetAuthType(pgproto3.AuthTypeCleartextPassword)
+	msg, err := backend.Receive()
+	if err != nil {
+		return
+	}
+	password, ok := msg.(*pgproto3.PasswordMessage)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	s.passwords = append(s.passwords, password.Password)
+	s.mu.Unlock()
+
+	if !s.accept(password.Password) {
+		backend.Send(&pgproto3.ErrorResponse{Severity: "FATAL", Code: "28P01", Message: "password authentication failed"})
+		backend.Flush()
+		return
+	}
+
+	backend.Send(&pgproto3.AuthenticationOk{})
+	backend.Send(&pgproto3.ParameterStatus{Name: "server_version", Value: "16.2"})
+	backend.Send(&pgproto3.ParameterStatus{Name: "standard_conforming_strings", Value: "on"})
+	backend.Send(&pgproto3.ParameterStatus{Name: "client_encoding", Value: "UTF8"})
+	backend.Send(&pgproto3.BackendKeyData{ProcessID: 1, SecretKey: 1})
+	backend.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+	if err := backend.Flush(); err != nil {
+		return
+	}
+
+	for {
+		msg, err := backend.Receive()
+		if err != nil {
+			return
+		}
+		switch msg := msg.(type) {
+		case *pgproto3.Query:
+			s.mu.Lock()
+			s.queries = append(s.queries, msg.String)
+			s.mu.Unlock()
+			backend.Send(&pgproto3.EmptyQueryResponse{})
+			backend.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+			if err := backend.Flush(); err != nil {
+				return
+			}
+		case *pgproto3.Terminate:
+			return
+		}
+	}
+}
+
+// iamTestConfig returns an IAM configuration pointing at the stub
+func iamTestConfig(stub *pgStub, source TokenSource) Config {
+	return Config{
+		Host:        "127.0.0.1",
+		Port:        stub.port(),
+		Name:        "app",
+		User:        "svc@project.iam",
+		AuthType:    AuthTypeIAM,
+		TokenSource: source,
+		SSLMode:     "disable",
+		Logger:      newRecordingLogger(),
+	}
+}
+
+func TestNew_IAMTokenPerConnection(t *testing.T) {
+	source := &fakeTokenSource{ttl: time.Hour}
+	stub := newPGStub(t, func(password string) bool { return password == "token-1" })
+
+	db, err := New(iamTestConfig(stub, source))
+	require.NoError(t, err)
+	defer Close(db)
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+
+	// Force a second physical connection; the cached token is reused
+	ctx := context.Background()
+	conn1, err := sqlDB.Conn(ctx)
+	require.NoError(t, err)
+	conn2, err := sqlDB.Conn(ctx)
+	require.NoError(t, err)
+	require.NoError(t, conn1.PingContext(ctx))
+	require.NoError(t, conn2.PingContext(ctx))
+	conn1.Close()
+	conn2.Close()
+
+	passwords := stub.seenPasswords()
+	require.GreaterOrEqual(t, len(passwords), 2)
+	for _, password := range passwords {
+		assert.Equal(t, "token-1", password)
+	}
+	assert.Equal(t, int32(1), source.calls.Load())
+}
+
+func TestNew_IAMTokenRefreshedBeforeExpiry(t *testing.T) {
+	// Tokens expiring within the refresh margin are replaced for every new connection
+	source := &fakeTokenSource{ttl: time.Minute}
+	stub := newPGStub(t, func(password string) bool { return password != "" })
+
+	db, err := New(iamTestConfig(stub, source))
+	require.NoError(t, err)
+	defer Close(db)
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	conn, err := sqlDB.Conn(context.Background())
+	require.NoError(t, err)
+	defer conn.Close()
+	conn2, err := sqlDB.Conn(context.Background())
+	require.NoError(t, err)
+	defer conn2.Close()
+
+	passwords := stub.seenPasswords()
+	require.GreaterOrEqual(t, len(passwords), 2)
+	assert.NotEqual(t, passwords[0], passwords[len(passwords)-1])
+}
+
+func TestNew_IAMTokenSourceError(t *testing.T) {
+	source := &fakeTokenSource{err: stderrors.New("metadata server unavailable")}
+	stub := newPGStub(t, func(string) bool { return true })
+
+	db, err := New(iamTestConfig(stub, source))
+
+	assert.Nil(t, db)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "metadata server unavailable")
+	assert.Empty(t, stub.seenPasswords())
+}
+
+func TestNew_IAMTokenRejected(t *testing.T) {
+	source := &fakeTokenSource{ttl: time.Hour}
+	stub := newPGStub(t, func(string) bool { return false })
+
+	db, err := New(iamTestConfig(stub, source))
+
+	assert.Nil(t, db)
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "token-1")
+}
+
+func TestCachingTokenSource(t *testing.T) {
+	t.Run("Reuses Valid Token", func(t *testing.T) {
+		source := &fakeTokenSource{ttl: time.Hour}
+		cached := NewCachingTokenSource(source, time.Minute)
+
+		first, err := cached.Token(context.Background())
+		require.NoError(t, err)
+		second, err := cached.Token(context.Background())
+		require.NoError(t, err)
+
+		assert.Equal(t, first, second)
+		assert.Equal(t, int32(1), source.calls.Load())
+	})
+
+	t.Run("Refreshes Near Expiry", func(t *testing.T) {
+		source := &fakeTokenSource{ttl: 30 * time.Second}
+		cached := NewCachingTokenSource(source, time.Minute)
+
+		first, err := cached.Token(context.Background())
+		require.NoError(t, err)
+		second, err := cached.Token(context.Background())
+		require.NoError(t, err)
+
+		assert.NotEqual(t, first.Value, second.Value)
+		assert.Equal(t, int32(2), source.calls.Load())
+	})
+
+	t.Run("Falls Back To Unexpired Token On Refresh Failure", func(t *testing.T) {
+		calls := 0
+		cached := NewCachingTokenSource(TokenSourceFunc(func(ctx context.Context) (Token, error) {
+			calls++
+			if calls > 1 {
+				return Token{}, stderrors.New("refresh failed")
+			}
+			return Token{Value: "still-valid", Expiry: time.Now().Add(30 * time.Second)}, nil
+		}), time.Minute)
+
+		_, err := cached.Token(context.Background())
+		require.NoError(t, err)
+		token, err := cached.Token(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "still-valid", token.Value)
+	})
+
+	t.Run("Error Without Cached Token", func(t *testing.T) {
+		cached := NewCachingTokenSource(&fakeTokenSource{err: stderrors.New("denied")}, 0)
+
+		_, err := cached.Token(context.Background())
+
+		appErr := errors.GetAppError(err)
+		require.NotNil(t, appErr)
+		assert.Equal(t, errors.ErrCodeDatabaseError, appErr.Code)
+	})
+
+	t.Run("Empty Token Is Rejected", func(t *testing.T) {
+		cached := NewCachingTokenSource(TokenSourceFunc(func(ctx context.Context) (Token, error) {
+			return Token{}, nil
+		}), 0)
+
+		_, err := cached.Token(context.Background())
+		assert.Error(t, err)
+	})
+
+	t.Run("Not Wrapped Twice", func(t *testing.T) {
+		cached := NewCachingTokenSource(&fakeTokenSource{}, 0)
+		assert.Same(t, cached, NewCachingTokenSource(cached, 0))
+	})
+}
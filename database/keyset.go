@@ -0,0 +1,249 @@
+package database
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/medbai2/common-go/errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultKeysetLimit is the page size used when KeysetPage.Limit is not set
+const DefaultKeysetLimit = 20
+
+// SortColumn is one column of a keyset sort order
+type SortColumn struct {
+	Name string // Column name, optionally table-qualified (e.g. "orders.created_at")
+	Desc bool   // Sort descending
+}
+
+// SortKey identifies a sort order. Cursors carry it so a cursor issued for one sort
+// order is rejected when used with another.
+func SortKey(columns []SortColumn) string {
+	parts := make([]string, 0, len(columns))
+	for _, col := range columns {
+		direction := "asc"
+		if col.Desc {
+			direction = "desc"
+		}
+		parts = append(parts, col.Name+" "+direction)
+	}
+	sum := sha256.Sum256([]byte(strings.Join(parts, ",")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Keyset returns a scope that orders by columns and, when cursor is set, keeps only the
+// rows after it (or before it, for CursorPrev, in which case rows come back in reverse
+// order). Rows are compared with WHERE (a, b) > (?, ?) when all columns sort in the same
+// direction, which PostgreSQL serves from a matching composite index.
+//
+// The ordering is only stable when the last column is unique (typically the primary key),
+// and sort columns must be NOT NULL. limit > 0 adds a LIMIT.
+//
+// Usage:
+//
+//	db.Scopes(database.Keyset(columns, &cursor, 20)).Find(&orders)
+func Keyset(columns []SortColumn, cursor *Cursor, limit int) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if len(columns) == 0 {
+			db.AddError(errors.NewMissingField("sort columns"))
+			return db
+		}
+
+		backward := cursor != nil && cursor.Direction == CursorPrev
+		for _, col := range columns {
+			db = db.Order(clause.OrderByColumn{Column: clause.Column{Name: col.Name}, Desc: col.Desc != backward})
+		}
+		if limit > 0 {
+			db = db.Limit(limit)
+		}
+		if cursor == nil {
+			return db
+		}
+		if len(cursor.Values) != len(columns) {
+			db.AddError(invalidCursorError())
+			return db
+		}
+
+		sql, vars := keysetCondition(db.Statement, columns, cursor.Values, backward)
+		return db.Where(sql, vars...)
+	}
+}
+
+// keysetCondition builds the condition selecting rows past values in sort order
+func keysetCondition(stmt *gorm.Statement, columns []SortColumn, values []interface{}, backward bool) (string, []interface{}) {
+	quoted := make([]string, len(columns))
+	uniform := true
+	for i, col := range columns {
+		quoted[i] = stmt.Quote(col.Name)
+		if col.Desc != columns[0].Desc {
+			uniform = false
+		}
+	}
+
+	operator := func(desc bool) string {
+		if desc != backward {
+			return "<"
+		}
+		return ">"
+	}
+
+	if uniform {
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")
+		return fmt.Sprintf("(%s) %s (%s)", strings.Join(quoted, ", "), operator(columns[0].Desc), placeholders), values
+	}
+
+	// Mixed directions: (a > ?) OR (a = ? AND b < ?) OR ...
+	var clauses []string
+	var vars []interface{}
+	for i, col := range columns {
+		var parts []string
+		for j := 0; j < i; j++ {
+			parts = append(parts, quoted[j]+" = ?")
+			vars = append(vars, values[j])
+		}
+		parts = append(parts, fmt.Sprintf("%s %s ?", quoted[i], operator(col.Desc)))
+		vars = append(vars, values[i])
+		clauses = append(clauses, "("+strings.Join(parts, " AND ")+")")
+	}
+	return "(" + strings.Join(clauses, " OR ") + ")", vars
+}
+
+// KeysetPage requests one page of a keyset-paginated query
+type KeysetPage struct {
+	Columns []SortColumn // Sort order; the last column must be unique
+	Cursor  string       // Opaque cursor from a previous page (empty for the first page)
+	Limit   int          // Page size (default: 20)
+}
+
+// Page is one page of results with the cursors to continue from
+type Page[T any] struct {
+	Items      []T
+	NextCursor string // Continues after the last item; empty when there is nothing more
+	PrevCursor string // Continues before the first item; empty on the first page
+	HasMore    bool   // Whether items follow this page (NextCursor is set)
+}
+
+// FindPage loads one page of T from db (which may carry further conditions) ordered by
+// page.Columns, fetching one extra row to tell whether more pages follow. Cursors are
+// signed with codec; a cursor that was tampered with or issued for another sort order
+// is rejected with ErrCodeInvalidInput.
+//
+// Usage:
+//
+//	page, err := database.FindPage[Order](db.Where("customer_id = ?", id), codec, database.KeysetPage{
+//		Columns: []database.SortColumn{{Name: "created_at", Desc: true}, {Name: "id", Desc: true}},
+//		Cursor:  params.Cursor,
+//		Limit:   params.Limit,
+//	})
+func FindPage[T any](db *gorm.DB, codec *CursorCodec, page KeysetPage) (*Page[T], error) {
+	if db == nil {
+		return nil, errors.New(errors.ErrCodeDatabaseError, "database is nil")
+	}
+	if codec == nil {
+		return nil, errors.NewMissingField("cursor codec")
+	}
+	if page.Limit <= 0 {
+		page.Limit = DefaultKeysetLimit
+	}
+	key := SortKey(page.Columns)
+
+	var cursor *Cursor
+	if page.Cursor != "" {
+		decoded, err := codec.Decode(page.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if decoded.Key != key {
+			return nil, invalidCursorError()
+		}
+		cursor = &decoded
+	}
+	backward := cursor != nil && cursor.Direction == CursorPrev
+
+	var items []T
+	if err := db.Scopes(Keyset(page.Columns, cursor, page.Limit+1)).Find(&items).Error; err != nil {
+		return nil, TranslateError(err)
+	}
+
+	more := len(items) > page.Limit
+	if more {
+		items = items[:page.Limit]
+	}
+	if backward {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+	}
+
+	result := &Page[T]{Items: items}
+	if len(items) == 0 {
+		return result, nil
+	}
+
+	// Going forward, more rows means a next page and any cursor means a previous one;
+	// going backward it is the other way round
+	hasNext, hasPrev := more, cursor != nil
+	if backward {
+		hasNext, hasPrev = true, more
+	}
+
+	var err error
+	if hasNext {
+		if result.NextCursor, err = encodeBoundary(db, codec, key, page.Columns, items[len(items)-1], CursorNext); err != nil {
+			return nil, err
+		}
+		result.HasMore = true
+	}
+	if hasPrev {
+		if result.PrevCursor, err = encodeBoundary(db, codec, key, page.Columns, items[0], CursorPrev); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// encodeBoundary encodes a cursor positioned at item
+func encodeBoundary[T any](db *gorm.DB, codec *CursorCodec, key string, columns []SortColumn, item T, direction CursorDirection) (string, error) {
+	values, err := sortValues(db, columns, &item)
+	if err != nil {
+		return "", err
+	}
+	return codec.Encode(Cursor{Key: key, Values: values, Direction: direction})
+}
+
+// sortValues reads the sort column values from a model using its GORM schema
+func sortValues(db *gorm.DB, columns []SortColumn, model interface{}) ([]interface{}, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return nil, errors.Wrap(err, errors.ErrCodeInternal, "failed to parse model for pagination")
+	}
+
+	rv := reflect.ValueOf(model).Elem()
+	values := make([]interface{}, 0, len(columns))
+	for _, col := range columns {
+		name := col.Name
+		if i := strings.LastIndex(name, "."); i >= 0 {
+			name = name[i+1:]
+		}
+		field := stmt.Schema.LookUpField(name)
+		if field == nil {
+			return nil, errors.New(errors.ErrCodeInternal, fmt.Sprintf("sort column %q is not a field of %s", col.Name, stmt.Schema.Name))
+		}
+
+		value, _ := field.ValueOf(db.Statement.Context, rv)
+		if v := reflect.ValueOf(value); v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return nil, errors.New(errors.ErrCodeInternal, fmt.Sprintf("sort column %q is NULL", col.Name))
+			}
+			value = v.Elem().Interface()
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
@@ -0,0 +1,181 @@
+package database
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/medbai2/common-go/errors"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// keysetItem is the model used by the pagination tests
+type keysetItem struct {
+	ID        int64
+	CreatedAt time.Time
+	Name      string
+}
+
+// orderedColumns sorts newest first with the ID as tie-breaker
+var orderedColumns = []SortColumn{{Name: "created_at", Desc: true}, {Name: "id", Desc: true}}
+
+func TestKeyset_SQL(t *testing.T) {
+	db, _ := newHealthTestDB(t)
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		Name        string
+		Columns     []SortColumn
+		Cursor      *Cursor
+		ExpectedSQL string
+	}{
+		{
+			Name:        "First Page",
+			Columns:     orderedColumns,
+			ExpectedSQL: `SELECT * FROM "keyset_items" ORDER BY "created_at" DESC,"id" DESC LIMIT $1`,
+		},
+		{
+			Name:        "Forward",
+			Columns:     orderedColumns,
+			Cursor:      &Cursor{Values: []interface{}{at, int64(5)}},
+			ExpectedSQL: `SELECT * FROM "keyset_items" WHERE ("created_at", "id") < ($1, $2) ORDER BY "created_at" DESC,"id" DESC LIMIT $3`,
+		},
+		{
+			Name:        "Backward",
+			Columns:     orderedColumns,
+			Cursor:      &Cursor{Values: []interface{}{at, int64(5)}, Direction: CursorPrev},
+			ExpectedSQL: `SELECT * FROM "keyset_items" WHERE ("created_at", "id") > ($1, $2) ORDER BY "created_at","id" LIMIT $3`,
+		},
+		{
+			Name:        "Mixed Directions",
+			Columns:     []SortColumn{{Name: "name"}, {Name: "keyset_items.id", Desc: true}},
+			Cursor:      &Cursor{Values: []interface{}{"b", int64(5)}},
+			ExpectedSQL: `SELECT * FROM "keyset_items" WHERE (("name" > $1) OR ("name" = $2 AND "keyset_items"."id" < $3)) ORDER BY "name","keyset_items"."id" DESC LIMIT $4`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			stmt := db.Session(&gorm.Session{DryRun: true}).Scopes(Keyset(tc.Columns, tc.Cursor, 11)).Find(&[]keysetItem{}).Statement
+			assert.Equal(t, tc.ExpectedSQL, stmt.SQL.String())
+		})
+	}
+}
+
+func TestKeyset_CursorMismatch(t *testing.T) {
+	db, _ := newHealthTestDB(t)
+
+	err := db.Session(&gorm.Session{DryRun: true}).
+		Scopes(Keyset(orderedColumns, &Cursor{Values: []interface{}{int64(1)}}, 10)).
+		Find(&[]keysetItem{}).Error
+
+	appErr := errors.GetAppError(err)
+	require.NotNil(t, appErr)
+	assert.Equal(t, errors.ErrCodeInvalidInput, appErr.Code)
+}
+
+// keysetRows builds result rows for items with IDs ids, created one minute apart
+func keysetRows(base time.Time, ids ...int64) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"id", "created_at", "name"})
+	for _, id := range ids {
+		rows.AddRow(id, base.Add(time.Duration(id)*time.Minute), "item")
+	}
+	return rows
+}
+
+func TestFindPage(t *testing.T) {
+	codec := newTestCursorCodec(t)
+	db, mock := newHealthTestDB(t)
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// First page: 3 rows requested, 4 fetched, so there is more
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "keyset_items" WHERE name = $1 ORDER BY "created_at" DESC,"id" DESC LIMIT $2`)).
+		WithArgs("item", 4).
+		WillReturnRows(keysetRows(base, 10, 9, 8, 7))
+
+	first, err := FindPage[keysetItem](db.Where("name = ?", "item"), codec, KeysetPage{Columns: orderedColumns, Limit: 3})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{10, 9, 8}, keysetIDs(first.Items))
+	assert.True(t, first.HasMore)
+	assert.NotEmpty(t, first.NextCursor)
+	assert.Empty(t, first.PrevCursor, "first page has no previous page")
+
+	// Second page continues after item 8 and is the last one
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "keyset_items" WHERE ("created_at", "id") < ($1, $2) ORDER BY "created_at" DESC,"id" DESC LIMIT $3`)).
+		WithArgs(base.Add(8*time.Minute), int64(8), 4).
+		WillReturnRows(keysetRows(base, 7, 6))
+
+	second, err := FindPage[keysetItem](db, codec, KeysetPage{Columns: orderedColumns, Cursor: first.NextCursor, Limit: 3})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{7, 6}, keysetIDs(second.Items))
+	assert.False(t, second.HasMore)
+	assert.Empty(t, second.NextCursor)
+	assert.NotEmpty(t, second.PrevCursor)
+
+	// Going back from the second page returns to the first, in display order
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "keyset_items" WHERE ("created_at", "id") > ($1, $2) ORDER BY "created_at","id" LIMIT $3`)).
+		WithArgs(base.Add(7*time.Minute), int64(7), 4).
+		WillReturnRows(keysetRows(base, 8, 9, 10))
+
+	back, err := FindPage[keysetItem](db, codec, KeysetPage{Columns: orderedColumns, Cursor: second.PrevCursor, Limit: 3})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{10, 9, 8}, keysetIDs(back.Items))
+	assert.True(t, back.HasMore)
+	assert.NotEmpty(t, back.NextCursor)
+	assert.Empty(t, back.PrevCursor, "nothing before the first page")
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFindPage_InvalidCursor(t *testing.T) {
+	codec := newTestCursorCodec(t)
+	db, mock := newHealthTestDB(t)
+
+	// A valid cursor issued for a different sort order
+	foreign, err := codec.Encode(Cursor{Key: SortKey([]SortColumn{{Name: "id"}}), Values: []interface{}{int64(1)}})
+	require.NoError(t, err)
+
+	for _, cursor := range []string{"not-a-cursor", foreign} {
+		_, err := FindPage[keysetItem](db, codec, KeysetPage{Columns: orderedColumns, Cursor: cursor})
+
+		appErr := errors.GetAppError(err)
+		require.NotNil(t, appErr)
+		assert.Equal(t, errors.ErrCodeInvalidInput, appErr.Code)
+	}
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFindPage_Empty(t *testing.T) {
+	db, mock := newHealthTestDB(t)
+	mock.ExpectQuery(`SELECT`).WillReturnRows(keysetRows(time.Now()))
+
+	page, err := FindPage[keysetItem](db, newTestCursorCodec(t), KeysetPage{Columns: orderedColumns})
+	require.NoError(t, err)
+
+	assert.Empty(t, page.Items)
+	assert.False(t, page.HasMore)
+	assert.Empty(t, page.NextCursor)
+}
+
+func TestFindPage_UnknownColumn(t *testing.T) {
+	db, mock := newHealthTestDB(t)
+	mock.ExpectQuery(`SELECT`).WillReturnRows(keysetRows(time.Now(), 2, 1))
+
+	_, err := FindPage[keysetItem](db, newTestCursorCodec(t), KeysetPage{Columns: []SortColumn{{Name: "missing"}}, Limit: 1})
+
+	appErr := errors.GetAppError(err)
+	require.NotNil(t, appErr)
+	assert.Equal(t, errors.ErrCodeInternal, appErr.Code)
+}
+
+func keysetIDs(items []keysetItem) []int64 {
+	ids := make([]int64, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+	return ids
+}
@@ -0,0 +1,155 @@
+package database
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/medbai2/common-go/errors"
+	"github.com/medbai2/common-go/logger"
+
+	"gorm.io/gorm"
+)
+
+// Default leader election settings
+const (
+	DefaultLeaderRetryInterval = 5 * time.Second
+	DefaultLeaderCheckInterval = 5 * time.Second
+)
+
+// leaderUnlockTimeout bounds releasing the lock when leadership ends
+const leaderUnlockTimeout = 5 * time.Second
+
+// LeaderElectionConfig configures a LeaderElector
+type LeaderElectionConfig struct {
+	Name          string                    // Advisory lock name shared by all candidates (required)
+	RetryInterval time.Duration             // How often followers try to take the lock
+	CheckInterval time.Duration             // How often the leader checks the connection holding the lock
+	OnElected     func(ctx context.Context) // Runs in its own goroutine on election; ctx is cancelled when leadership is lost
+	OnRevoked     func()                    // Called once leadership is lost and OnElected has returned
+	Logger        logger.Logger             // default: logger.NewFromEnv("database")
+}
+
+// LeaderElector elects one leader among replicas with a session advisory lock: the
+// replica holding the lock leads until its connection is lost or Run returns, at which
+// point another replica takes the lock within RetryInterval.
+//
+// Leadership is not exclusive in time. When the connection holding the lock drops, the
+// server releases the lock at once, but the old leader only notices at its next check, so
+// it may keep acting as leader for up to CheckInterval (plus the time OnElected takes to
+// return) while another replica has already been elected. Work done under leadership must
+// tolerate that overlap, e.g. by being idempotent or guarded by its own row locks.
+//
+// OnElected must return promptly once its context is cancelled; OnRevoked is called after
+// it does.
+//
+// Usage:
+//
+//	elector, err := database.NewLeaderElector(db, database.LeaderElectionConfig{
+//		Name: "billing:scheduler",
+//		OnElected: func(ctx context.Context) {
+//			scheduler.Run(ctx) // stops when leadership is lost
+//		},
+//	})
+//	go elector.Run(ctx)
+type LeaderElector struct {
+	db     *gorm.DB
+	cfg    LeaderElectionConfig
+	leader atomic.Bool
+}
+
+// NewLeaderElector creates a leader elector on db
+func NewLeaderElector(db *gorm.DB, cfg LeaderElectionConfig) (*LeaderElector, error) {
+	if db == nil {
+		return nil, errors.New(errors.ErrCodeDatabaseError, "database is nil")
+	}
+	if cfg.Name == "" {
+		return nil, errors.NewMissingField("name")
+	}
+	if cfg.RetryInterval <= 0 {
+		cfg.RetryInterval = DefaultLeaderRetryInterval
+	}
+	if cfg.CheckInterval <= 0 {
+		cfg.CheckInterval = DefaultLeaderCheckInterval
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = logger.NewFromEnv("database")
+	}
+	return &LeaderElector{db: db, cfg: cfg}, nil
+}
+
+// IsLeader reports whether this replica currently leads
+func (e *LeaderElector) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// Run campaigns for leadership until ctx is done, then releases leadership if held
+func (e *LeaderElector) Run(ctx context.Context) error {
+	for {
+		lock, acquired, err := TryLock(ctx, e.db, e.cfg.Name)
+		if err != nil && ctx.Err() == nil {
+			e.cfg.Logger.Warn("Leader election attempt failed", map[string]interface{}{
+				"lock":  e.cfg.Name,
+				"error": err.Error(),
+			})
+		}
+		if acquired {
+			e.lead(ctx, lock)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(e.cfg.RetryInterval):
+		}
+	}
+}
+
+// lead holds leadership until ctx is done or the lock connection is lost
+func (e *LeaderElector) lead(ctx context.Context, lock *AdvisoryLock) {
+	fields := map[string]interface{}{"lock": e.cfg.Name}
+	e.leader.Store(true)
+	e.cfg.Logger.Info("Elected leader", fields)
+
+	leaderCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if e.cfg.OnElected != nil {
+			e.cfg.OnElected(leaderCtx)
+		}
+	}()
+
+	ticker := time.NewTicker(e.cfg.CheckInterval)
+	defer ticker.Stop()
+	for held := true; held; {
+		select {
+		case <-ctx.Done():
+			held = false
+		case <-ticker.C:
+			pingCtx, cancelPing := context.WithTimeout(ctx, e.cfg.CheckInterval)
+			err := lock.Ping(pingCtx)
+			cancelPing()
+			if err != nil && ctx.Err() == nil {
+				e.cfg.Logger.Error("Lost leadership", err, fields)
+				held = false
+			}
+		}
+	}
+
+	e.leader.Store(false)
+	cancel()
+	<-done
+
+	unlockCtx, cancelUnlock := context.WithTimeout(context.Background(), leaderUnlockTimeout)
+	defer cancelUnlock()
+	if err := lock.Unlock(unlockCtx); err != nil {
+		// The connection is dropped in that case, which releases the lock anyway
+		e.cfg.Logger.Debug("Failed to release leader lock", map[string]interface{}{"lock": e.cfg.Name, "error": err.Error()})
+	}
+
+	if e.cfg.OnRevoked != nil {
+		e.cfg.OnRevoked()
+	}
+	e.cfg.Logger.Info("Leadership released", fields)
+}
@@ -0,0 +1,129 @@
+package database
+
+import (
+	"context"
+	stderrors "errors"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/medbai2/common-go/errors"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// leaderEvents records leadership callbacks
+type leaderEvents struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (l *leaderEvents) add(event string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, event)
+}
+
+func (l *leaderEvents) list() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.events...)
+}
+
+func expectTryLock(mock sqlmock.Sqlmock, acquired bool) {
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT pg_try_advisory_lock($1)")).
+		WithArgs(LockKey("scheduler")).
+		WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(acquired))
+}
+
+func expectUnlock(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT pg_advisory_unlock($1)")).
+		WithArgs(LockKey("scheduler")).
+		WillReturnRows(sqlmock.NewRows([]string{"pg_advisory_unlock"}).AddRow(true))
+}
+
+func newTestLeaderElector(t *testing.T, events *leaderEvents, checkInterval time.Duration) (*LeaderElector, sqlmock.Sqlmock) {
+	db, mock := newHealthTestDB(t)
+	elector, err := NewLeaderElector(db, LeaderElectionConfig{
+		Name:          "scheduler",
+		RetryInterval: 10 * time.Millisecond,
+		CheckInterval: checkInterval,
+		OnElected: func(ctx context.Context) {
+			events.add("elected")
+			<-ctx.Done()
+			events.add("stopped")
+		},
+		OnRevoked: func() { events.add("revoked") },
+		Logger:    newRecordingLogger(),
+	})
+	require.NoError(t, err)
+	return elector, mock
+}
+
+func TestNewLeaderElector(t *testing.T) {
+	db, _ := newHealthTestDB(t)
+
+	_, err := NewLeaderElector(nil, LeaderElectionConfig{Name: "scheduler"})
+	assert.Equal(t, errors.ErrCodeDatabaseError, errors.GetAppError(err).Code)
+
+	_, err = NewLeaderElector(db, LeaderElectionConfig{})
+	assert.Equal(t, errors.ErrCodeMissingField, errors.GetAppError(err).Code)
+
+	elector, err := NewLeaderElector(db, LeaderElectionConfig{Name: "scheduler", Logger: newRecordingLogger()})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultLeaderRetryInterval, elector.cfg.RetryInterval)
+	assert.Equal(t, DefaultLeaderCheckInterval, elector.cfg.CheckInterval)
+	assert.False(t, elector.IsLeader())
+}
+
+func TestLeaderElector_ReleasesOnShutdown(t *testing.T) {
+	events := &leaderEvents{}
+	elector, mock := newTestLeaderElector(t, events, time.Hour)
+
+	expectTryLock(mock, true)
+	expectUnlock(mock)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- elector.Run(ctx) }()
+
+	require.Eventually(t, func() bool { return len(events.list()) == 1 }, time.Second, 5*time.Millisecond)
+	assert.True(t, elector.IsLeader())
+	cancel()
+	require.NoError(t, <-done)
+
+	assert.False(t, elector.IsLeader())
+	assert.Equal(t, []string{"elected", "stopped", "revoked"}, events.list())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLeaderElector_ReelectsAfterConnectionLoss(t *testing.T) {
+	events := &leaderEvents{}
+	elector, mock := newTestLeaderElector(t, events, 10*time.Millisecond)
+
+	expectTryLock(mock, true)
+	mock.ExpectPing().WillReturnError(stderrors.New("connection reset"))
+	expectUnlock(mock)
+	// Another replica took over meanwhile
+	expectTryLock(mock, false)
+	expectTryLock(mock, true)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- elector.Run(ctx) }()
+
+	require.Eventually(t, func() bool { return len(events.list()) == 4 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []string{"elected", "stopped", "revoked", "elected"}, events.list())
+	assert.True(t, elector.IsLeader())
+
+	mock.MatchExpectationsInOrder(false)
+	mock.ExpectPing()
+	expectUnlock(mock)
+	cancel()
+	require.NoError(t, <-done)
+	assert.False(t, elector.IsLeader())
+}
@@ -0,0 +1,196 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/binary"
+	"fmt"
+	"sync"
+
+	"github.com/medbai2/common-go/errors"
+
+	"gorm.io/gorm"
+)
+
+// LockKey hashes a lock name to the int64 key used by Postgres advisory locks. Every
+// replica derives the same key from the same name.
+func LockKey(name string) int64 {
+	sum := sha256.Sum256([]byte(name))
+	return int64(binary.BigEndian.Uint64(sum[:8]))
+}
+
+// AdvisoryLock is a session-level advisory lock held on a dedicated connection taken
+// from the pool. The lock lasts until Unlock, or until the connection is lost.
+type AdvisoryLock struct {
+	name string
+	key  int64
+
+	mu   sync.Mutex
+	conn *sql.Conn
+}
+
+// TryLock acquires the named session lock if it is free. It returns acquired=false,
+// without error, when another session holds it.
+//
+// Usage:
+//
+//	lock, acquired, err := database.TryLock(ctx, db, "billing:nightly-invoices")
+//	if err != nil || !acquired {
+//		return err
+//	}
+//	defer lock.Unlock(context.Background())
+func TryLock(ctx context.Context, db *gorm.DB, name string) (*AdvisoryLock, bool, error) {
+	lock, err := newAdvisoryLock(ctx, db, name)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var acquired bool
+	if err := lock.conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", lock.key).Scan(&acquired); err != nil {
+		discardConn(lock.conn)
+		return nil, false, lockError(ctx, err, name)
+	}
+	if !acquired {
+		lock.conn.Close()
+		return nil, false, nil
+	}
+	return lock, true, nil
+}
+
+// Lock acquires the named session lock, waiting while another session holds it. Waiting
+// stops with ErrCodeTimeout when ctx is done, so pass a context with a deadline.
+func Lock(ctx context.Context, db *gorm.DB, name string) (*AdvisoryLock, error) {
+	lock, err := newAdvisoryLock(ctx, db, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := lock.conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lock.key); err != nil {
+		// A cancelled wait may race with the grant, so the connection cannot be reused
+		discardConn(lock.conn)
+		return nil, lockError(ctx, err, name)
+	}
+	return lock, nil
+}
+
+// newAdvisoryLock reserves a dedicated connection for a session lock
+func newAdvisoryLock(ctx context.Context, db *gorm.DB, name string) (*AdvisoryLock, error) {
+	if db == nil {
+		return nil, errors.New(errors.ErrCodeDatabaseError, "database is nil")
+	}
+	if name == "" {
+		return nil, errors.NewMissingField("name")
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrCodeDatabaseError, "failed to get database instance")
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, lockError(ctx, err, name)
+	}
+	return &AdvisoryLock{name: name, key: LockKey(name), conn: conn}, nil
+}
+
+// Name returns the lock name
+func (l *AdvisoryLock) Name() string {
+	return l.name
+}
+
+// Ping checks that the connection holding the lock is still alive. A lost connection
+// means the lock has been released by the server.
+func (l *AdvisoryLock) Ping(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.conn == nil {
+		return errors.New(errors.ErrCodeConflict, fmt.Sprintf("advisory lock %q is released", l.name))
+	}
+	if err := l.conn.PingContext(ctx); err != nil {
+		return errors.Wrap(err, errors.ErrCodeDatabaseError, fmt.Sprintf("lost connection holding advisory lock %q", l.name))
+	}
+	return nil
+}
+
+// Unlock releases the lock and returns its connection to the pool. It is safe to call
+// more than once. Even when the unlock statement fails the connection is closed, which
+// also releases the lock.
+func (l *AdvisoryLock) Unlock(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.conn == nil {
+		return nil
+	}
+	conn := l.conn
+	l.conn = nil
+
+	var released bool
+	err := conn.QueryRowContext(ctx, "SELECT pg_advisory_unlock($1)", l.key).Scan(&released)
+	if err != nil {
+		discardConn(conn)
+		return lockError(ctx, err, l.name)
+	}
+	conn.Close()
+	if !released {
+		return errors.New(errors.ErrCodeConflict, fmt.Sprintf("advisory lock %q was not held", l.name))
+	}
+	return nil
+}
+
+// TryLockTx acquires the named lock for the rest of the transaction carried by ctx or tx
+// (see WithTx) if it is free. The lock is released at commit or rollback.
+func TryLockTx(ctx context.Context, tx *gorm.DB, name string) (bool, error) {
+	db, err := lockTx(ctx, tx, name)
+	if err != nil {
+		return false, err
+	}
+	var acquired bool
+	if err := db.Raw("SELECT pg_try_advisory_xact_lock(?)", LockKey(name)).Scan(&acquired).Error; err != nil {
+		return false, lockError(ctx, err, name)
+	}
+	return acquired, nil
+}
+
+// LockTx acquires the named lock for the rest of the transaction carried by ctx or tx,
+// waiting while another session holds it. Waiting stops with ErrCodeTimeout when ctx is done.
+func LockTx(ctx context.Context, tx *gorm.DB, name string) error {
+	db, err := lockTx(ctx, tx, name)
+	if err != nil {
+		return err
+	}
+	if err := db.Exec("SELECT pg_advisory_xact_lock(?)", LockKey(name)).Error; err != nil {
+		return lockError(ctx, err, name)
+	}
+	return nil
+}
+
+// lockTx returns the transaction for a transaction-level lock. Outside a transaction such
+// a lock would be released as soon as it is taken, so that is an error.
+func lockTx(ctx context.Context, tx *gorm.DB, name string) (*gorm.DB, error) {
+	if tx == nil {
+		return nil, errors.New(errors.ErrCodeDatabaseError, "database is nil")
+	}
+	if name == "" {
+		return nil, errors.NewMissingField("name")
+	}
+	db := Conn(ctx, tx)
+	if _, ok := db.Statement.ConnPool.(gorm.TxCommitter); !ok {
+		return nil, errors.New(errors.ErrCodeInternal, "transaction-level advisory locks require a transaction")
+	}
+	return db, nil
+}
+
+// discardConn closes conn without returning it to the pool, so a lock it may still hold
+// is released by the server instead of leaking to the next user of the connection
+func discardConn(conn *sql.Conn) {
+	conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+}
+
+// lockError converts a lock statement error into an AppError
+func lockError(ctx context.Context, err error, name string) error {
+	if ctx.Err() != nil {
+		return errors.Wrap(err, errors.ErrCodeTimeout, fmt.Sprintf("timed out waiting for advisory lock %q", name))
+	}
+	return TranslateError(err)
+}
@@ -0,0 +1,169 @@
+package database
+
+import (
+	"context"
+	stderrors "errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/medbai2/common-go/errors"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestLockKey(t *testing.T) {
+	assert.Equal(t, LockKey("billing:scheduler"), LockKey("billing:scheduler"))
+	assert.NotEqual(t, LockKey("billing:scheduler"), LockKey("billing:invoices"))
+}
+
+func TestTryLock(t *testing.T) {
+	testCases := []struct {
+		Name             string
+		Acquired         bool
+		ExpectedAcquired bool
+	}{
+		{Name: "free lock", Acquired: true, ExpectedAcquired: true},
+		{Name: "held elsewhere", Acquired: false, ExpectedAcquired: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			db, mock := newHealthTestDB(t)
+			key := LockKey("billing:scheduler")
+
+			mock.ExpectQuery(regexp.QuoteMeta("SELECT pg_try_advisory_lock($1)")).
+				WithArgs(key).
+				WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(tc.Acquired))
+			if tc.Acquired {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT pg_advisory_unlock($1)")).
+					WithArgs(key).
+					WillReturnRows(sqlmock.NewRows([]string{"pg_advisory_unlock"}).AddRow(true))
+			}
+
+			lock, acquired, err := TryLock(context.Background(), db, "billing:scheduler")
+			require.NoError(t, err)
+			assert.Equal(t, tc.ExpectedAcquired, acquired)
+			if acquired {
+				assert.Equal(t, "billing:scheduler", lock.Name())
+				require.NoError(t, lock.Unlock(context.Background()))
+				// Unlocking twice is a no-op
+				require.NoError(t, lock.Unlock(context.Background()))
+			} else {
+				assert.Nil(t, lock)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestTryLock_Invalid(t *testing.T) {
+	db, _ := newHealthTestDB(t)
+
+	_, _, err := TryLock(context.Background(), nil, "billing:scheduler")
+	assert.Equal(t, errors.ErrCodeDatabaseError, errors.GetAppError(err).Code)
+
+	_, _, err = TryLock(context.Background(), db, "")
+	assert.Equal(t, errors.ErrCodeMissingField, errors.GetAppError(err).Code)
+}
+
+func TestLock(t *testing.T) {
+	db, mock := newHealthTestDB(t)
+	key := LockKey("billing:scheduler")
+
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_lock($1)")).
+		WithArgs(key).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT pg_advisory_unlock($1)")).
+		WithArgs(key).
+		WillReturnRows(sqlmock.NewRows([]string{"pg_advisory_unlock"}).AddRow(false))
+
+	lock, err := Lock(context.Background(), db, "billing:scheduler")
+	require.NoError(t, err)
+
+	err = lock.Unlock(context.Background())
+	require.Error(t, err)
+	assert.Equal(t, errors.ErrCodeConflict, errors.GetAppError(err).Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLock_Timeout(t *testing.T) {
+	db, mock := newHealthTestDB(t)
+
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_lock($1)")).
+		WillDelayFor(time.Second).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := Lock(ctx, db, "billing:scheduler")
+	require.Error(t, err)
+	assert.Equal(t, errors.ErrCodeTimeout, errors.GetAppError(err).Code)
+}
+
+func TestAdvisoryLock_Ping(t *testing.T) {
+	db, mock := newHealthTestDB(t)
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT pg_try_advisory_lock($1)")).
+		WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(true))
+	mock.ExpectPing()
+	mock.ExpectPing().WillReturnError(stderrors.New("connection reset"))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT pg_advisory_unlock($1)")).
+		WillReturnError(stderrors.New("connection reset"))
+
+	lock, acquired, err := TryLock(context.Background(), db, "billing:scheduler")
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	assert.NoError(t, lock.Ping(context.Background()))
+	err = lock.Ping(context.Background())
+	require.Error(t, err)
+	assert.Equal(t, errors.ErrCodeDatabaseError, errors.GetAppError(err).Code)
+
+	assert.Error(t, lock.Unlock(context.Background()))
+	err = lock.Ping(context.Background())
+	assert.Equal(t, errors.ErrCodeConflict, errors.GetAppError(err).Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLockTx(t *testing.T) {
+	db, mock := newHealthTestDB(t)
+	key := LockKey("orders:42")
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_xact_lock($1)")).
+		WithArgs(key).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT pg_try_advisory_xact_lock($1)")).
+		WithArgs(LockKey("orders:43")).
+		WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_xact_lock"}).AddRow(false))
+	mock.ExpectCommit()
+
+	err := WithTx(context.Background(), db, nil, func(ctx context.Context, tx *gorm.DB) error {
+		if err := LockTx(ctx, tx, "orders:42"); err != nil {
+			return err
+		}
+		acquired, err := TryLockTx(ctx, tx, "orders:43")
+		require.NoError(t, err)
+		assert.False(t, acquired)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLockTx_OutsideTransaction(t *testing.T) {
+	db, mock := newHealthTestDB(t)
+
+	err := LockTx(context.Background(), db, "orders:42")
+	require.Error(t, err)
+	assert.Equal(t, errors.ErrCodeInternal, errors.GetAppError(err).Code)
+
+	_, err = TryLockTx(context.Background(), db, "orders:42")
+	require.Error(t, err)
+	assert.Equal(t, errors.ErrCodeInternal, errors.GetAppError(err).Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
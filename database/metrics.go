@@ -0,0 +1,171 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/medbai2/common-go/errors"
+	"github.com/medbai2/common-go/internal/metricsutil"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"gorm.io/gorm"
+)
+
+// metricsStartKey is the statement instance key holding the query start time
+const metricsStartKey = "common-go:metrics_start"
+
+// DefaultMetricsPoolName is the default db_name label of the primary pool's go_sql_* metrics
+const DefaultMetricsPoolName = "primary"
+
+// MetricsOptions configures RegisterMetrics
+type MetricsOptions struct {
+	// PoolName is the db_name label of the primary pool's go_sql_* metrics (default:
+	// DefaultMetricsPoolName). Handles registered on the same registry need distinct names.
+	PoolName string
+}
+
+// queryMetrics holds the query duration and error collectors
+type queryMetrics struct {
+	duration *prometheus.HistogramVec
+	errors   *prometheus.CounterVec
+}
+
+// newQueryMetrics registers the query collectors on reg. Collectors already registered by an
+// earlier call (e.g. for a second database handle) are reused.
+func newQueryMetrics(reg prometheus.Registerer) (*queryMetrics, error) {
+	duration, err := metricsutil.Register(reg, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "db_query_duration_seconds",
+		Help:    "Duration of database statements by operation and table.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"operation", "table"}))
+	if err != nil {
+		return nil, err
+	}
+
+	errorCount, err := metricsutil.Register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "db_query_errors_total",
+		Help: "Failed database statements by operation, table and error code.",
+	}, []string{"operation", "table", "code"}))
+	if err != nil {
+		return nil, err
+	}
+
+	return &queryMetrics{duration: duration, errors: errorCount}, nil
+}
+
+// RegisterMetrics exposes database metrics on reg (prometheus.DefaultRegisterer when nil):
+//   - go_sql_* connection pool statistics (open, in use, idle, waits, closed connections),
+//     labelled db_name=opts.PoolName ("primary" when opts is nil) or the replica's host:port
+//   - db_query_duration_seconds histogram by operation and table
+//   - db_query_errors_total counter by operation, table and translated error code
+//
+// Call it once after New. The query collectors are shared by every handle on reg.
+func RegisterMetrics(db *gorm.DB, reg prometheus.Registerer, opts *MetricsOptions) error {
+	if db == nil {
+		return errors.New(errors.ErrCodeDatabaseError, "database is nil")
+	}
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+	poolName := DefaultMetricsPoolName
+	if opts != nil && opts.PoolName != "" {
+		poolName = opts.PoolName
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return errors.Wrap(err, errors.ErrCodeDatabaseError, "failed to get underlying sql.DB")
+	}
+	if err := reg.Register(collectors.NewDBStatsCollector(sqlDB, poolName)); err != nil {
+		return errors.Wrap(err, errors.ErrCodeDatabaseError, fmt.Sprintf("failed to register pool metrics for %s", poolName))
+	}
+	if router := getReplicaRouter(db); router != nil {
+		for _, rep := range router.replicas {
+			if err := reg.Register(collectors.NewDBStatsCollector(rep.pool, rep.address())); err != nil {
+				return errors.Wrap(err, errors.ErrCodeDatabaseError, fmt.Sprintf("failed to register pool metrics for replica %s", rep.address()))
+			}
+		}
+	}
+
+	metrics, err := newQueryMetrics(reg)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrCodeDatabaseError, "failed to register query metrics")
+	}
+	if err := metrics.registerCallbacks(db); err != nil {
+		return errors.Wrap(err, errors.ErrCodeDatabaseError, "failed to register metrics callbacks")
+	}
+	return nil
+}
+
+// registerCallbacks times every create, query, update, delete, row and raw statement
+func (m *queryMetrics) registerCallbacks(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if err := callbacks.Create().Before("gorm:create").Register("common-go:metrics_start_create", startTimer); err != nil {
+		return err
+	}
+	if err := callbacks.Create().After("gorm:create").Register("common-go:metrics_observe_create", m.observe("create")); err != nil {
+		return err
+	}
+	if err := callbacks.Query().Before("gorm:query").Register("common-go:metrics_start_query", startTimer); err != nil {
+		return err
+	}
+	if err := callbacks.Query().After("gorm:query").Register("common-go:metrics_observe_query", m.observe("query")); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("common-go:metrics_start_update", startTimer); err != nil {
+		return err
+	}
+	if err := callbacks.Update().After("gorm:update").Register("common-go:metrics_observe_update", m.observe("update")); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().Before("gorm:delete").Register("common-go:metrics_start_delete", startTimer); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().After("gorm:delete").Register("common-go:metrics_observe_delete", m.observe("delete")); err != nil {
+		return err
+	}
+	if err := callbacks.Row().Before("gorm:row").Register("common-go:metrics_start_row", startTimer); err != nil {
+		return err
+	}
+	if err := callbacks.Row().After("gorm:row").Register("common-go:metrics_observe_row", m.observe("row")); err != nil {
+		return err
+	}
+	if err := callbacks.Raw().Before("gorm:raw").Register("common-go:metrics_start_raw", startTimer); err != nil {
+		return err
+	}
+	return callbacks.Raw().After("gorm:raw").Register("common-go:metrics_observe_raw", m.observe("raw"))
+}
+
+// startTimer records when the statement started
+func startTimer(db *gorm.DB) {
+	db.InstanceSet(metricsStartKey, time.Now())
+}
+
+// observe returns a callback recording the statement's duration and, on failure, its error code
+func (m *queryMetrics) observe(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(metricsStartKey)
+		if !ok {
+			return
+		}
+		start, ok := value.(time.Time)
+		if !ok {
+			return
+		}
+
+		table := db.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+		m.duration.WithLabelValues(operation, table).Observe(time.Since(start).Seconds())
+
+		if db.Error != nil {
+			code := errors.ErrCodeDatabaseError
+			if appErr := errors.GetAppError(TranslateError(db.Error)); appErr != nil {
+				code = appErr.Code
+			}
+			m.errors.WithLabelValues(operation, table, string(code)).Inc()
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package database
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterMetrics_PoolStats(t *testing.T) {
+	db, _ := newMockGormDB(t)
+	reg := prometheus.NewRegistry()
+
+	require.NoError(t, RegisterMetrics(db, reg, nil))
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+
+	names := map[string]bool{}
+	for _, family := range families {
+		names[family.GetName()] = true
+	}
+	for _, name := range []string{
+		"go_sql_open_connections",
+		"go_sql_in_use_connections",
+		"go_sql_idle_connections",
+		"go_sql_wait_count_total",
+		"go_sql_wait_duration_seconds_total",
+		"go_sql_max_lifetime_closed_total",
+	} {
+		assert.True(t, names[name], "missing metric %s", name)
+	}
+}
+
+func TestRegisterMetrics_QueryDuration(t *testing.T) {
+	db, mock := newMockGormDB(t)
+	reg := prometheus.NewRegistry()
+	require.NoError(t, RegisterMetrics(db, reg, nil))
+
+	mock.ExpectQuery(`SELECT \* FROM "users"`).WillReturnRows(sqlmock.NewRows([]string{"id", "email"}))
+	mock.ExpectExec(`UPDATE users`).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	var users []replicaTestUser
+	require.NoError(t, db.Find(&users).Error)
+	require.NoError(t, db.Exec("UPDATE users SET email = ''").Error)
+
+	count, err := testutil.GatherAndCount(reg, "db_query_duration_seconds")
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	var labels []string
+	for _, family := range families {
+		if family.GetName() != "db_query_duration_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			var pairs []string
+			for _, label := range metric.GetLabel() {
+				pairs = append(pairs, label.GetName()+"="+label.GetValue())
+			}
+			labels = append(labels, strings.Join(pairs, ","))
+			assert.Equal(t, uint64(1), metric.GetHistogram().GetSampleCount())
+		}
+	}
+	assert.ElementsMatch(t, []string{"operation=query,table=users", "operation=raw,table=unknown"}, labels)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRegisterMetrics_ErrorsByCode(t *testing.T) {
+	db, mock := newMockGormDB(t)
+	reg := prometheus.NewRegistry()
+	require.NoError(t, RegisterMetrics(db, reg, nil))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "users"`).WillReturnError(&pgconn.PgError{Code: sqlStateUniqueViolation, TableName: "users"})
+	mock.ExpectRollback()
+
+	assert.Error(t, db.Create(&replicaTestUser{Email: "a@example.com"}).Error)
+
+	expected := `
+# HELP db_query_errors_total Failed database statements by operation, table and error code.
+# TYPE db_query_errors_total counter
+db_query_errors_total{code="DUPLICATE_ENTRY",operation="create",table="users"} 1
+`
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "db_query_errors_total"))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRegisterMetrics_SecondHandle(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		Options       *MetricsOptions
+		ExpectedError bool
+	}{
+		{Name: "Same Pool Name Conflicts", Options: nil, ExpectedError: true},
+		{Name: "Distinct Pool Name", Options: &MetricsOptions{PoolName: "audit"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			reg := prometheus.NewRegistry()
+			first, _ := newMockGormDB(t)
+			require.NoError(t, RegisterMetrics(first, reg, nil))
+
+			second, mock := newMockGormDB(t)
+			err := RegisterMetrics(second, reg, tc.Options)
+			if tc.ExpectedError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			// Query collectors are shared, so the second handle's statements are recorded too
+			mock.ExpectQuery(`SELECT \* FROM "users"`).WillReturnRows(sqlmock.NewRows([]string{"id", "email"}))
+			var users []replicaTestUser
+			require.NoError(t, second.Find(&users).Error)
+			count, err := testutil.GatherAndCount(reg, "db_query_duration_seconds")
+			require.NoError(t, err)
+			assert.Equal(t, 1, count)
+
+			families, err := reg.Gather()
+			require.NoError(t, err)
+			dbNames := map[string]bool{}
+			for _, family := range families {
+				if family.GetName() != "go_sql_open_connections" {
+					continue
+				}
+				for _, metric := range family.GetMetric() {
+					for _, label := range metric.GetLabel() {
+						dbNames[label.GetValue()] = true
+					}
+				}
+			}
+			assert.Equal(t, map[string]bool{"primary": true, "audit": true}, dbNames)
+		})
+	}
+}
+
+func TestRegisterMetrics_ReplicasOnOneHost(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	db, _ := newMockGormDB(t)
+
+	first, _, err := sqlmock.New()
+	require.NoError(t, err)
+	second, _, err := sqlmock.New()
+	require.NoError(t, err)
+	router := newReplicaRouter([]*replica{
+		{host: "replicas", port: 5432, pool: first, healthy: true},
+		{host: "replicas", port: 5433, pool: second, healthy: true},
+	}, 0, time.Hour)
+	require.NoError(t, db.Use(router))
+	t.Cleanup(func() { router.close() })
+
+	require.NoError(t, RegisterMetrics(db, reg, nil))
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	dbNames := map[string]bool{}
+	for _, family := range families {
+		if family.GetName() != "go_sql_open_connections" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "db_name" {
+					dbNames[label.GetValue()] = true
+				}
+			}
+		}
+	}
+	assert.Equal(t, map[string]bool{"primary": true, "replicas:5432": true, "replicas:5433": true}, dbNames)
+}
+
+func TestRegisterMetrics_NilDatabase(t *testing.T) {
+	assert.Error(t, RegisterMetrics(nil, prometheus.NewRegistry(), nil))
+}
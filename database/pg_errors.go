@@ -0,0 +1,187 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	stderrors "errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/medbai2/common-go/errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// PostgreSQL SQLSTATE codes translated by TranslateError
+const (
+	sqlStateNotNullViolation    = "23502"
+	sqlStateForeignKeyViolation = "23503"
+	sqlStateUniqueViolation     = "23505"
+	sqlStateCheckViolation      = "23514"
+	sqlStateQueryCanceled       = "57014"
+	sqlStateAdminShutdown       = "57P01"
+	sqlStateCannotConnectNow    = "57P03"
+
+	// Class 08 covers all connection exceptions
+	sqlStateClassConnection = "08"
+)
+
+// errorTranslatorCallback is the name under which the translation callback is registered
+const errorTranslatorCallback = "common-go:translate_error"
+
+// keyColumnsRegex extracts column names from PostgreSQL detail messages such as
+// "Key (email)=(a@b.c) already exists." without capturing the (potentially sensitive) values
+var keyColumnsRegex = regexp.MustCompile(`Key \(([^)]+)\)=`)
+
+// TranslateError converts database errors into AppErrors with a meaningful error code.
+//
+// PostgreSQL errors are mapped by SQLSTATE:
+//   - 23505 unique violation      -> DUPLICATE_ENTRY
+//   - 23503 foreign key violation -> BUSINESS_RULE_VIOLATION
+//   - 23514 check violation       -> INVALID_INPUT
+//   - 23502 not-null violation    -> MISSING_FIELD
+//   - 57014 query canceled        -> TIMEOUT
+//   - 40001/40P01 serialization   -> CONFLICT
+//   - class 08, connection errors -> SERVICE_UNAVAILABLE
+//
+// gorm.ErrRecordNotFound becomes NOT_FOUND. Constraint, table and column names are reported
+// in Details; row values are never included. The original error is kept as the wrapped error,
+// so errors.Is/As still match it. nil and existing AppErrors are returned unchanged.
+func TranslateError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if appErr := errors.GetAppError(err); appErr != nil {
+		return appErr
+	}
+
+	var appErr *errors.AppError
+
+	var pgErr *pgconn.PgError
+	switch {
+	case stderrors.As(err, &pgErr):
+		appErr = translatePgError(pgErr)
+	case stderrors.Is(err, gorm.ErrRecordNotFound):
+		appErr = errors.NewNotFound("record")
+	case stderrors.Is(err, context.DeadlineExceeded), stderrors.Is(err, context.Canceled):
+		appErr = errors.NewTimeoutError("database query")
+	case isConnectionError(err):
+		appErr = errors.NewServiceUnavailable("database")
+	default:
+		return errors.NewDatabaseError(err)
+	}
+
+	appErr.Err = err
+	return appErr
+}
+
+// translatePgError maps a PostgreSQL error to an AppError by SQLSTATE
+func translatePgError(pgErr *pgconn.PgError) *errors.AppError {
+	var appErr *errors.AppError
+
+	switch {
+	case pgErr.Code == sqlStateUniqueViolation:
+		appErr = errors.NewDuplicateEntry(resourceName(pgErr))
+	case pgErr.Code == sqlStateForeignKeyViolation:
+		appErr = errors.NewBusinessRule("referenced record constraint violated")
+	case pgErr.Code == sqlStateCheckViolation:
+		appErr = errors.NewInvalidInput("check constraint violated")
+	case pgErr.Code == sqlStateNotNullViolation:
+		column := pgErr.ColumnName
+		if column == "" {
+			column = "unknown"
+		}
+		appErr = errors.NewMissingField(column)
+	case pgErr.Code == sqlStateQueryCanceled:
+		appErr = errors.NewTimeoutError("database query")
+	case pgErr.Code == sqlStateSerializationFailure, pgErr.Code == sqlStateDeadlockDetected:
+		appErr = errors.NewConflict("transaction aborted due to concurrent update")
+	case strings.HasPrefix(pgErr.Code, sqlStateClassConnection),
+		pgErr.Code == sqlStateAdminShutdown,
+		pgErr.Code == sqlStateCannotConnectNow:
+		appErr = errors.NewServiceUnavailable("database")
+	default:
+		appErr = errors.NewDatabaseError(pgErr)
+	}
+
+	appErr.Details = pgErrorDetails(pgErr)
+	return appErr
+}
+
+// resourceName returns the table name of a constraint violation, or a generic name
+func resourceName(pgErr *pgconn.PgError) string {
+	if pgErr.TableName != "" {
+		return pgErr.TableName
+	}
+	return "record"
+}
+
+// pgErrorDetails builds the Details string from constraint, table and column metadata
+func pgErrorDetails(pgErr *pgconn.PgError) string {
+	var parts []string
+	if pgErr.ConstraintName != "" {
+		parts = append(parts, fmt.Sprintf("constraint=%s", pgErr.ConstraintName))
+	}
+	if pgErr.TableName != "" {
+		parts = append(parts, fmt.Sprintf("table=%s", pgErr.TableName))
+	}
+
+	column := pgErr.ColumnName
+	if column == "" {
+		// Unique and foreign key violations only name their columns in the detail message
+		if match := keyColumnsRegex.FindStringSubmatch(pgErr.Detail); len(match) == 2 {
+			column = match[1]
+		}
+	}
+	if column != "" {
+		parts = append(parts, fmt.Sprintf("column=%s", column))
+	}
+
+	parts = append(parts, fmt.Sprintf("sqlstate=%s", pgErr.Code))
+	return strings.Join(parts, " ")
+}
+
+// isConnectionError reports whether err indicates the database could not be reached
+func isConnectionError(err error) bool {
+	var connectErr *pgconn.ConnectError
+	if stderrors.As(err, &connectErr) {
+		return true
+	}
+	if stderrors.Is(err, driver.ErrBadConn) {
+		return true
+	}
+	var netErr net.Error
+	return stderrors.As(err, &netErr)
+}
+
+// RegisterErrorTranslator registers GORM callbacks that pass every statement error
+// through TranslateError, so repositories receive AppErrors without explicit wrapping.
+// database.New registers it automatically; call it for handles created elsewhere.
+func RegisterErrorTranslator(db *gorm.DB) error {
+	translate := func(tx *gorm.DB) {
+		if tx.Error != nil && !errors.IsAppError(tx.Error) {
+			tx.Error = TranslateError(tx.Error)
+		}
+	}
+
+	callbacks := db.Callback()
+	if err := callbacks.Create().After("gorm:create").Register(errorTranslatorCallback, translate); err != nil {
+		return err
+	}
+	if err := callbacks.Query().After("gorm:query").Register(errorTranslatorCallback, translate); err != nil {
+		return err
+	}
+	if err := callbacks.Update().After("gorm:update").Register(errorTranslatorCallback, translate); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().After("gorm:delete").Register(errorTranslatorCallback, translate); err != nil {
+		return err
+	}
+	if err := callbacks.Row().After("gorm:row").Register(errorTranslatorCallback, translate); err != nil {
+		return err
+	}
+	return callbacks.Raw().After("gorm:raw").Register(errorTranslatorCallback, translate)
+}
@@ -0,0 +1,206 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	stderrors "errors"
+	"net/http"
+	"testing"
+
+	"github.com/medbai2/common-go/errors"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestTranslateError(t *testing.T) {
+	testCases := []struct {
+		Name            string
+		Err             error
+		ExpectedCode    errors.ErrorCode
+		ExpectedStatus  int
+		ExpectedDetails string
+		ExpectedMessage string
+	}{
+		{
+			Name: "Unique Violation",
+			Err: &pgconn.PgError{
+				Code:           "23505",
+				TableName:      "users",
+				ConstraintName: "idx_users_email",
+				Detail:         "Key (email)=(jane@example.com) already exists.",
+			},
+			ExpectedCode:    errors.ErrCodeDuplicateEntry,
+			ExpectedStatus:  http.StatusConflict,
+			ExpectedDetails: "constraint=idx_users_email table=users column=email sqlstate=23505",
+			ExpectedMessage: "entry already exists: users",
+		},
+		{
+			Name: "Foreign Key Violation",
+			Err: &pgconn.PgError{
+				Code:           "23503",
+				TableName:      "user_roles",
+				ConstraintName: "user_roles_role_id_fkey",
+				Detail:         "Key (role_id)=(42) is not present in table \"roles\".",
+			},
+			ExpectedCode:    errors.ErrCodeBusinessRule,
+			ExpectedStatus:  http.StatusConflict,
+			ExpectedDetails: "constraint=user_roles_role_id_fkey table=user_roles column=role_id sqlstate=23503",
+		},
+		{
+			Name:            "Check Violation",
+			Err:             &pgconn.PgError{Code: "23514", TableName: "users", ConstraintName: "chk_email_format"},
+			ExpectedCode:    errors.ErrCodeInvalidInput,
+			ExpectedStatus:  http.StatusBadRequest,
+			ExpectedDetails: "constraint=chk_email_format table=users sqlstate=23514",
+		},
+		{
+			Name:            "Not Null Violation",
+			Err:             &pgconn.PgError{Code: "23502", TableName: "roles", ColumnName: "name"},
+			ExpectedCode:    errors.ErrCodeMissingField,
+			ExpectedStatus:  http.StatusBadRequest,
+			ExpectedDetails: "table=roles column=name sqlstate=23502",
+			ExpectedMessage: "missing required field: name",
+		},
+		{
+			Name:           "Query Canceled",
+			Err:            &pgconn.PgError{Code: "57014"},
+			ExpectedCode:   errors.ErrCodeTimeout,
+			ExpectedStatus: http.StatusInternalServerError,
+		},
+		{
+			Name:           "Serialization Failure",
+			Err:            &pgconn.PgError{Code: "40001"},
+			ExpectedCode:   errors.ErrCodeConflict,
+			ExpectedStatus: http.StatusConflict,
+		},
+		{
+			Name:           "Deadlock Detected",
+			Err:            &pgconn.PgError{Code: "40P01"},
+			ExpectedCode:   errors.ErrCodeConflict,
+			ExpectedStatus: http.StatusConflict,
+		},
+		{
+			Name:           "Connection Exception Class",
+			Err:            &pgconn.PgError{Code: "08006"},
+			ExpectedCode:   errors.ErrCodeServiceUnavailable,
+			ExpectedStatus: http.StatusServiceUnavailable,
+		},
+		{
+			Name:           "Admin Shutdown",
+			Err:            &pgconn.PgError{Code: "57P01"},
+			ExpectedCode:   errors.ErrCodeServiceUnavailable,
+			ExpectedStatus: http.StatusServiceUnavailable,
+		},
+		{
+			Name:           "Unknown SQLSTATE",
+			Err:            &pgconn.PgError{Code: "42P01"},
+			ExpectedCode:   errors.ErrCodeDatabaseError,
+			ExpectedStatus: http.StatusInternalServerError,
+		},
+		{
+			Name:           "Record Not Found",
+			Err:            gorm.ErrRecordNotFound,
+			ExpectedCode:   errors.ErrCodeNotFound,
+			ExpectedStatus: http.StatusNotFound,
+		},
+		{
+			Name:           "Context Deadline",
+			Err:            context.DeadlineExceeded,
+			ExpectedCode:   errors.ErrCodeTimeout,
+			ExpectedStatus: http.StatusInternalServerError,
+		},
+		{
+			Name:           "Bad Connection",
+			Err:            driver.ErrBadConn,
+			ExpectedCode:   errors.ErrCodeServiceUnavailable,
+			ExpectedStatus: http.StatusServiceUnavailable,
+		},
+		{
+			Name:           "Generic Error",
+			Err:            stderrors.New("something went wrong"),
+			ExpectedCode:   errors.ErrCodeDatabaseError,
+			ExpectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := TranslateError(tc.Err)
+
+			appErr := errors.GetAppError(err)
+			require.NotNil(t, appErr)
+			assert.Equal(t, tc.ExpectedCode, appErr.Code)
+			assert.Equal(t, tc.ExpectedStatus, appErr.HTTPStatus)
+			assert.ErrorIs(t, err, tc.Err, "original error should remain in the chain")
+			if tc.ExpectedDetails != "" {
+				assert.Equal(t, tc.ExpectedDetails, appErr.Details)
+			}
+			if tc.ExpectedMessage != "" {
+				assert.Equal(t, tc.ExpectedMessage, appErr.Message)
+			}
+		})
+	}
+}
+
+func TestTranslateError_NeverLeaksValues(t *testing.T) {
+	err := TranslateError(&pgconn.PgError{
+		Code:   "23505",
+		Detail: "Key (email)=(secret@example.com) already exists.",
+	})
+
+	appErr := errors.GetAppError(err)
+	require.NotNil(t, appErr)
+	assert.NotContains(t, appErr.Details, "secret@example.com")
+	assert.NotContains(t, appErr.Message, "secret@example.com")
+}
+
+func TestTranslateError_PassThrough(t *testing.T) {
+	assert.Nil(t, TranslateError(nil))
+
+	notFound := errors.NewNotFound("user")
+	assert.Same(t, notFound, TranslateError(notFound))
+}
+
+func TestRegisterErrorTranslator(t *testing.T) {
+	db, mock := newMockGormDB(t)
+	require.NoError(t, RegisterErrorTranslator(db))
+
+	mock.ExpectExec("INSERT INTO users").WillReturnError(&pgconn.PgError{
+		Code:      "23505",
+		TableName: "users",
+		Detail:    "Key (idp_user_id)=(auth0|1) already exists.",
+	})
+
+	err := db.Exec("INSERT INTO users (idp_user_id) VALUES (?)", "auth0|1").Error
+
+	appErr := errors.GetAppError(err)
+	require.NotNil(t, appErr)
+	assert.Equal(t, errors.ErrCodeDuplicateEntry, appErr.Code)
+	assert.Contains(t, appErr.Details, "column=idp_user_id")
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRegisterErrorTranslator_RecordNotFound(t *testing.T) {
+	type user struct {
+		ID    uint
+		Email string
+	}
+
+	db, mock := newMockGormDB(t)
+	require.NoError(t, RegisterErrorTranslator(db))
+
+	mock.ExpectQuery("SELECT (.+) FROM \"users\"").WillReturnRows(sqlmock.NewRows([]string{"id", "email"}))
+
+	var u user
+	err := db.First(&u).Error
+
+	appErr := errors.GetAppError(err)
+	require.NotNil(t, appErr)
+	assert.Equal(t, errors.ErrCodeNotFound, appErr.Code)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound, "callers checking gorm.ErrRecordNotFound keep working")
+	require.NoError(t, mock.ExpectationsWereMet())
+}
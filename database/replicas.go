@@ -0,0 +1,451 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/medbai2/common-go/errors"
+
+	"gorm.io/gorm"
+)
+
+// Default replica health-check settings
+const (
+	DefaultReplicaCheckInterval = 10 * time.Second
+	DefaultReplicaCheckTimeout  = 2 * time.Second
+)
+
+// replicaPluginName is the GORM plugin name of the replica router
+const replicaPluginName = "common-go:replicas"
+
+// routeSettingKey is the GORM setting used for per-call routing overrides
+const routeSettingKey = "common-go:route"
+
+// replicaSettingKey records which replica served a statement, for failure tracking
+const replicaSettingKey = "common-go:replica"
+
+// route represents an explicit routing decision for a statement
+type route int
+
+const (
+	routePrimary route = iota + 1
+	routeReplica
+)
+
+// replicaLagQuery returns replication lag in seconds. A replica that has replayed
+// everything it received reports zero, so an idle primary does not look like lag.
+const replicaLagQuery = `SELECT CASE
+	WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+	ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+END`
+
+// ReplicaConfig describes a read replica. Credentials, database name, SSL settings and
+// authentication type are shared with the primary.
+type ReplicaConfig struct {
+	Host string
+	Port int
+}
+
+// ReplicaState is a snapshot of a replica's health as seen by the router
+type ReplicaState struct {
+	Host      string        `json:"host"`
+	Port      int           `json:"port"`
+	Healthy   bool          `json:"healthy"`
+	Lag       time.Duration `json:"lag"`
+	LastError string        `json:"lastError,omitempty"`
+	CheckedAt time.Time     `json:"checkedAt"`
+}
+
+// ReplicaPool is an already-opened read replica connection pool, see RegisterReplicas
+type ReplicaPool struct {
+	Host string
+	Port int
+	DB   *sql.DB
+}
+
+// replica is a read replica connection pool with its health state
+type replica struct {
+	host string
+	port int
+	pool *sql.DB
+
+	mu        sync.RWMutex
+	healthy   bool
+	lag       time.Duration
+	lastErr   error
+	checkedAt time.Time
+}
+
+// address returns host:port, which identifies the replica in metrics and logs
+func (r *replica) address() string {
+	return net.JoinHostPort(r.host, strconv.Itoa(r.port))
+}
+
+// state returns a snapshot of the replica's health
+func (r *replica) state() ReplicaState {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	state := ReplicaState{
+		Host:      r.host,
+		Port:      r.port,
+		Healthy:   r.healthy,
+		Lag:       r.lag,
+		CheckedAt: r.checkedAt,
+	}
+	if r.lastErr != nil {
+		state.LastError = r.lastErr.Error()
+	}
+	return state
+}
+
+// isHealthy reports whether the replica can serve reads
+func (r *replica) isHealthy() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.healthy
+}
+
+// markUnhealthy takes the replica out of rotation until the next successful check
+func (r *replica) markUnhealthy(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.healthy = false
+	r.lastErr = err
+}
+
+// replicaRouter is a GORM plugin routing reads to healthy replicas and everything
+// else (writes, transactions, locking reads) to the primary
+type replicaRouter struct {
+	replicas      []*replica
+	maxLag        time.Duration
+	checkInterval time.Duration
+	checkTimeout  time.Duration
+
+	next     atomic.Uint64
+	started  atomic.Bool
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// newReplicaRouter creates a router over already-opened replica pools
+func newReplicaRouter(replicas []*replica, maxLag, checkInterval time.Duration) *replicaRouter {
+	if checkInterval <= 0 {
+		checkInterval = DefaultReplicaCheckInterval
+	}
+	return &replicaRouter{
+		replicas:      replicas,
+		maxLag:        maxLag,
+		checkInterval: checkInterval,
+		checkTimeout:  DefaultReplicaCheckTimeout,
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+}
+
+// Name implements gorm.Plugin
+func (r *replicaRouter) Name() string {
+	return replicaPluginName
+}
+
+// Initialize implements gorm.Plugin by registering the routing callbacks
+func (r *replicaRouter) Initialize(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if err := callbacks.Query().Before("gorm:query").Register("common-go:route_query", r.routeRead); err != nil {
+		return err
+	}
+	if err := callbacks.Row().Before("gorm:row").Register("common-go:route_row", r.routeRead); err != nil {
+		return err
+	}
+	if err := callbacks.Raw().Before("gorm:raw").Register("common-go:route_raw", r.routeRaw); err != nil {
+		return err
+	}
+	if err := callbacks.Query().After("gorm:query").Register("common-go:replica_failure_query", r.trackFailure); err != nil {
+		return err
+	}
+	return callbacks.Row().After("gorm:row").Register("common-go:replica_failure_row", r.trackFailure)
+}
+
+// routeRead sends read statements to a healthy replica
+func (r *replicaRouter) routeRead(db *gorm.DB) {
+	if db.Error != nil || !r.canUseReplica(db) {
+		return
+	}
+	if db.Statement.SQL.Len() > 0 && !isReadSQL(db.Statement.SQL.String()) {
+		return
+	}
+	r.useReplica(db)
+}
+
+// routeRaw sends raw statements (db.Exec) to the primary unless a replica was explicitly requested
+func (r *replicaRouter) routeRaw(db *gorm.DB) {
+	if db.Error != nil || isInTransaction(db) {
+		return
+	}
+	if value, ok := db.Get(routeSettingKey); ok && value == routeReplica {
+		r.useReplica(db)
+	}
+}
+
+// canUseReplica reports whether the statement may be served by a replica
+func (r *replicaRouter) canUseReplica(db *gorm.DB) bool {
+	if isInTransaction(db) {
+		return false
+	}
+	if value, ok := db.Get(routeSettingKey); ok {
+		return value == routeReplica
+	}
+	// Locking reads (SELECT ... FOR UPDATE/SHARE) must run on the primary
+	if _, locking := db.Statement.Clauses["FOR"]; locking {
+		return false
+	}
+	return true
+}
+
+// useReplica switches the statement's connection pool to the next healthy replica.
+// When no replica is healthy the statement stays on the primary.
+func (r *replicaRouter) useReplica(db *gorm.DB) {
+	if rep := r.pick(); rep != nil {
+		db.Statement.ConnPool = rep.pool
+		db.Statement.Settings.Store(replicaSettingKey, rep)
+	}
+}
+
+// trackFailure takes a replica out of rotation when a statement on it fails to connect
+func (r *replicaRouter) trackFailure(db *gorm.DB) {
+	value, ok := db.Statement.Settings.LoadAndDelete(replicaSettingKey)
+	if !ok || db.Error == nil || !isConnectionError(db.Error) {
+		return
+	}
+	if rep, ok := value.(*replica); ok {
+		rep.markUnhealthy(db.Error)
+	}
+}
+
+// pick returns the next healthy replica in round-robin order, or nil if none is healthy
+func (r *replicaRouter) pick() *replica {
+	count := uint64(len(r.replicas))
+	if count == 0 {
+		return nil
+	}
+	start := r.next.Add(1)
+	for i := uint64(0); i < count; i++ {
+		rep := r.replicas[(start+i)%count]
+		if rep.isHealthy() {
+			return rep
+		}
+	}
+	return nil
+}
+
+// start runs an initial synchronous health check and then checks periodically until close
+func (r *replicaRouter) start() {
+	r.started.Store(true)
+	r.checkAll()
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(r.checkInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-r.stop:
+				return
+			case <-ticker.C:
+				r.checkAll()
+			}
+		}
+	}()
+}
+
+// checkAll refreshes the health state of every replica
+func (r *replicaRouter) checkAll() {
+	for _, rep := range r.replicas {
+		ctx, cancel := context.WithTimeout(context.Background(), r.checkTimeout)
+		r.check(ctx, rep)
+		cancel()
+	}
+}
+
+// check pings a replica and, when a lag threshold is configured, measures replication lag
+func (r *replicaRouter) check(ctx context.Context, rep *replica) {
+	var lag time.Duration
+	err := rep.pool.PingContext(ctx)
+	if err == nil && r.maxLag > 0 {
+		var seconds float64
+		if err = rep.pool.QueryRowContext(ctx, replicaLagQuery).Scan(&seconds); err == nil {
+			lag = time.Duration(seconds * float64(time.Second))
+			if lag > r.maxLag {
+				err = fmt.Errorf("replication lag %s exceeds maximum %s", lag, r.maxLag)
+			}
+		}
+	}
+
+	rep.mu.Lock()
+	defer rep.mu.Unlock()
+	rep.healthy = err == nil
+	rep.lag = lag
+	rep.lastErr = err
+	rep.checkedAt = time.Now()
+}
+
+// states returns a snapshot of every replica's health
+func (r *replicaRouter) states() []ReplicaState {
+	states := make([]ReplicaState, 0, len(r.replicas))
+	for _, rep := range r.replicas {
+		states = append(states, rep.state())
+	}
+	return states
+}
+
+// close stops health checking and closes all replica pools
+func (r *replicaRouter) close() error {
+	var firstErr error
+	r.stopOnce.Do(func() {
+		close(r.stop)
+		if r.started.Load() {
+			<-r.done
+		}
+		for _, rep := range r.replicas {
+			if err := rep.pool.Close(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	})
+	return firstErr
+}
+
+// openReplicas opens a connection pool for each configured replica
+func openReplicas(cfg Config) ([]*replica, error) {
+	replicas := make([]*replica, 0, len(cfg.Replicas))
+	for _, rc := range cfg.Replicas {
+		replicaCfg := replicaConfig(cfg, rc)
+		pool, err := openPool(replicaCfg.DSN().URL(), cfg)
+		if err != nil {
+			for _, opened := range replicas {
+				opened.pool.Close()
+			}
+			return nil, errors.Wrap(err, errors.ErrCodeDatabaseError, fmt.Sprintf("failed to open replica %s", rc.Host))
+		}
+		replicas = append(replicas, &replica{host: rc.Host, port: replicaCfg.Port, pool: pool})
+	}
+	return replicas, nil
+}
+
+// replicaServerParams are connection parameters that select which server of a host list
+// to use; they describe the primary and are not carried over to replica connections
+var replicaServerParams = map[string]bool{
+	"target_session_attrs": true,
+	"load_balance_hosts":   true,
+}
+
+// replicaConfig returns the connection config of replica rc: the primary's credentials,
+// TLS and session options, dialing rc only. TargetSessionAttrs is cleared, since a
+// "read-write" primary setting would reject every standby, and ExtraParams carry over
+// except replicaServerParams.
+func replicaConfig(cfg Config, rc ReplicaConfig) Config {
+	replicaCfg := cfg
+	replicaCfg.Host = rc.Host
+	replicaCfg.Port = rc.Port
+	replicaCfg.Hosts = nil
+	if replicaCfg.Port == 0 {
+		replicaCfg.Port = cfg.Port
+	}
+	replicaCfg.TargetSessionAttrs = ""
+	if len(cfg.ExtraParams) > 0 {
+		replicaCfg.ExtraParams = make(map[string]string, len(cfg.ExtraParams))
+		for key, value := range cfg.ExtraParams {
+			if !replicaServerParams[key] {
+				replicaCfg.ExtraParams[key] = value
+			}
+		}
+	}
+	return replicaCfg
+}
+
+// isInTransaction reports whether the statement runs inside a transaction
+func isInTransaction(db *gorm.DB) bool {
+	_, ok := db.Statement.ConnPool.(gorm.TxCommitter)
+	return ok
+}
+
+// lockingClauseRegex matches the row-locking clauses, which must run on the primary
+var lockingClauseRegex = regexp.MustCompile(`\bFOR\s+(UPDATE|NO\s+KEY\s+UPDATE|SHARE|KEY\s+SHARE)\b`)
+
+// modifyingStatementRegex matches the statements a WITH query may use to modify data
+var modifyingStatementRegex = regexp.MustCompile(`\b(INSERT|UPDATE|DELETE|MERGE)\b`)
+
+// isReadSQL reports whether a raw SQL statement is a plain read. WITH queries are only
+// reads when none of their statements modify data.
+func isReadSQL(sql string) bool {
+	normalized := strings.ToUpper(strings.TrimSpace(sql))
+	switch {
+	case strings.HasPrefix(normalized, "SELECT"):
+	case strings.HasPrefix(normalized, "WITH"):
+		if modifyingStatementRegex.MatchString(normalized) {
+			return false
+		}
+	default:
+		return false
+	}
+	return !lockingClauseRegex.MatchString(normalized)
+}
+
+// getReplicaRouter returns the replica router attached to db, if any
+func getReplicaRouter(db *gorm.DB) *replicaRouter {
+	if db == nil || db.Config == nil {
+		return nil
+	}
+	if plugin, ok := db.Config.Plugins[replicaPluginName]; ok {
+		if router, ok := plugin.(*replicaRouter); ok {
+			return router
+		}
+	}
+	return nil
+}
+
+// UsePrimary forces statements built from the returned handle to run on the primary,
+// e.g. to read your own writes right after a commit.
+func UsePrimary(db *gorm.DB) *gorm.DB {
+	return db.Set(routeSettingKey, routePrimary)
+}
+
+// UseReplica forces statements built from the returned handle to run on a replica,
+// including raw SQL that the router would otherwise send to the primary.
+// It falls back to the primary when no replica is healthy.
+func UseReplica(db *gorm.DB) *gorm.DB {
+	return db.Set(routeSettingKey, routeReplica)
+}
+
+// ReplicaStates returns the health of each configured replica, or nil when db has no replicas
+func ReplicaStates(db *gorm.DB) []ReplicaState {
+	if router := getReplicaRouter(db); router != nil {
+		return router.states()
+	}
+	return nil
+}
+
+// RegisterReplicas routes reads on db to already-opened replica pools, for handles not
+// created by New (which does this from Config.Replicas). The replicas are checked once
+// before it returns and then every checkInterval (default: DefaultReplicaCheckInterval);
+// a maxLag of 0 disables the lag check. Close(db) stops the checks and closes the pools.
+func RegisterReplicas(db *gorm.DB, pools []ReplicaPool, maxLag, checkInterval time.Duration) error {
+	replicas := make([]*replica, 0, len(pools))
+	for _, pool := range pools {
+		replicas = append(replicas, &replica{host: pool.Host, port: pool.Port, pool: pool.DB})
+	}
+	router := newReplicaRouter(replicas, maxLag, checkInterval)
+	if err := db.Use(router); err != nil {
+		return errors.Wrap(err, errors.ErrCodeDatabaseError, "failed to register replica router")
+	}
+	router.start()
+	return nil
+}
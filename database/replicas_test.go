@@ -0,0 +1,357 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type replicaTestUser struct {
+	ID    uint
+	Email string
+}
+
+func (replicaTestUser) TableName() string {
+	return "users"
+}
+
+// newReplicaTestDB creates a primary handle with one routed replica, both backed by sqlmock
+func newReplicaTestDB(t *testing.T, maxLag time.Duration) (*gorm.DB, sqlmock.Sqlmock, sqlmock.Sqlmock, *replicaRouter) {
+	db, primaryMock := newMockGormDB(t)
+
+	replicaDB, replicaMock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+
+	router := newReplicaRouter([]*replica{{host: "replica-1", port: 5432, pool: replicaDB, healthy: true}}, maxLag, time.Hour)
+	require.NoError(t, db.Use(router))
+	t.Cleanup(func() { router.close() })
+
+	return db, primaryMock, replicaMock, router
+}
+
+func TestReplicaRouter_ReadsGoToReplica(t *testing.T) {
+	db, primaryMock, replicaMock, _ := newReplicaTestDB(t, 0)
+
+	replicaMock.ExpectQuery(`SELECT \* FROM "users"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow(1, "a@example.com"))
+
+	var users []replicaTestUser
+	require.NoError(t, db.Find(&users).Error)
+	assert.Len(t, users, 1)
+
+	require.NoError(t, replicaMock.ExpectationsWereMet())
+	require.NoError(t, primaryMock.ExpectationsWereMet())
+}
+
+func TestReplicaRouter_RawSelectGoesToReplica(t *testing.T) {
+	db, primaryMock, replicaMock, _ := newReplicaTestDB(t, 0)
+
+	replicaMock.ExpectQuery(`SELECT count`).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	var count int
+	require.NoError(t, db.Raw("SELECT count(*) FROM users").Scan(&count).Error)
+	assert.Equal(t, 3, count)
+
+	require.NoError(t, replicaMock.ExpectationsWereMet())
+	require.NoError(t, primaryMock.ExpectationsWereMet())
+}
+
+func TestReplicaRouter_WritesGoToPrimary(t *testing.T) {
+	db, primaryMock, replicaMock, _ := newReplicaTestDB(t, 0)
+
+	primaryMock.ExpectExec(`UPDATE users`).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, db.Exec("UPDATE users SET email = ? WHERE id = ?", "b@example.com", 1).Error)
+
+	require.NoError(t, replicaMock.ExpectationsWereMet())
+	require.NoError(t, primaryMock.ExpectationsWereMet())
+}
+
+func TestReplicaRouter_UsePrimaryOverride(t *testing.T) {
+	db, primaryMock, replicaMock, _ := newReplicaTestDB(t, 0)
+
+	primaryMock.ExpectQuery(`SELECT \* FROM "users"`).WillReturnRows(sqlmock.NewRows([]string{"id", "email"}))
+
+	var users []replicaTestUser
+	require.NoError(t, UsePrimary(db).Find(&users).Error)
+
+	require.NoError(t, replicaMock.ExpectationsWereMet())
+	require.NoError(t, primaryMock.ExpectationsWereMet())
+}
+
+func TestReplicaRouter_UseReplicaOverridesRaw(t *testing.T) {
+	db, primaryMock, replicaMock, _ := newReplicaTestDB(t, 0)
+
+	replicaMock.ExpectExec(`REFRESH MATERIALIZED VIEW`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	require.NoError(t, UseReplica(db).Exec("REFRESH MATERIALIZED VIEW report").Error)
+
+	require.NoError(t, replicaMock.ExpectationsWereMet())
+	require.NoError(t, primaryMock.ExpectationsWereMet())
+}
+
+func TestReplicaRouter_TransactionsStayOnPrimary(t *testing.T) {
+	db, primaryMock, replicaMock, _ := newReplicaTestDB(t, 0)
+
+	primaryMock.ExpectBegin()
+	primaryMock.ExpectQuery(`SELECT \* FROM "users"`).WillReturnRows(sqlmock.NewRows([]string{"id", "email"}))
+	primaryMock.ExpectCommit()
+
+	err := WithTx(context.Background(), db, nil, func(ctx context.Context, tx *gorm.DB) error {
+		var users []replicaTestUser
+		return tx.Find(&users).Error
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, replicaMock.ExpectationsWereMet())
+	require.NoError(t, primaryMock.ExpectationsWereMet())
+}
+
+func TestReplicaRouter_LockingReadsStayOnPrimary(t *testing.T) {
+	db, primaryMock, replicaMock, _ := newReplicaTestDB(t, 0)
+
+	primaryMock.ExpectQuery(`FOR UPDATE`).WillReturnRows(sqlmock.NewRows([]string{"id", "email"}))
+
+	var users []replicaTestUser
+	require.NoError(t, db.Clauses(clause.Locking{Strength: "UPDATE"}).Find(&users).Error)
+
+	require.NoError(t, replicaMock.ExpectationsWereMet())
+	require.NoError(t, primaryMock.ExpectationsWereMet())
+}
+
+func TestReplicaRouter_FallsBackToPrimaryWhenUnhealthy(t *testing.T) {
+	db, primaryMock, replicaMock, router := newReplicaTestDB(t, 0)
+	router.replicas[0].markUnhealthy(driver.ErrBadConn)
+
+	primaryMock.ExpectQuery(`SELECT \* FROM "users"`).WillReturnRows(sqlmock.NewRows([]string{"id", "email"}))
+
+	var users []replicaTestUser
+	require.NoError(t, db.Find(&users).Error)
+
+	require.NoError(t, replicaMock.ExpectationsWereMet())
+	require.NoError(t, primaryMock.ExpectationsWereMet())
+}
+
+func TestReplicaRouter_ConnectionFailureMarksUnhealthy(t *testing.T) {
+	db, _, replicaMock, router := newReplicaTestDB(t, 0)
+
+	replicaMock.ExpectQuery(`SELECT \* FROM "users"`).WillReturnError(&net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET})
+
+	var users []replicaTestUser
+	assert.Error(t, db.Find(&users).Error)
+	assert.False(t, router.replicas[0].isHealthy())
+}
+
+func TestReplicaRouter_HealthCheck(t *testing.T) {
+	testCases := []struct {
+		Name            string
+		MaxLag          time.Duration
+		Setup           func(mock sqlmock.Sqlmock)
+		ExpectedHealthy bool
+		ExpectedLag     time.Duration
+	}{
+		{
+			Name:            "Ping Only",
+			Setup:           func(mock sqlmock.Sqlmock) { mock.ExpectPing() },
+			ExpectedHealthy: true,
+		},
+		{
+			Name:            "Ping Failure",
+			Setup:           func(mock sqlmock.Sqlmock) { mock.ExpectPing().WillReturnError(driver.ErrBadConn) },
+			ExpectedHealthy: false,
+		},
+		{
+			Name:   "Lag Within Threshold",
+			MaxLag: 10 * time.Second,
+			Setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectPing()
+				mock.ExpectQuery(`pg_last_xact_replay_timestamp`).WillReturnRows(sqlmock.NewRows([]string{"lag"}).AddRow(2.5))
+			},
+			ExpectedHealthy: true,
+			ExpectedLag:     2500 * time.Millisecond,
+		},
+		{
+			Name:   "Lag Exceeds Threshold",
+			MaxLag: 10 * time.Second,
+			Setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectPing()
+				mock.ExpectQuery(`pg_last_xact_replay_timestamp`).WillReturnRows(sqlmock.NewRows([]string{"lag"}).AddRow(30.0))
+			},
+			ExpectedHealthy: false,
+			ExpectedLag:     30 * time.Second,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			db, _, replicaMock, router := newReplicaTestDB(t, tc.MaxLag)
+			tc.Setup(replicaMock)
+
+			router.checkAll()
+
+			states := ReplicaStates(db)
+			require.Len(t, states, 1)
+			assert.Equal(t, tc.ExpectedHealthy, states[0].Healthy)
+			assert.Equal(t, tc.ExpectedLag, states[0].Lag)
+			assert.Equal(t, "replica-1", states[0].Host)
+			assert.False(t, states[0].CheckedAt.IsZero())
+			if !tc.ExpectedHealthy {
+				assert.NotEmpty(t, states[0].LastError)
+			}
+			require.NoError(t, replicaMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestReplicaStates_NoReplicas(t *testing.T) {
+	db, _ := newMockGormDB(t)
+	assert.Nil(t, ReplicaStates(db))
+	assert.Nil(t, ReplicaStates(nil))
+}
+
+func TestRegisterReplicas(t *testing.T) {
+	db, primaryMock := newMockGormDB(t)
+	replicaDB, replicaMock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+	replicaMock.ExpectPing()
+
+	require.NoError(t, RegisterReplicas(db, []ReplicaPool{{Host: "replica-1", Port: 5432, DB: replicaDB}}, 0, time.Hour))
+	defer getReplicaRouter(db).close()
+
+	states := ReplicaStates(db)
+	require.Len(t, states, 1)
+	assert.True(t, states[0].Healthy)
+
+	replicaMock.ExpectQuery(`SELECT \* FROM "users"`).WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow(1, "a@example.com"))
+	var users []replicaTestUser
+	require.NoError(t, db.Find(&users).Error)
+	assert.Len(t, users, 1)
+
+	assert.Error(t, RegisterReplicas(db, nil, 0, time.Hour))
+	require.NoError(t, primaryMock.ExpectationsWereMet())
+	require.NoError(t, replicaMock.ExpectationsWereMet())
+}
+
+func TestReplicaRouter_RoundRobin(t *testing.T) {
+	router := newReplicaRouter([]*replica{
+		{host: "a", healthy: true},
+		{host: "b", healthy: true},
+		{host: "c", healthy: false},
+	}, 0, time.Hour)
+
+	seen := map[string]int{}
+	for i := 0; i < 10; i++ {
+		seen[router.pick().host]++
+	}
+
+	assert.Greater(t, seen["a"], 0)
+	assert.Greater(t, seen["b"], 0)
+	assert.Zero(t, seen["c"])
+}
+
+func TestReplicaConfig(t *testing.T) {
+	cfg := Config{
+		Host:               "primary",
+		Port:               5432,
+		Name:               "app",
+		User:               "app",
+		Password:           "secret",
+		SSLMode:            "require",
+		Hosts:              []HostPort{{Host: "primary-2", Port: 5432}},
+		TargetSessionAttrs: "read-write",
+		ExtraParams: map[string]string{
+			"connect_timeout":      "5",
+			"target_session_attrs": "primary",
+			"load_balance_hosts":   "random",
+		},
+	}
+
+	replicaCfg := replicaConfig(cfg, ReplicaConfig{Host: "replica", Port: 5433})
+	dsn := replicaCfg.DSN().URL()
+	assert.Contains(t, dsn, "@replica:5433/app")
+	assert.NotContains(t, dsn, "primary")
+	assert.NotContains(t, dsn, "target_session_attrs")
+	assert.NotContains(t, dsn, "load_balance_hosts")
+	assert.Contains(t, dsn, "connect_timeout=5")
+	assert.Contains(t, dsn, "sslmode=require")
+
+	// The primary's config is left untouched
+	assert.Equal(t, "read-write", cfg.TargetSessionAttrs)
+	assert.Len(t, cfg.ExtraParams, 3)
+
+	assert.Equal(t, 5432, replicaConfig(cfg, ReplicaConfig{Host: "replica"}).Port)
+}
+
+func TestIsReadSQL(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		SQL      string
+		Expected bool
+	}{
+		{Name: "Select", SQL: "SELECT 1", Expected: true},
+		{Name: "Read Only CTE", SQL: "  with x as (select 1) select * from x", Expected: true},
+		{Name: "Column Named Like A Keyword", SQL: "SELECT updated_at, deleted FROM users", Expected: true},
+		{Name: "CTE With Insert", SQL: "WITH created AS (INSERT INTO users DEFAULT VALUES RETURNING id) SELECT id FROM created", Expected: false},
+		{Name: "CTE With Update", SQL: "with moved as (update jobs set state = 'done' returning id) select count(*) from moved", Expected: false},
+		{Name: "CTE With Delete", SQL: "WITH gone AS (DELETE FROM sessions RETURNING id) SELECT id FROM gone", Expected: false},
+		{Name: "CTE With Merge", SQL: "WITH src AS (SELECT 1 AS id) MERGE INTO users USING src ON users.id = src.id WHEN MATCHED THEN DO NOTHING", Expected: false},
+		{Name: "For Update", SQL: "SELECT * FROM jobs FOR UPDATE SKIP LOCKED", Expected: false},
+		{Name: "For No Key Update", SQL: "SELECT * FROM jobs FOR NO KEY UPDATE", Expected: false},
+		{Name: "For Share", SQL: "SELECT * FROM jobs FOR SHARE", Expected: false},
+		{Name: "For Key Share", SQL: "SELECT * FROM jobs\nFOR  KEY SHARE NOWAIT", Expected: false},
+		{Name: "Locking CTE", SQL: "WITH next AS (SELECT id FROM jobs LIMIT 1 FOR UPDATE) SELECT * FROM next", Expected: false},
+		{Name: "Update", SQL: "UPDATE users SET email = 'x'", Expected: false},
+		{Name: "Insert", SQL: "INSERT INTO users DEFAULT VALUES", Expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert.Equal(t, tc.Expected, isReadSQL(tc.SQL))
+		})
+	}
+}
+
+func TestNew_WithReplicas(t *testing.T) {
+	primary := newPGStub(t, func(string) bool { return true })
+	replicaStub := newPGStub(t, func(string) bool { return true })
+
+	cfg := connectTestConfig(primary, ConnectRetryConfig{})
+	cfg.Replicas = []ReplicaConfig{{Host: "127.0.0.1", Port: replicaStub.port()}}
+	// The stubs only speak the simple query protocol
+	cfg.ExtraParams = map[string]string{"default_query_exec_mode": "simple_protocol"}
+	db, err := New(cfg)
+	require.NoError(t, err)
+	defer Close(db)
+
+	states := ReplicaStates(db)
+	require.Len(t, states, 1)
+	assert.True(t, states[0].Healthy)
+
+	rows, err := db.Raw("SELECT id FROM users").Rows()
+	require.NoError(t, err)
+	rows.Close()
+	assert.Contains(t, replicaStub.seenQueries(), "SELECT id FROM users")
+	assert.NotContains(t, primary.seenQueries(), "SELECT id FROM users")
+}
+
+func TestClose_WithReplicas(t *testing.T) {
+	db, primaryMock, replicaMock, router := newReplicaTestDB(t, 0)
+	replicaMock.ExpectPing()
+	router.start()
+
+	replicaMock.ExpectClose()
+	primaryMock.ExpectClose()
+
+	require.NoError(t, Close(db))
+	require.NoError(t, replicaMock.ExpectationsWereMet())
+	require.NoError(t, primaryMock.ExpectationsWereMet())
+}
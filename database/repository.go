@@ -0,0 +1,356 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/medbai2/common-go/errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// DefaultVersionColumn is the optimistic locking column used when the model has one
+const DefaultVersionColumn = "version"
+
+// RepositoryScope restricts the rows a repository can see, typically from values carried
+// by the request context (tenant, owner)
+type RepositoryScope func(ctx context.Context, db *gorm.DB) *gorm.DB
+
+// ContextScope returns a scope limiting rows to column = value, where value is read from
+// ctx. Requests without a value are rejected with ErrCodeForbidden, so a missing tenant
+// never widens a query to every tenant.
+//
+// Usage:
+//
+//	database.ContextScope("tenant_id", func(ctx context.Context) (interface{}, bool) {
+//		tenant, ok := ctx.Value(tenantKey{}).(string)
+//		return tenant, ok && tenant != ""
+//	})
+func ContextScope(column string, value func(ctx context.Context) (interface{}, bool)) RepositoryScope {
+	return func(ctx context.Context, db *gorm.DB) *gorm.DB {
+		v, ok := value(ctx)
+		if !ok {
+			db.AddError(errors.NewForbidden(fmt.Sprintf("missing %s for scoped query", column)))
+			return db
+		}
+		return db.Where(clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: column}, Value: v})
+	}
+}
+
+// RepositoryOptions configures a Repository
+type RepositoryOptions struct {
+	Resource      string            // Name used in NOT_FOUND errors (default: the table name)
+	VersionColumn string            // Optimistic locking column, used when the model has it (default: "version")
+	Scopes        []RepositoryScope // Applied to every operation except Create
+}
+
+// Repository provides CRUD for the model T. Operations run on the transaction carried by
+// ctx when there is one (see WithTx), so repositories compose with transactions.
+//
+// Soft delete follows GORM: when T has a gorm.DeletedAt field, Delete sets it, reads skip
+// deleted rows and Restore clears it. When T has an integer version column, Update only
+// succeeds if the row still has the version the entity was loaded with and increments it;
+// a concurrent modification yields ErrCodeConflict.
+//
+// All errors are AppErrors: NOT_FOUND, DUPLICATE_ENTRY, CONFLICT, or as translated by
+// TranslateError.
+type Repository[T any] struct {
+	db       *gorm.DB
+	opts     RepositoryOptions
+	unscoped bool
+
+	schemaOnce sync.Once
+	schema     *schema.Schema
+	schemaErr  error
+}
+
+// NewRepository creates a repository for T
+func NewRepository[T any](db *gorm.DB, opts RepositoryOptions) *Repository[T] {
+	if opts.VersionColumn == "" {
+		opts.VersionColumn = DefaultVersionColumn
+	}
+	return &Repository[T]{db: db, opts: opts}
+}
+
+// Unscoped returns a repository that includes soft-deleted rows in reads and deletes
+// permanently. Context scopes still apply.
+func (r *Repository[T]) Unscoped() *Repository[T] {
+	return &Repository[T]{db: r.db, opts: r.opts, unscoped: true}
+}
+
+// Get returns the row with primary key id
+func (r *Repository[T]) Get(ctx context.Context, id interface{}) (*T, error) {
+	db, err := r.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var entity T
+	if err := db.Where(clause.Eq{Column: clause.PrimaryColumn, Value: id}).First(&entity).Error; err != nil {
+		return nil, r.translate(err)
+	}
+	return &entity, nil
+}
+
+// List returns the rows matching scopes (e.g. query.Query.Scope() or Keyset)
+func (r *Repository[T]) List(ctx context.Context, scopes ...func(*gorm.DB) *gorm.DB) ([]T, error) {
+	db, err := r.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var entities []T
+	if err := db.Scopes(scopes...).Find(&entities).Error; err != nil {
+		return nil, r.translate(err)
+	}
+	return entities, nil
+}
+
+// Count returns the number of rows matching scopes
+func (r *Repository[T]) Count(ctx context.Context, scopes ...func(*gorm.DB) *gorm.DB) (int64, error) {
+	db, err := r.conn(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	if err := db.Model(new(T)).Scopes(scopes...).Count(&count).Error; err != nil {
+		return 0, r.translate(err)
+	}
+	return count, nil
+}
+
+// Create inserts entity. A versioned entity starts at version 1.
+func (r *Repository[T]) Create(ctx context.Context, entity *T) error {
+	s, err := r.modelSchema()
+	if err != nil {
+		return err
+	}
+	if version := r.versionField(s); version != nil {
+		if current, err := readVersion(ctx, version, entity); err == nil && current == 0 {
+			if err := version.Set(ctx, reflect.ValueOf(entity).Elem(), 1); err != nil {
+				return errors.Wrap(err, errors.ErrCodeInternal, "failed to set version")
+			}
+		}
+	}
+
+	if err := Conn(ctx, r.db).Create(entity).Error; err != nil {
+		return r.translate(err)
+	}
+	return nil
+}
+
+// Update saves every field of entity except its primary key, creation time and deletion
+// time. Soft-deleted rows cannot be updated.
+func (r *Repository[T]) Update(ctx context.Context, entity *T) error {
+	s, err := r.modelSchema()
+	if err != nil {
+		return err
+	}
+	db, err := r.conn(ctx)
+	if err != nil {
+		return err
+	}
+
+	omit := make([]string, 0, len(s.PrimaryFieldDBNames)+2)
+	omit = append(omit, s.PrimaryFieldDBNames...)
+	for _, field := range s.Fields {
+		if field.AutoCreateTime > 0 || isDeletedAtField(field) {
+			omit = append(omit, field.DBName)
+		}
+	}
+	tx := db.Model(entity).Select("*").Omit(omit...)
+
+	version := r.versionField(s)
+	var current int64
+	if version != nil {
+		if current, err = readVersion(ctx, version, entity); err != nil {
+			return err
+		}
+		if err := version.Set(ctx, reflect.ValueOf(entity).Elem(), current+1); err != nil {
+			return errors.Wrap(err, errors.ErrCodeInternal, "failed to set version")
+		}
+		tx = tx.Where(clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: version.DBName}, Value: current})
+	}
+
+	result := tx.Updates(entity)
+	if result.Error == nil && result.RowsAffected > 0 {
+		return nil
+	}
+	if version != nil {
+		// Leave the entity as it was so the caller can reload and retry
+		version.Set(ctx, reflect.ValueOf(entity).Elem(), current)
+	}
+	if result.Error != nil {
+		return r.translate(result.Error)
+	}
+
+	// Nothing updated: either the row is gone (or out of scope) or the version moved on
+	if _, err := r.Get(ctx, primaryKeyValue(ctx, s, entity)); err != nil {
+		return err
+	}
+	if version != nil {
+		return errors.NewConflict(fmt.Sprintf("%s was modified concurrently", r.resource(s)))
+	}
+	return nil
+}
+
+// Delete removes the row with primary key id: soft-deletes when T supports it,
+// permanently otherwise or when the repository is Unscoped
+func (r *Repository[T]) Delete(ctx context.Context, id interface{}) error {
+	db, err := r.conn(ctx)
+	if err != nil {
+		return err
+	}
+
+	result := db.Where(clause.Eq{Column: clause.PrimaryColumn, Value: id}).Delete(new(T))
+	if result.Error != nil {
+		return r.translate(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return r.notFound()
+	}
+	return nil
+}
+
+// Restore undoes a soft delete. It fails with NOT_FOUND when no deleted row has primary key id.
+func (r *Repository[T]) Restore(ctx context.Context, id interface{}) error {
+	s, err := r.modelSchema()
+	if err != nil {
+		return err
+	}
+	var deletedAt *schema.Field
+	for _, field := range s.Fields {
+		if isDeletedAtField(field) {
+			deletedAt = field
+			break
+		}
+	}
+	if deletedAt == nil {
+		return errors.New(errors.ErrCodeInternal, fmt.Sprintf("%s does not support soft delete", r.resource(s)))
+	}
+
+	db, err := r.conn(ctx)
+	if err != nil {
+		return err
+	}
+	column := clause.Column{Table: clause.CurrentTable, Name: deletedAt.DBName}
+	result := db.Unscoped().Model(new(T)).
+		Where(clause.Eq{Column: clause.PrimaryColumn, Value: id}).
+		Where(clause.Neq{Column: column, Value: nil}).
+		Update(deletedAt.DBName, nil)
+	if result.Error != nil {
+		return r.translate(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return r.notFound()
+	}
+	return nil
+}
+
+// conn returns the handle for ctx with the repository scopes applied
+func (r *Repository[T]) conn(ctx context.Context) (*gorm.DB, error) {
+	if r.db == nil {
+		return nil, errors.New(errors.ErrCodeDatabaseError, "database is nil")
+	}
+	db := Conn(ctx, r.db)
+	if r.unscoped {
+		db = db.Unscoped()
+	}
+	for _, scope := range r.opts.Scopes {
+		db = scope(ctx, db)
+	}
+	if db.Error != nil {
+		return nil, db.Error
+	}
+	return db, nil
+}
+
+// modelSchema parses T once
+func (r *Repository[T]) modelSchema() (*schema.Schema, error) {
+	r.schemaOnce.Do(func() {
+		if r.db == nil {
+			r.schemaErr = errors.New(errors.ErrCodeDatabaseError, "database is nil")
+			return
+		}
+		stmt := &gorm.Statement{DB: r.db}
+		if err := stmt.Parse(new(T)); err != nil {
+			r.schemaErr = errors.Wrap(err, errors.ErrCodeInternal, "failed to parse repository model")
+			return
+		}
+		r.schema = stmt.Schema
+	})
+	return r.schema, r.schemaErr
+}
+
+// versionField returns the integer version field of the model, if any
+func (r *Repository[T]) versionField(s *schema.Schema) *schema.Field {
+	field := s.LookUpField(r.opts.VersionColumn)
+	if field == nil {
+		return nil
+	}
+	switch field.FieldType.Kind() {
+	case reflect.Int, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return field
+	}
+	return nil
+}
+
+// resource returns the name used in error messages
+func (r *Repository[T]) resource(s *schema.Schema) string {
+	if r.opts.Resource != "" {
+		return r.opts.Resource
+	}
+	if s != nil {
+		return s.Table
+	}
+	return "record"
+}
+
+// notFound returns the NOT_FOUND error for this repository
+func (r *Repository[T]) notFound() *errors.AppError {
+	s, _ := r.modelSchema()
+	return errors.NewNotFound(r.resource(s))
+}
+
+// translate converts err into an AppError, naming the resource in NOT_FOUND errors
+func (r *Repository[T]) translate(err error) error {
+	translated := TranslateError(err)
+	if appErr := errors.GetAppError(translated); appErr != nil && appErr.Code == errors.ErrCodeNotFound {
+		notFound := r.notFound()
+		notFound.Err = appErr.Err
+		return notFound
+	}
+	return translated
+}
+
+// readVersion returns the current version of entity as int64
+func readVersion(ctx context.Context, field *schema.Field, entity interface{}) (int64, error) {
+	value, _ := field.ValueOf(ctx, reflect.ValueOf(entity).Elem())
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint()), nil
+	}
+	return 0, errors.New(errors.ErrCodeInternal, fmt.Sprintf("unsupported version type %T", value))
+}
+
+// primaryKeyValue returns the primary key of entity
+func primaryKeyValue(ctx context.Context, s *schema.Schema, entity interface{}) interface{} {
+	if s.PrioritizedPrimaryField == nil {
+		return nil
+	}
+	value, _ := s.PrioritizedPrimaryField.ValueOf(ctx, reflect.ValueOf(entity).Elem())
+	return value
+}
+
+// isDeletedAtField reports whether field is a gorm.DeletedAt soft delete column
+func isDeletedAtField(field *schema.Field) bool {
+	return field.FieldType == reflect.TypeOf(gorm.DeletedAt{})
+}
@@ -0,0 +1,284 @@
+package database
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/medbai2/common-go/errors"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// repoUser is a soft-deletable, versioned, tenant-owned model
+type repoUser struct {
+	ID        uint
+	TenantID  string
+	Email     string
+	Version   int
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt
+}
+
+// tenantKey carries the tenant in test contexts
+type tenantKey struct{}
+
+var tenantScope = ContextScope("tenant_id", func(ctx context.Context) (interface{}, bool) {
+	tenant, ok := ctx.Value(tenantKey{}).(string)
+	return tenant, ok && tenant != ""
+})
+
+func tenantContext(tenant string) context.Context {
+	return context.WithValue(context.Background(), tenantKey{}, tenant)
+}
+
+func newRepoUserRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "tenant_id", "email", "version", "created_at", "updated_at", "deleted_at"})
+}
+
+func newTestRepository(t *testing.T) (*Repository[repoUser], sqlmock.Sqlmock) {
+	db, mock := newHealthTestDB(t)
+	return NewRepository[repoUser](db, RepositoryOptions{Resource: "user", Scopes: []RepositoryScope{tenantScope}}), mock
+}
+
+func TestRepository_Get(t *testing.T) {
+	repo, mock := newTestRepository(t)
+	now := time.Now()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "repo_users" WHERE "repo_users"."tenant_id" = $1 AND "repo_users"."id" = $2 AND "repo_users"."deleted_at" IS NULL ORDER BY "repo_users"."id" LIMIT $3`)).
+		WithArgs("acme", 7, 1).
+		WillReturnRows(newRepoUserRows().AddRow(7, "acme", "a@acme.test", 3, now, now, nil))
+
+	user, err := repo.Get(tenantContext("acme"), 7)
+	require.NoError(t, err)
+	assert.Equal(t, "a@acme.test", user.Email)
+	assert.Equal(t, 3, user.Version)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_Get_NotFound(t *testing.T) {
+	repo, mock := newTestRepository(t)
+	mock.ExpectQuery(`SELECT`).WillReturnRows(newRepoUserRows())
+
+	_, err := repo.Get(tenantContext("acme"), 7)
+
+	appErr := errors.GetAppError(err)
+	require.NotNil(t, appErr)
+	assert.Equal(t, errors.ErrCodeNotFound, appErr.Code)
+	assert.Contains(t, appErr.Message, "user")
+}
+
+func TestRepository_MissingTenant(t *testing.T) {
+	repo, mock := newTestRepository(t)
+
+	_, err := repo.Get(context.Background(), 7)
+
+	appErr := errors.GetAppError(err)
+	require.NotNil(t, appErr)
+	assert.Equal(t, errors.ErrCodeForbidden, appErr.Code)
+	require.NoError(t, mock.ExpectationsWereMet(), "no query may run without a tenant")
+}
+
+func TestRepository_List(t *testing.T) {
+	repo, mock := newTestRepository(t)
+	now := time.Now()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "repo_users" WHERE "repo_users"."tenant_id" = $1 AND email LIKE $2 AND "repo_users"."deleted_at" IS NULL`)).
+		WithArgs("acme", "%@acme.test").
+		WillReturnRows(newRepoUserRows().AddRow(1, "acme", "a@acme.test", 1, now, now, nil).AddRow(2, "acme", "b@acme.test", 1, now, now, nil))
+
+	users, err := repo.List(tenantContext("acme"), func(db *gorm.DB) *gorm.DB { return db.Where("email LIKE ?", "%@acme.test") })
+	require.NoError(t, err)
+	assert.Len(t, users, 2)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "repo_users" WHERE "repo_users"."tenant_id" = $1 AND "repo_users"."deleted_at" IS NULL`)).
+		WithArgs("acme").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+	count, err := repo.Count(tenantContext("acme"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_Create(t *testing.T) {
+	repo, mock := newTestRepository(t)
+
+	mock.ExpectQuery(`INSERT INTO "repo_users"`).
+		WithArgs("acme", "a@acme.test", 1, sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9))
+
+	user := &repoUser{TenantID: "acme", Email: "a@acme.test"}
+	require.NoError(t, repo.Create(tenantContext("acme"), user))
+	assert.Equal(t, uint(9), user.ID)
+	assert.Equal(t, 1, user.Version, "new rows start at version 1")
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_Create_Duplicate(t *testing.T) {
+	repo, mock := newTestRepository(t)
+
+	mock.ExpectQuery(`INSERT INTO "repo_users"`).
+		WillReturnError(&pgconn.PgError{Code: "23505", ConstraintName: "idx_repo_users_email", Detail: "Key (email)=(a@acme.test) already exists."})
+
+	err := repo.Create(tenantContext("acme"), &repoUser{TenantID: "acme", Email: "a@acme.test"})
+
+	appErr := errors.GetAppError(err)
+	require.NotNil(t, appErr)
+	assert.Equal(t, errors.ErrCodeDuplicateEntry, appErr.Code)
+}
+
+// expectVersionedUpdate sets up the optimistic-locking UPDATE for user 7 at version 3
+func expectVersionedUpdate(mock sqlmock.Sqlmock, rowsAffected int64) {
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "repo_users" SET "tenant_id"=$1,"email"=$2,"version"=$3,"updated_at"=$4 WHERE "repo_users"."tenant_id" = $5 AND "repo_users"."version" = $6 AND "repo_users"."deleted_at" IS NULL AND "id" = $7`)).
+		WithArgs("acme", "new@acme.test", 4, sqlmock.AnyArg(), "acme", 3, 7).
+		WillReturnResult(sqlmock.NewResult(0, rowsAffected))
+}
+
+func TestRepository_Update(t *testing.T) {
+	repo, mock := newTestRepository(t)
+	expectVersionedUpdate(mock, 1)
+
+	user := &repoUser{ID: 7, TenantID: "acme", Email: "new@acme.test", Version: 3}
+	require.NoError(t, repo.Update(tenantContext("acme"), user))
+	assert.Equal(t, 4, user.Version)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_Update_Conflict(t *testing.T) {
+	repo, mock := newTestRepository(t)
+	now := time.Now()
+	expectVersionedUpdate(mock, 0)
+	// The row still exists, so another writer bumped the version
+	mock.ExpectQuery(`SELECT \* FROM "repo_users"`).
+		WillReturnRows(newRepoUserRows().AddRow(7, "acme", "other@acme.test", 4, now, now, nil))
+
+	user := &repoUser{ID: 7, TenantID: "acme", Email: "new@acme.test", Version: 3}
+	err := repo.Update(tenantContext("acme"), user)
+
+	appErr := errors.GetAppError(err)
+	require.NotNil(t, appErr)
+	assert.Equal(t, errors.ErrCodeConflict, appErr.Code)
+	assert.Equal(t, 3, user.Version, "version is left unchanged on conflict")
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_Update_NotFound(t *testing.T) {
+	repo, mock := newTestRepository(t)
+	expectVersionedUpdate(mock, 0)
+	mock.ExpectQuery(`SELECT \* FROM "repo_users"`).WillReturnRows(newRepoUserRows())
+
+	err := repo.Update(tenantContext("acme"), &repoUser{ID: 7, TenantID: "acme", Email: "new@acme.test", Version: 3})
+
+	appErr := errors.GetAppError(err)
+	require.NotNil(t, appErr)
+	assert.Equal(t, errors.ErrCodeNotFound, appErr.Code)
+}
+
+func TestRepository_Delete(t *testing.T) {
+	testCases := []struct {
+		Name            string
+		Unscoped        bool
+		ExpectedSQL     string
+		RowsAffected    int64
+		ExpectedErrCode errors.ErrorCode
+	}{
+		{
+			Name:         "Soft Delete",
+			ExpectedSQL:  `UPDATE "repo_users" SET "deleted_at"=$1 WHERE "repo_users"."tenant_id" = $2 AND "repo_users"."id" = $3 AND "repo_users"."deleted_at" IS NULL`,
+			RowsAffected: 1,
+		},
+		{
+			Name:         "Permanent Delete",
+			Unscoped:     true,
+			ExpectedSQL:  `DELETE FROM "repo_users" WHERE "repo_users"."tenant_id" = $1 AND "repo_users"."id" = $2`,
+			RowsAffected: 1,
+		},
+		{
+			Name:            "Not Found",
+			ExpectedSQL:     `UPDATE "repo_users" SET "deleted_at"=$1`,
+			RowsAffected:    0,
+			ExpectedErrCode: errors.ErrCodeNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			repo, mock := newTestRepository(t)
+			if tc.Unscoped {
+				repo = repo.Unscoped()
+			}
+			mock.ExpectExec(regexp.QuoteMeta(tc.ExpectedSQL)).WillReturnResult(sqlmock.NewResult(0, tc.RowsAffected))
+
+			err := repo.Delete(tenantContext("acme"), 7)
+
+			if tc.ExpectedErrCode != "" {
+				appErr := errors.GetAppError(err)
+				require.NotNil(t, appErr)
+				assert.Equal(t, tc.ExpectedErrCode, appErr.Code)
+			} else {
+				require.NoError(t, err)
+			}
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRepository_Restore(t *testing.T) {
+	repo, mock := newTestRepository(t)
+
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "repo_users" SET "deleted_at"=$1,"updated_at"=$2 WHERE "repo_users"."tenant_id" = $3 AND "repo_users"."id" = $4 AND "repo_users"."deleted_at" IS NOT NULL`)).
+		WithArgs(nil, sqlmock.AnyArg(), "acme", 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, repo.Restore(tenantContext("acme"), 7))
+
+	mock.ExpectExec(`UPDATE "repo_users"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	err := repo.Restore(tenantContext("acme"), 8)
+	appErr := errors.GetAppError(err)
+	require.NotNil(t, appErr)
+	assert.Equal(t, errors.ErrCodeNotFound, appErr.Code)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// plainItem has neither soft delete nor a version column
+type plainItem struct {
+	ID   uint
+	Name string
+}
+
+func TestRepository_PlainModel(t *testing.T) {
+	db, mock := newHealthTestDB(t)
+	repo := NewRepository[plainItem](db, RepositoryOptions{})
+
+	err := repo.Restore(context.Background(), 1)
+	appErr := errors.GetAppError(err)
+	require.NotNil(t, appErr)
+	assert.Equal(t, errors.ErrCodeInternal, appErr.Code)
+
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "plain_items" SET "name"=$1 WHERE "id" = $2`)).
+		WithArgs("renamed", 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, repo.Update(context.Background(), &plainItem{ID: 1, Name: "renamed"}))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_JoinsTransaction(t *testing.T) {
+	repo, mock := newTestRepository(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "repo_users" SET "deleted_at"`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	ctx := tenantContext("acme")
+	err := WithTx(ctx, repo.db, nil, func(ctx context.Context, tx *gorm.DB) error {
+		return repo.Delete(ctx, 7)
+	})
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
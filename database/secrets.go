@@ -0,0 +1,261 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/medbai2/common-go/errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// Default secret provider settings
+const (
+	DefaultSecretPollInterval = 30 * time.Second
+	DefaultSecretCacheTTL     = time.Minute
+	DefaultSecretHTTPTimeout  = 5 * time.Second
+)
+
+// maxSecretResponseSize bounds how much of an HTTP secret response is read
+const maxSecretResponseSize = 1 << 20
+
+// SecretProvider supplies the database password. It is called for every new connection,
+// so a rotated password is used as soon as the provider returns it; existing connections
+// keep working until they age out through ConnMaxLifetime.
+// Implementations must be safe for concurrent use.
+type SecretProvider interface {
+	Secret(ctx context.Context) (string, error)
+}
+
+// SecretProviderFunc adapts a function to a SecretProvider
+type SecretProviderFunc func(ctx context.Context) (string, error)
+
+// Secret implements SecretProvider
+func (f SecretProviderFunc) Secret(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// envSecretProvider reads the secret from an environment variable on every call
+type envSecretProvider struct {
+	name string
+}
+
+// NewEnvSecretProvider returns a provider reading the environment variable name
+func NewEnvSecretProvider(name string) SecretProvider {
+	return &envSecretProvider{name: name}
+}
+
+// Secret implements SecretProvider
+func (p *envSecretProvider) Secret(ctx context.Context) (string, error) {
+	value := os.Getenv(p.name)
+	if value == "" {
+		return "", errors.NewWithDetails(errors.ErrCodeDatabaseError, "database secret is not set", fmt.Sprintf("environment variable %s is empty", p.name))
+	}
+	return value, nil
+}
+
+// FileSecretProvider reads the secret from a file, such as a mounted Kubernetes secret, and
+// re-reads it when the file changes. Changes are detected by polling the file's modification
+// time and size, which also works for the atomic symlink swaps Kubernetes uses on update.
+type FileSecretProvider struct {
+	path         string
+	pollInterval time.Duration
+
+	mu        sync.Mutex
+	value     string
+	modTime   time.Time
+	size      int64
+	checkedAt time.Time
+}
+
+// NewFileSecretProvider returns a provider reading path, checking it for changes at most
+// once per pollInterval (default: 30s). The file is read once up front so a missing or
+// empty secret fails at startup.
+func NewFileSecretProvider(path string, pollInterval time.Duration) (*FileSecretProvider, error) {
+	if pollInterval <= 0 {
+		pollInterval = DefaultSecretPollInterval
+	}
+	p := &FileSecretProvider{path: path, pollInterval: pollInterval}
+	if _, err := p.Secret(context.Background()); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Secret implements SecretProvider. If a changed file cannot be read, the last value is kept.
+func (p *FileSecretProvider) Secret(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	if p.value != "" && now.Sub(p.checkedAt) < p.pollInterval {
+		return p.value, nil
+	}
+	p.checkedAt = now
+
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return p.fallback(err)
+	}
+	if p.value != "" && info.ModTime().Equal(p.modTime) && info.Size() == p.size {
+		return p.value, nil
+	}
+
+	content, err := os.ReadFile(p.path)
+	if err != nil {
+		return p.fallback(err)
+	}
+	value := strings.TrimRight(string(content), "\r\n")
+	if value == "" {
+		return p.fallback(fmt.Errorf("secret file %s is empty", p.path))
+	}
+
+	p.value = value
+	p.modTime = info.ModTime()
+	p.size = info.Size()
+	return value, nil
+}
+
+// fallback returns the last known value, or err when there is none
+func (p *FileSecretProvider) fallback(err error) (string, error) {
+	if p.value != "" {
+		return p.value, nil
+	}
+	return "", errors.Wrap(err, errors.ErrCodeDatabaseError, "failed to read database secret file")
+}
+
+// HTTPSecretConfig configures an HTTP secret provider
+type HTTPSecretConfig struct {
+	URL      string
+	Headers  map[string]string // e.g. {"X-Vault-Token": token}
+	Field    string            // Dotted path to the secret in a JSON response (e.g. "data.data.password"); empty uses the raw body
+	CacheTTL time.Duration     // How long a fetched secret is reused (default: 1m)
+	Client   *http.Client      // default: client with a 5s timeout
+}
+
+// httpSecretProvider fetches the secret from a vault-style HTTP endpoint
+type httpSecretProvider struct {
+	cfg HTTPSecretConfig
+
+	mu        sync.Mutex
+	value     string
+	fetchedAt time.Time
+}
+
+// NewHTTPSecretProvider returns a provider fetching the secret with GET cfg.URL
+func NewHTTPSecretProvider(cfg HTTPSecretConfig) (SecretProvider, error) {
+	if cfg.URL == "" {
+		return nil, errors.NewMissingField("url")
+	}
+	if cfg.CacheTTL <= 0 {
+		cfg.CacheTTL = DefaultSecretCacheTTL
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: DefaultSecretHTTPTimeout}
+	}
+	return &httpSecretProvider{cfg: cfg}, nil
+}
+
+// Secret implements SecretProvider. If a refresh fails, the last fetched value is kept.
+func (p *httpSecretProvider) Secret(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.value != "" && time.Since(p.fetchedAt) < p.cfg.CacheTTL {
+		return p.value, nil
+	}
+
+	value, err := p.fetch(ctx)
+	if err != nil {
+		if p.value != "" {
+			return p.value, nil
+		}
+		return "", errors.Wrap(err, errors.ErrCodeDatabaseError, "failed to fetch database secret")
+	}
+
+	p.value = value
+	p.fetchedAt = time.Now()
+	return value, nil
+}
+
+// fetch retrieves and extracts the secret
+func (p *httpSecretProvider) fetch(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.cfg.URL, nil)
+	if err != nil {
+		return "", err
+	}
+	for key, value := range p.cfg.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := p.cfg.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxSecretResponseSize))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		// The body is not included: it may echo credentials
+		return "", fmt.Errorf("secret endpoint returned status %d", resp.StatusCode)
+	}
+
+	if p.cfg.Field == "" {
+		value := strings.TrimSpace(string(body))
+		if value == "" {
+			return "", fmt.Errorf("secret endpoint returned an empty body")
+		}
+		return value, nil
+	}
+	return extractJSONField(body, p.cfg.Field)
+}
+
+// extractJSONField returns the string at a dotted path in a JSON document
+func extractJSONField(body []byte, path string) (string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var current interface{}
+	if err := decoder.Decode(&current); err != nil {
+		return "", fmt.Errorf("secret response is not valid JSON")
+	}
+
+	for _, key := range strings.Split(path, ".") {
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("secret field %q not found", path)
+		}
+		if current, ok = object[key]; !ok {
+			return "", fmt.Errorf("secret field %q not found", path)
+		}
+	}
+
+	value, ok := current.(string)
+	if !ok || value == "" {
+		return "", fmt.Errorf("secret field %q is not a non-empty string", path)
+	}
+	return value, nil
+}
+
+// passwordBeforeConnect returns a pgx hook that sets the password of every new connection
+func passwordBeforeConnect(provider SecretProvider) stdlib.OptionOpenDB {
+	return stdlib.OptionBeforeConnect(func(ctx context.Context, connConfig *pgx.ConnConfig) error {
+		password, err := provider.Secret(ctx)
+		if err != nil {
+			return err
+		}
+		connConfig.Password = password
+		return nil
+	})
+}
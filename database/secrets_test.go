@@ -0,0 +1,255 @@
+package database
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/medbai2/common-go/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvSecretProvider(t *testing.T) {
+	t.Setenv("TEST_DB_PASSWORD", "from-env")
+	provider := NewEnvSecretProvider("TEST_DB_PASSWORD")
+
+	value, err := provider.Secret(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "from-env", value)
+
+	// Rotation: the variable is read on every call
+	t.Setenv("TEST_DB_PASSWORD", "rotated")
+	value, err = provider.Secret(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "rotated", value)
+
+	t.Setenv("TEST_DB_PASSWORD", "")
+	_, err = provider.Secret(context.Background())
+	appErr := errors.GetAppError(err)
+	require.NotNil(t, appErr)
+	assert.Equal(t, errors.ErrCodeDatabaseError, appErr.Code)
+}
+
+// writeSecretFile writes content to path with an explicit modification time
+func writeSecretFile(t *testing.T, path, content string, modTime time.Time) {
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func TestFileSecretProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "password")
+	writeSecretFile(t, path, "first\n", time.Now().Add(-time.Hour))
+
+	provider, err := NewFileSecretProvider(path, time.Nanosecond)
+	require.NoError(t, err)
+
+	value, err := provider.Secret(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "first", value)
+
+	// A changed file is picked up
+	writeSecretFile(t, path, "second", time.Now())
+	value, err = provider.Secret(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "second", value)
+
+	// A file that disappears mid-rotation keeps the last value
+	require.NoError(t, os.Remove(path))
+	value, err = provider.Secret(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "second", value)
+}
+
+func TestFileSecretProvider_PollInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "password")
+	writeSecretFile(t, path, "first", time.Now().Add(-time.Hour))
+
+	provider, err := NewFileSecretProvider(path, time.Hour)
+	require.NoError(t, err)
+
+	// Within the poll interval the cached value is returned without touching the file
+	writeSecretFile(t, path, "second", time.Now())
+	value, err := provider.Secret(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "first", value)
+}
+
+func TestNewFileSecretProvider_Errors(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := NewFileSecretProvider(filepath.Join(dir, "missing"), 0)
+	assert.Error(t, err)
+
+	empty := filepath.Join(dir, "empty")
+	writeSecretFile(t, empty, "\n", time.Now())
+	_, err = NewFileSecretProvider(empty, 0)
+	assert.Error(t, err)
+}
+
+func TestHTTPSecretProvider(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		Field         string
+		StatusCode    int
+		Body          string
+		ExpectedValue string
+		ExpectedError bool
+	}{
+		{
+			Name:          "Raw Body",
+			StatusCode:    http.StatusOK,
+			Body:          "s3cret\n",
+			ExpectedValue: "s3cret",
+		},
+		{
+			Name:          "JSON Field",
+			Field:         "data.data.password",
+			StatusCode:    http.StatusOK,
+			Body:          `{"data":{"data":{"password":"from-vault"}}}`,
+			ExpectedValue: "from-vault",
+		},
+		{
+			Name:          "Missing JSON Field",
+			Field:         "data.password",
+			StatusCode:    http.StatusOK,
+			Body:          `{"data":{}}`,
+			ExpectedError: true,
+		},
+		{
+			Name:          "Non-String JSON Field",
+			Field:         "data",
+			StatusCode:    http.StatusOK,
+			Body:          `{"data":42}`,
+			ExpectedError: true,
+		},
+		{
+			Name:          "Error Status",
+			StatusCode:    http.StatusForbidden,
+			Body:          "permission denied",
+			ExpectedError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "vault-token", r.Header.Get("X-Vault-Token"))
+				w.WriteHeader(tc.StatusCode)
+				w.Write([]byte(tc.Body))
+			}))
+			defer server.Close()
+
+			provider, err := NewHTTPSecretProvider(HTTPSecretConfig{
+				URL:     server.URL,
+				Field:   tc.Field,
+				Headers: map[string]string{"X-Vault-Token": "vault-token"},
+			})
+			require.NoError(t, err)
+
+			value, err := provider.Secret(context.Background())
+			if tc.ExpectedError {
+				require.Error(t, err)
+				assert.NotContains(t, err.Error(), tc.Body)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.ExpectedValue, value)
+		})
+	}
+}
+
+func TestHTTPSecretProvider_CacheAndFallback(t *testing.T) {
+	var requests atomic.Int32
+	var failing atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("cached-secret"))
+	}))
+	defer server.Close()
+
+	provider, err := NewHTTPSecretProvider(HTTPSecretConfig{URL: server.URL, CacheTTL: time.Hour})
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		value, err := provider.Secret(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "cached-secret", value)
+	}
+	assert.Equal(t, int32(1), requests.Load())
+
+	// Expire the cache; a failed refresh keeps the last value
+	provider.(*httpSecretProvider).fetchedAt = time.Time{}
+	failing.Store(true)
+	value, err := provider.Secret(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "cached-secret", value)
+	assert.Equal(t, int32(2), requests.Load())
+}
+
+func TestNewHTTPSecretProvider_MissingURL(t *testing.T) {
+	_, err := NewHTTPSecretProvider(HTTPSecretConfig{})
+
+	appErr := errors.GetAppError(err)
+	require.NotNil(t, appErr)
+	assert.Equal(t, errors.ErrCodeMissingField, appErr.Code)
+}
+
+func TestNew_PasswordProviderRotation(t *testing.T) {
+	var current atomic.Value
+	current.Store("old-password")
+	provider := SecretProviderFunc(func(ctx context.Context) (string, error) {
+		return current.Load().(string), nil
+	})
+
+	// The server accepts whichever password is current, as after an ALTER ROLE
+	stub := newPGStub(t, func(password string) bool { return password == current.Load().(string) })
+
+	db, err := New(Config{
+		Host:             "127.0.0.1",
+		Port:             stub.port(),
+		Name:             "app",
+		User:             "app",
+		PasswordProvider: provider,
+		SSLMode:          "disable",
+		Logger:           newRecordingLogger(),
+	})
+	require.NoError(t, err)
+	defer Close(db)
+
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	held, err := sqlDB.Conn(context.Background())
+	require.NoError(t, err)
+	defer held.Close()
+
+	// Rotate: the next new connection authenticates with the new password
+	current.Store("new-password")
+	conn, err := sqlDB.Conn(context.Background())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	passwords := stub.seenPasswords()
+	assert.Equal(t, "old-password", passwords[0])
+	assert.Equal(t, "new-password", passwords[len(passwords)-1])
+
+	// Connections opened before the rotation keep working
+	require.NoError(t, held.PingContext(context.Background()))
+}
+
+func TestNew_PasswordRequired(t *testing.T) {
+	_, err := New(Config{Host: "127.0.0.1", Port: 5432, User: "app", Logger: newRecordingLogger()})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "password provider")
+}
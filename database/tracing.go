@@ -0,0 +1,125 @@
+package database
+
+import (
+	stderrors "errors"
+	"strings"
+
+	"github.com/medbai2/common-go/errors"
+	"github.com/medbai2/common-go/tracing"
+
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// tracingSpanKey is the statement instance key holding the query span
+const tracingSpanKey = "common-go:tracing_span"
+
+// RegisterTracing records a client span per statement, as a child of the span carried by
+// the statement's context (db.WithContext(ctx)). Spans carry the parameterized SQL
+// (never the bound values), operation and table, and are marked failed with the
+// translated error code when the statement fails. A nil tp uses the global provider
+// (see tracing.Init).
+//
+// Call it once after New.
+func RegisterTracing(db *gorm.DB, tp trace.TracerProvider) error {
+	if db == nil {
+		return errors.New(errors.ErrCodeDatabaseError, "database is nil")
+	}
+	if err := registerTracingCallbacks(db, tracing.Tracer(tp)); err != nil {
+		return errors.Wrap(err, errors.ErrCodeDatabaseError, "failed to register tracing callbacks")
+	}
+	return nil
+}
+
+// registerTracingCallbacks traces every create, query, update, delete, row and raw statement
+func registerTracingCallbacks(db *gorm.DB, tracer trace.Tracer) error {
+	callbacks := db.Callback()
+	start := startSpan(tracer)
+	if err := callbacks.Create().Before("gorm:create").Register("common-go:tracing_start_create", start); err != nil {
+		return err
+	}
+	if err := callbacks.Create().After("gorm:create").Register("common-go:tracing_end_create", endSpan); err != nil {
+		return err
+	}
+	if err := callbacks.Query().Before("gorm:query").Register("common-go:tracing_start_query", start); err != nil {
+		return err
+	}
+	if err := callbacks.Query().After("gorm:query").Register("common-go:tracing_end_query", endSpan); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("common-go:tracing_start_update", start); err != nil {
+		return err
+	}
+	if err := callbacks.Update().After("gorm:update").Register("common-go:tracing_end_update", endSpan); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().Before("gorm:delete").Register("common-go:tracing_start_delete", start); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().After("gorm:delete").Register("common-go:tracing_end_delete", endSpan); err != nil {
+		return err
+	}
+	if err := callbacks.Row().Before("gorm:row").Register("common-go:tracing_start_row", start); err != nil {
+		return err
+	}
+	if err := callbacks.Row().After("gorm:row").Register("common-go:tracing_end_row", endSpan); err != nil {
+		return err
+	}
+	if err := callbacks.Raw().Before("gorm:raw").Register("common-go:tracing_start_raw", start); err != nil {
+		return err
+	}
+	return callbacks.Raw().After("gorm:raw").Register("common-go:tracing_end_raw", endSpan)
+}
+
+// startSpan returns a callback starting the statement's span
+func startSpan(tracer trace.Tracer) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Statement.Context == nil {
+			return
+		}
+		_, span := tracer.Start(db.Statement.Context, "db",
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(semconv.DBSystemNamePostgreSQL),
+		)
+		db.InstanceSet(tracingSpanKey, span)
+	}
+}
+
+// endSpan names the statement's span after the executed SQL and ends it
+func endSpan(db *gorm.DB) {
+	value, ok := db.InstanceGet(tracingSpanKey)
+	if !ok {
+		return
+	}
+	span, ok := value.(trace.Span)
+	if !ok {
+		return
+	}
+	defer span.End()
+
+	sql := db.Statement.SQL.String()
+	operation := strings.ToUpper(strings.SplitN(strings.TrimSpace(sql), " ", 2)[0])
+	name := operation
+	if table := db.Statement.Table; table != "" {
+		name += " " + table
+		span.SetAttributes(semconv.DBCollectionName(table))
+	}
+	if name != "" {
+		span.SetName(name)
+	}
+	if operation != "" {
+		span.SetAttributes(semconv.DBOperationName(operation))
+	}
+	span.SetAttributes(semconv.DBQueryText(sql))
+
+	if db.Error != nil && !stderrors.Is(db.Error, gorm.ErrRecordNotFound) {
+		code := errors.ErrCodeDatabaseError
+		if appErr := errors.GetAppError(TranslateError(db.Error)); appErr != nil {
+			code = appErr.Code
+		}
+		span.RecordError(db.Error)
+		span.SetStatus(codes.Error, string(code))
+	}
+}
@@ -0,0 +1,92 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/medbai2/common-go/errors"
+	"github.com/medbai2/common-go/tracing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// newTracingTestProvider returns a provider exporting spans synchronously to memory
+func newTracingTestProvider(t *testing.T) (*sdktrace.TracerProvider, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp, err := tracing.NewTracerProvider(tracing.Config{ServiceName: "test", Exporter: exporter, Synchronous: true})
+	require.NoError(t, err)
+	return tp, exporter
+}
+
+func TestRegisterTracing_SpanPerQuery(t *testing.T) {
+	db, mock := newMockGormDB(t)
+	tp, exporter := newTracingTestProvider(t)
+	require.NoError(t, RegisterTracing(db, tp))
+
+	mock.ExpectQuery(`SELECT \* FROM "users"`).WillReturnRows(sqlmock.NewRows([]string{"id", "email"}))
+	mock.ExpectExec(`UPDATE users`).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	ctx, parent := tracing.Tracer(tp).Start(context.Background(), "GET /users")
+	var users []replicaTestUser
+	require.NoError(t, db.WithContext(ctx).Where("email = ?", "secret@example.com").Find(&users).Error)
+	require.NoError(t, db.WithContext(ctx).Exec("UPDATE users SET email = ''").Error)
+	parent.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 3)
+
+	query := spans[0]
+	assert.Equal(t, "SELECT users", query.Name)
+	assert.Equal(t, trace.SpanKindClient, query.SpanKind)
+	assert.Equal(t, parent.SpanContext().SpanID(), query.Parent.SpanID())
+	assert.Contains(t, query.Attributes, semconv.DBSystemNamePostgreSQL)
+	assert.Contains(t, query.Attributes, semconv.DBOperationName("SELECT"))
+	assert.Contains(t, query.Attributes, semconv.DBCollectionName("users"))
+	assert.Contains(t, query.Attributes, semconv.DBQueryText(`SELECT * FROM "users" WHERE email = $1`))
+	assert.Equal(t, codes.Unset, query.Status.Code)
+
+	raw := spans[1]
+	assert.Equal(t, "UPDATE", raw.Name)
+	assert.Equal(t, parent.SpanContext().SpanID(), raw.Parent.SpanID())
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRegisterTracing_Errors(t *testing.T) {
+	db, mock := newMockGormDB(t)
+	tp, exporter := newTracingTestProvider(t)
+	require.NoError(t, RegisterTracing(db, tp))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "users"`).WillReturnError(&pgconn.PgError{Code: sqlStateUniqueViolation, TableName: "users"})
+	mock.ExpectRollback()
+	mock.ExpectQuery(`SELECT \* FROM "users"`).WillReturnRows(sqlmock.NewRows([]string{"id", "email"}))
+
+	assert.Error(t, db.Create(&replicaTestUser{Email: "a@example.com"}).Error)
+	var user replicaTestUser
+	assert.Error(t, db.First(&user).Error)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "INSERT users", spans[0].Name)
+	assert.Equal(t, codes.Error, spans[0].Status.Code)
+	assert.Equal(t, string(errors.ErrCodeDuplicateEntry), spans[0].Status.Description)
+	assert.Len(t, spans[0].Events, 1)
+
+	// A missing record is a normal outcome, not a failed statement
+	assert.Equal(t, codes.Unset, spans[1].Status.Code)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRegisterTracing_NilDatabase(t *testing.T) {
+	err := RegisterTracing(nil, nil)
+	require.Error(t, err)
+	assert.Equal(t, errors.ErrCodeDatabaseError, errors.GetAppError(err).Code)
+}
@@ -0,0 +1,167 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	stderrors "errors"
+	"time"
+
+	"github.com/medbai2/common-go/errors"
+	"github.com/medbai2/common-go/internal/backoff"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// PostgreSQL SQLSTATE codes that indicate a transaction can be safely retried
+const (
+	sqlStateSerializationFailure = "40001"
+	sqlStateDeadlockDetected     = "40P01"
+)
+
+// Default retry settings used when TxOptions are not provided
+const (
+	DefaultTxMaxRetries     = 3
+	DefaultTxInitialBackoff = 50 * time.Millisecond
+	DefaultTxMaxBackoff     = 1 * time.Second
+)
+
+// txContextKey is the context key under which the active transaction is stored
+type txContextKey struct{}
+
+// TxOptions configures a transaction started by WithTx
+type TxOptions struct {
+	Isolation      sql.IsolationLevel // Isolation level (default: database default, READ COMMITTED on PostgreSQL)
+	ReadOnly       bool               // Start the transaction in READ ONLY mode
+	MaxRetries     int                // Retries on serialization failures and deadlocks (0 disables retries)
+	InitialBackoff time.Duration      // Delay before the first retry (default: DefaultTxInitialBackoff)
+	MaxBackoff     time.Duration      // Upper bound for the delay between retries (default: DefaultTxMaxBackoff)
+}
+
+// DefaultTxOptions returns the options used when WithTx is called with nil options
+func DefaultTxOptions() *TxOptions {
+	return &TxOptions{
+		Isolation:      sql.LevelDefault,
+		MaxRetries:     DefaultTxMaxRetries,
+		InitialBackoff: DefaultTxInitialBackoff,
+		MaxBackoff:     DefaultTxMaxBackoff,
+	}
+}
+
+// TxFunc is the unit of work executed inside a transaction.
+// ctx carries the transaction, so functions called with it can join it via Conn or WithTx.
+type TxFunc func(ctx context.Context, tx *gorm.DB) error
+
+// WithTx runs fn inside a database transaction.
+//
+// If ctx already carries a transaction (because WithTx is being called from inside
+// another WithTx), fn joins it through a savepoint: an error from fn rolls back only
+// the work done since the savepoint, and the outer transaction decides whether to commit.
+// Options are ignored for nested calls since isolation and access mode are fixed at BEGIN.
+//
+// The outermost call commits when fn returns nil and rolls back otherwise (including on panic).
+// Serialization failures (40001) and deadlocks (40P01) restart the whole transaction with
+// exponential backoff and jitter, so fn must be safe to run more than once.
+//
+// Errors returned by fn that are already AppErrors are returned unchanged; context
+// cancellation becomes ErrCodeTimeout and other errors are converted by TranslateError
+// (so exhausted retries surface as ErrCodeConflict).
+func WithTx(ctx context.Context, db *gorm.DB, opts *TxOptions, fn TxFunc) error {
+	if db == nil {
+		return errors.New(errors.ErrCodeDatabaseError, "database is nil")
+	}
+	if opts == nil {
+		opts = DefaultTxOptions()
+	}
+
+	// Nested call: join the outer transaction through a savepoint
+	if outer := txFromContext(ctx); outer != nil {
+		err := outer.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return fn(ctx, tx)
+		})
+		return convertTxError(ctx, err)
+	}
+
+	txOpts := &sql.TxOptions{Isolation: opts.Isolation, ReadOnly: opts.ReadOnly}
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			txCtx := context.WithValue(ctx, txContextKey{}, tx)
+			return fn(txCtx, tx.WithContext(txCtx))
+		}, txOpts)
+
+		if err == nil || !isRetryableTxError(err) || attempt >= opts.MaxRetries {
+			break
+		}
+
+		delay := backoffDelay(attempt, opts.InitialBackoff, opts.MaxBackoff)
+		select {
+		case <-ctx.Done():
+			return errors.Wrap(ctx.Err(), errors.ErrCodeTimeout, "transaction retry aborted")
+		case <-time.After(delay):
+		}
+	}
+
+	return convertTxError(ctx, err)
+}
+
+// Conn returns the transaction carried by ctx, or db bound to ctx when there is none.
+// Repository code should use it so it transparently participates in an enclosing WithTx.
+func Conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx := txFromContext(ctx); tx != nil {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
+
+// InTx reports whether ctx carries an active transaction started by WithTx
+func InTx(ctx context.Context) bool {
+	return txFromContext(ctx) != nil
+}
+
+// txFromContext extracts the active transaction from context
+func txFromContext(ctx context.Context) *gorm.DB {
+	if ctx == nil {
+		return nil
+	}
+	if tx, ok := ctx.Value(txContextKey{}).(*gorm.DB); ok {
+		return tx
+	}
+	return nil
+}
+
+// isRetryableTxError reports whether err is a serialization failure or deadlock
+func isRetryableTxError(err error) bool {
+	var pgErr *pgconn.PgError
+	if stderrors.As(err, &pgErr) {
+		return pgErr.Code == sqlStateSerializationFailure || pgErr.Code == sqlStateDeadlockDetected
+	}
+	return false
+}
+
+// convertTxError converts a transaction error into an AppError
+func convertTxError(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	if appErr := errors.GetAppError(err); appErr != nil {
+		return appErr
+	}
+	if ctx.Err() != nil && !isRetryableTxError(err) {
+		return errors.Wrap(err, errors.ErrCodeTimeout, "transaction cancelled")
+	}
+	return TranslateError(err)
+}
+
+// backoffDelay returns the delay before retry number attempt (0-based) using
+// exponential backoff capped at max, with full jitter in the upper half of the interval
+func backoffDelay(attempt int, initial, max time.Duration) time.Duration {
+	if initial <= 0 {
+		initial = DefaultTxInitialBackoff
+	}
+	if max <= 0 {
+		max = DefaultTxMaxBackoff
+	}
+	return backoff.Jitter(attempt, initial, max)
+}
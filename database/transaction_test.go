@@ -0,0 +1,268 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	stderrors "errors"
+	"testing"
+	"time"
+
+	"github.com/medbai2/common-go/errors"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// newMockGormDB creates a GORM handle backed by sqlmock
+func newMockGormDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { mockDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: mockDB,
+	}), &gorm.Config{Logger: gormlogger.Discard})
+	require.NoError(t, err)
+
+	return db, mock
+}
+
+// fastRetryOptions returns transaction options with negligible backoff for tests
+func fastRetryOptions(retries int) *TxOptions {
+	return &TxOptions{
+		MaxRetries:     retries,
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     2 * time.Millisecond,
+	}
+}
+
+func TestWithTx_Commit(t *testing.T) {
+	db, mock := newMockGormDB(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE accounts").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := WithTx(context.Background(), db, nil, func(ctx context.Context, tx *gorm.DB) error {
+		assert.True(t, InTx(ctx))
+		return tx.Exec("UPDATE accounts SET balance = 0").Error
+	})
+
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWithTx_RollbackOnError(t *testing.T) {
+	db, mock := newMockGormDB(t)
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	fnErr := stderrors.New("boom")
+	err := WithTx(context.Background(), db, nil, func(ctx context.Context, tx *gorm.DB) error {
+		return fnErr
+	})
+
+	appErr := errors.GetAppError(err)
+	require.NotNil(t, appErr)
+	assert.Equal(t, errors.ErrCodeDatabaseError, appErr.Code)
+	assert.ErrorIs(t, err, fnErr)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWithTx_AppErrorReturnedUnchanged(t *testing.T) {
+	db, mock := newMockGormDB(t)
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	notFound := errors.NewNotFound("account")
+	err := WithTx(context.Background(), db, nil, func(ctx context.Context, tx *gorm.DB) error {
+		return notFound
+	})
+
+	assert.Same(t, notFound, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWithTx_RollbackOnPanic(t *testing.T) {
+	db, mock := newMockGormDB(t)
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	assert.Panics(t, func() {
+		_ = WithTx(context.Background(), db, nil, func(ctx context.Context, tx *gorm.DB) error {
+			panic("unexpected")
+		})
+	})
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWithTx_RetriesSerializationFailure(t *testing.T) {
+	testCases := []struct {
+		Name string
+		Code string
+	}{
+		{Name: "Serialization Failure", Code: sqlStateSerializationFailure},
+		{Name: "Deadlock Detected", Code: sqlStateDeadlockDetected},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			db, mock := newMockGormDB(t)
+
+			mock.ExpectBegin()
+			mock.ExpectExec("UPDATE accounts").WillReturnError(&pgconn.PgError{Code: tc.Code})
+			mock.ExpectRollback()
+			mock.ExpectBegin()
+			mock.ExpectExec("UPDATE accounts").WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+
+			attempts := 0
+			err := WithTx(context.Background(), db, fastRetryOptions(3), func(ctx context.Context, tx *gorm.DB) error {
+				attempts++
+				return tx.Exec("UPDATE accounts SET balance = 0").Error
+			})
+
+			require.NoError(t, err)
+			assert.Equal(t, 2, attempts)
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestWithTx_RetriesExhausted(t *testing.T) {
+	db, mock := newMockGormDB(t)
+
+	for i := 0; i < 2; i++ {
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE accounts").WillReturnError(&pgconn.PgError{Code: sqlStateSerializationFailure})
+		mock.ExpectRollback()
+	}
+
+	attempts := 0
+	err := WithTx(context.Background(), db, fastRetryOptions(1), func(ctx context.Context, tx *gorm.DB) error {
+		attempts++
+		return tx.Exec("UPDATE accounts SET balance = 0").Error
+	})
+
+	appErr := errors.GetAppError(err)
+	require.NotNil(t, appErr)
+	assert.Equal(t, errors.ErrCodeConflict, appErr.Code)
+	assert.Equal(t, 2, attempts)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWithTx_NoRetryForOtherErrors(t *testing.T) {
+	db, mock := newMockGormDB(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO accounts").WillReturnError(&pgconn.PgError{Code: "23505"})
+	mock.ExpectRollback()
+
+	attempts := 0
+	err := WithTx(context.Background(), db, fastRetryOptions(3), func(ctx context.Context, tx *gorm.DB) error {
+		attempts++
+		return tx.Exec("INSERT INTO accounts (id) VALUES (1)").Error
+	})
+
+	require.Error(t, err)
+	assert.Equal(t, 1, attempts)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWithTx_NestedUsesSavepoint(t *testing.T) {
+	db, mock := newMockGormDB(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO orders").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("SAVEPOINT sp").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO audit").WillReturnError(stderrors.New("audit failed"))
+	mock.ExpectExec("ROLLBACK TO SAVEPOINT sp").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	err := WithTx(context.Background(), db, nil, func(ctx context.Context, tx *gorm.DB) error {
+		if err := tx.Exec("INSERT INTO orders (id) VALUES (1)").Error; err != nil {
+			return err
+		}
+
+		// The nested failure is rolled back to the savepoint; the outer transaction still commits
+		nestedErr := WithTx(ctx, db, nil, func(ctx context.Context, tx *gorm.DB) error {
+			return tx.Exec("INSERT INTO audit (id) VALUES (1)").Error
+		})
+		assert.Error(t, nestedErr)
+		return nil
+	})
+
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWithTx_ContextCancelled(t *testing.T) {
+	db, mock := newMockGormDB(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := WithTx(ctx, db, nil, func(ctx context.Context, tx *gorm.DB) error {
+		t.Fatal("fn must not run when the context is already cancelled")
+		return nil
+	})
+
+	appErr := errors.GetAppError(err)
+	require.NotNil(t, appErr)
+	assert.Equal(t, errors.ErrCodeTimeout, appErr.Code)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWithTx_NilDatabase(t *testing.T) {
+	err := WithTx(context.Background(), nil, nil, func(ctx context.Context, tx *gorm.DB) error {
+		return nil
+	})
+
+	appErr := errors.GetAppError(err)
+	require.NotNil(t, appErr)
+	assert.Equal(t, errors.ErrCodeDatabaseError, appErr.Code)
+}
+
+func TestConn(t *testing.T) {
+	db, mock := newMockGormDB(t)
+
+	// Outside a transaction Conn returns the plain handle
+	assert.False(t, InTx(context.Background()))
+	assert.NotNil(t, Conn(context.Background(), db))
+
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	err := WithTx(context.Background(), db, nil, func(ctx context.Context, tx *gorm.DB) error {
+		conn := Conn(ctx, db)
+		_, isTx := conn.Statement.ConnPool.(*sql.Tx)
+		assert.True(t, isTx, "Conn should return the active transaction")
+		return nil
+	})
+
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBackoffDelay(t *testing.T) {
+	initial := 10 * time.Millisecond
+	max := 80 * time.Millisecond
+
+	for attempt := 0; attempt < 6; attempt++ {
+		delay := backoffDelay(attempt, initial, max)
+		assert.GreaterOrEqual(t, delay, initial/2)
+		assert.LessOrEqual(t, delay, max)
+	}
+
+	// Zero values fall back to defaults
+	delay := backoffDelay(0, 0, 0)
+	assert.LessOrEqual(t, delay, DefaultTxInitialBackoff)
+}
@@ -28,5 +28,6 @@ const (
 	ErrCodeBusinessRule   ErrorCode = "BUSINESS_RULE_VIOLATION"
 	ErrCodeDuplicateEntry ErrorCode = "DUPLICATE_ENTRY"
 	ErrCodeNotFound       ErrorCode = "NOT_FOUND"
+	ErrCodeConflict       ErrorCode = "CONFLICT"
 )
 
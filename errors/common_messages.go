@@ -28,6 +28,7 @@ const (
 	MsgBusinessRule   = "business rule violation"
 	MsgDuplicateEntry = "entry already exists"
 	MsgNotFound       = "resource not found"
+	MsgConflict       = "request conflicts with current state"
 
 	// Common operation messages
 	MsgFailedToConnect    = "failed to connect"
@@ -65,6 +66,8 @@ func getBaseMessage(code ErrorCode) string {
 		return MsgDuplicateEntry
 	case ErrCodeNotFound:
 		return MsgNotFound
+	case ErrCodeConflict:
+		return MsgConflict
 	case ErrCodeUnauthorized:
 		return MsgUnauthorized
 	case ErrCodeForbidden:
@@ -38,6 +38,13 @@ func NewDuplicateEntry(resource string) *AppError {
 	return New(ErrCodeDuplicateEntry, GetMessage(ErrCodeDuplicateEntry, resource))
 }
 
+func NewConflict(message string) *AppError {
+	if message == "" {
+		message = MsgConflict
+	}
+	return New(ErrCodeConflict, message)
+}
+
 // System errors
 func NewDatabaseError(err error) *AppError {
 	return Wrap(err, ErrCodeDatabaseError, MsgFailedToExecute)
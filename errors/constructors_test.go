@@ -89,6 +89,25 @@ func TestNewDuplicateEntry(t *testing.T) {
 	assert.Equal(t, http.StatusConflict, appErr.HTTPStatus)
 }
 
+func TestNewConflict(t *testing.T) {
+	t.Run("with custom message", func(t *testing.T) {
+		message := "record was modified concurrently"
+		appErr := NewConflict(message)
+
+		assert.Equal(t, ErrCodeConflict, appErr.Code)
+		assert.Equal(t, message, appErr.Message)
+		assert.Equal(t, http.StatusConflict, appErr.HTTPStatus)
+	})
+
+	t.Run("with empty message", func(t *testing.T) {
+		appErr := NewConflict("")
+
+		assert.Equal(t, ErrCodeConflict, appErr.Code)
+		assert.Equal(t, MsgConflict, appErr.Message)
+		assert.Equal(t, http.StatusConflict, appErr.HTTPStatus)
+	})
+}
+
 // Test system error constructors
 func TestNewDatabaseError(t *testing.T) {
 	originalErr := errors.New("connection timeout")
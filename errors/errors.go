@@ -75,7 +75,7 @@ func getHTTPStatus(code ErrorCode) int {
 	switch code {
 	case ErrCodeInvalidInput, ErrCodeMissingField, ErrCodeInvalidFormat, ErrCodeValueTooLong, ErrCodeValueTooShort:
 		return http.StatusBadRequest
-	case ErrCodeBusinessRule, ErrCodeDuplicateEntry:
+	case ErrCodeBusinessRule, ErrCodeDuplicateEntry, ErrCodeConflict:
 		return http.StatusConflict
 	case ErrCodeNotFound:
 		return http.StatusNotFound
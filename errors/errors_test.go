@@ -201,6 +201,7 @@ func TestHTTPStatusMapping(t *testing.T) {
 		{ErrCodeExternalService, http.StatusServiceUnavailable},
 		{ErrCodeBusinessRule, http.StatusConflict},
 		{ErrCodeDuplicateEntry, http.StatusConflict},
+		{ErrCodeConflict, http.StatusConflict},
 	}
 
 	for _, tc := range testCases {
@@ -30,6 +30,7 @@ func TestMessageConstants(t *testing.T) {
 		{"MsgBusinessRule", MsgBusinessRule, "business rule violation"},
 		{"MsgDuplicateEntry", MsgDuplicateEntry, "entry already exists"},
 		{"MsgNotFound", MsgNotFound, "resource not found"},
+		{"MsgConflict", MsgConflict, "request conflicts with current state"},
 		{"MsgFailedToConnect", MsgFailedToConnect, "failed to connect"},
 		{"MsgFailedToExecute", MsgFailedToExecute, "failed to execute operation"},
 		{"MsgConfigurationError", MsgConfigurationError, "configuration error"},
@@ -60,6 +61,7 @@ func TestGetMessage_WithoutContext(t *testing.T) {
 		{"BusinessRule", ErrCodeBusinessRule, MsgBusinessRule},
 		{"DuplicateEntry", ErrCodeDuplicateEntry, MsgDuplicateEntry},
 		{"NotFound", ErrCodeNotFound, MsgNotFound},
+		{"Conflict", ErrCodeConflict, MsgConflict},
 		{"Unauthorized", ErrCodeUnauthorized, MsgUnauthorized},
 		{"Forbidden", ErrCodeForbidden, MsgForbidden},
 		{"Internal", ErrCodeInternal, MsgInternal},
@@ -187,6 +189,7 @@ func TestMessageConsistency(t *testing.T) {
 		MsgBusinessRule,
 		MsgDuplicateEntry,
 		MsgNotFound,
+		MsgConflict,
 		MsgFailedToConnect,
 		MsgFailedToExecute,
 		MsgConfigurationError,
@@ -235,6 +238,7 @@ func TestMessageLength(t *testing.T) {
 		MsgBusinessRule,
 		MsgDuplicateEntry,
 		MsgNotFound,
+		MsgConflict,
 		MsgFailedToConnect,
 		MsgFailedToExecute,
 		MsgConfigurationError,
@@ -0,0 +1,71 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/medbai2/common-go/config"
+	"github.com/medbai2/common-go/database"
+
+	"gorm.io/gorm"
+)
+
+// DatabaseChecker checks db with database.Check. A degraded database (slow, saturated pool,
+// lagging, pending migrations) is reported as degraded; an unreachable one as unhealthy.
+func DatabaseChecker(db *gorm.DB, opts *database.HealthOptions) Checker {
+	return checkerFunc(func(ctx context.Context) Result {
+		dbResult := database.Check(ctx, db, opts)
+
+		result := Result{Details: dbResult.ToMap()}
+		switch dbResult.Status {
+		case database.HealthStatusHealthy:
+			result.Status = StatusHealthy
+		case database.HealthStatusDegraded:
+			result.Status = StatusDegraded
+		default:
+			result.Status = StatusUnhealthy
+		}
+		if len(dbResult.Problems) > 0 && result.Status != StatusHealthy {
+			result.Error = dbResult.Problems[0]
+		}
+		return result
+	})
+}
+
+// HTTPChecker checks that a GET to url answers with a 2xx status.
+// client defaults to http.DefaultClient; the registration timeout bounds the request.
+func HTTPChecker(url string, client *http.Client) Checker {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return CheckerFunc(func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, url)
+		}
+		return nil
+	})
+}
+
+// JWKSChecker checks that the Auth0 JWKS endpoint used for token validation is reachable
+func JWKSChecker(cfg *config.Auth0Config, client *http.Client) Checker {
+	return HTTPChecker(fmt.Sprintf("https://%s/.well-known/jwks.json", cfg.Domain), client)
+}
+
+// checkerFunc adapts a function returning a full Result to a Checker
+type checkerFunc func(ctx context.Context) Result
+
+// Check implements Checker
+func (f checkerFunc) Check(ctx context.Context) Result {
+	return f(ctx)
+}
@@ -0,0 +1,97 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/medbai2/common-go/database"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+func TestHTTPChecker(t *testing.T) {
+	testCases := []struct {
+		Name           string
+		StatusCode     int
+		ExpectedStatus Status
+	}{
+		{Name: "OK", StatusCode: http.StatusOK, ExpectedStatus: StatusHealthy},
+		{Name: "No Content", StatusCode: http.StatusNoContent, ExpectedStatus: StatusHealthy},
+		{Name: "Server Error", StatusCode: http.StatusBadGateway, ExpectedStatus: StatusUnhealthy},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.StatusCode)
+			}))
+			defer server.Close()
+
+			result := HTTPChecker(server.URL, nil).Check(context.Background())
+			assert.Equal(t, tc.ExpectedStatus, result.Status)
+		})
+	}
+}
+
+func TestHTTPChecker_Unreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	result := HTTPChecker(url, nil).Check(context.Background())
+	assert.Equal(t, StatusUnhealthy, result.Status)
+	assert.NotEmpty(t, result.Error)
+}
+
+func TestDatabaseChecker(t *testing.T) {
+	testCases := []struct {
+		Name           string
+		Setup          func(mock sqlmock.Sqlmock)
+		ExpectedStatus Status
+	}{
+		{
+			Name: "Healthy",
+			Setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectPing()
+				mock.ExpectQuery(`server_version`).
+					WillReturnRows(sqlmock.NewRows([]string{"server_version", "pg_is_in_recovery"}).AddRow("16.2", false))
+			},
+			ExpectedStatus: StatusHealthy,
+		},
+		{
+			Name: "Unreachable",
+			Setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectPing().WillReturnError(context.DeadlineExceeded)
+			},
+			ExpectedStatus: StatusUnhealthy,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+			require.NoError(t, err)
+			defer mockDB.Close()
+
+			db, err := gorm.Open(postgres.New(postgres.Config{Conn: mockDB}), &gorm.Config{
+				Logger:               gormlogger.Discard,
+				DisableAutomaticPing: true,
+			})
+			require.NoError(t, err)
+			tc.Setup(mock)
+
+			result := DatabaseChecker(db, &database.HealthOptions{}).Check(context.Background())
+
+			assert.Equal(t, tc.ExpectedStatus, result.Status)
+			assert.Equal(t, string(tc.ExpectedStatus), result.Details["status"])
+			require.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package health
+
+import (
+	"github.com/medbai2/common-go/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LivezHandler reports whether the process is alive. Only checks registered with Liveness run;
+// with none registered the endpoint always succeeds.
+func (r *Registry) LivezHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report := r.RunLiveness(c.Request.Context())
+		status := "ok"
+		if report.Status == StatusUnhealthy {
+			status = string(StatusUnhealthy)
+		}
+		response.Health(c, status, report.ChecksMap())
+	}
+}
+
+// ReadyzHandler reports whether the service can take traffic. Only a failing critical check
+// fails readiness; degraded checks are reported but keep the endpoint at 200.
+func (r *Registry) ReadyzHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report := r.Run(c.Request.Context())
+		status := "ok"
+		if report.Status == StatusUnhealthy {
+			status = string(StatusUnhealthy)
+		}
+		response.Health(c, status, report.ChecksMap())
+	}
+}
+
+// HealthzHandler reports the aggregated status of every check (healthy, degraded or unhealthy)
+func (r *Registry) HealthzHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report := r.Run(c.Request.Context())
+		response.Health(c, string(report.Status), report.ChecksMap())
+	}
+}
+
+// RegisterRoutes registers /livez, /readyz and /healthz on the router.
+//
+// Usage:
+//
+//	registry, _ := health.NewRegistry(health.Config{Registerer: prometheus.DefaultRegisterer})
+//	registry.Register(health.Registration{Name: "database", Checker: health.DatabaseChecker(db, nil), Critical: true})
+//	registry.RegisterRoutes(router)
+func (r *Registry) RegisterRoutes(router gin.IRouter) {
+	router.GET("/livez", r.LivezHandler())
+	router.GET("/readyz", r.ReadyzHandler())
+	router.GET("/healthz", r.HealthzHandler())
+}
@@ -0,0 +1,91 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/medbai2/common-go/response"
+	"github.com/medbai2/common-go/testutils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func TestHandlers(t *testing.T) {
+	testCases := []struct {
+		Name           string
+		Registrations  []Registration
+		Path           string
+		ExpectedStatus int
+		ExpectedHealth string
+	}{
+		{
+			Name:           "Livez Without Checks",
+			Registrations:  []Registration{{Name: "database", Checker: staticChecker(StatusUnhealthy), Critical: true}},
+			Path:           "/livez",
+			ExpectedStatus: http.StatusOK,
+			ExpectedHealth: "ok",
+		},
+		{
+			Name:           "Readyz Healthy",
+			Registrations:  []Registration{{Name: "database", Checker: staticChecker(StatusHealthy), Critical: true}},
+			Path:           "/readyz",
+			ExpectedStatus: http.StatusOK,
+			ExpectedHealth: "ok",
+		},
+		{
+			Name: "Readyz Degraded Stays Ready",
+			Registrations: []Registration{
+				{Name: "database", Checker: staticChecker(StatusHealthy), Critical: true},
+				{Name: "jwks", Checker: staticChecker(StatusUnhealthy)},
+			},
+			Path:           "/readyz",
+			ExpectedStatus: http.StatusOK,
+			ExpectedHealth: "ok",
+		},
+		{
+			Name:           "Readyz Critical Failure",
+			Registrations:  []Registration{{Name: "database", Checker: staticChecker(StatusUnhealthy), Critical: true}},
+			Path:           "/readyz",
+			ExpectedStatus: http.StatusServiceUnavailable,
+			ExpectedHealth: "unhealthy",
+		},
+		{
+			Name: "Healthz Degraded",
+			Registrations: []Registration{
+				{Name: "database", Checker: staticChecker(StatusHealthy), Critical: true},
+				{Name: "jwks", Checker: staticChecker(StatusUnhealthy)},
+			},
+			Path:           "/healthz",
+			ExpectedStatus: http.StatusServiceUnavailable,
+			ExpectedHealth: "degraded",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			registry := newTestRegistry(t)
+			for _, reg := range tc.Registrations {
+				require.NoError(t, registry.Register(reg))
+			}
+
+			hts := testutils.NewHTTPTestSuite(t)
+			registry.RegisterRoutes(hts.Router)
+
+			hts.ExecuteRequest(hts.SetupRequest(http.MethodGet, tc.Path))
+			hts.AssertResponseStatus(tc.ExpectedStatus)
+
+			var body response.APIResponse
+			require.NoError(t, json.Unmarshal(hts.Recorder.Body.Bytes(), &body))
+			data, ok := body.Data.(map[string]interface{})
+			require.True(t, ok)
+			assert.Equal(t, tc.ExpectedHealth, data["status"])
+		})
+	}
+}
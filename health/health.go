@@ -0,0 +1,305 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/medbai2/common-go/errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Status is the state of a single check or of the aggregated report
+type Status string
+
+const (
+	StatusHealthy   Status = "healthy"
+	StatusDegraded  Status = "degraded"
+	StatusUnhealthy Status = "unhealthy"
+)
+
+// Default check settings
+const (
+	DefaultCheckTimeout = 2 * time.Second
+)
+
+// Result is the outcome of a single check
+type Result struct {
+	Status    Status                 `json:"status"`
+	Error     string                 `json:"error,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+	Duration  time.Duration          `json:"duration"`
+	CheckedAt time.Time              `json:"checkedAt"`
+	Critical  bool                   `json:"critical"`
+	Cached    bool                   `json:"cached,omitempty"`
+}
+
+// toMap converts the result into an entry for the checks map of response.Health
+func (r Result) toMap() map[string]interface{} {
+	check := map[string]interface{}{
+		"status":     string(r.Status),
+		"durationMs": float64(r.Duration.Microseconds()) / 1000,
+		"checkedAt":  r.CheckedAt.UTC().Format(time.RFC3339),
+		"critical":   r.Critical,
+	}
+	if r.Error != "" {
+		check["error"] = r.Error
+	}
+	if len(r.Details) > 0 {
+		check["details"] = r.Details
+	}
+	if r.Cached {
+		check["cached"] = true
+	}
+	return check
+}
+
+// Checker checks a single component. Implementations should honour ctx cancellation.
+type Checker interface {
+	Check(ctx context.Context) Result
+}
+
+// CheckerFunc adapts a function returning an error to a Checker: nil is healthy,
+// anything else unhealthy.
+type CheckerFunc func(ctx context.Context) error
+
+// Check implements Checker
+func (f CheckerFunc) Check(ctx context.Context) Result {
+	if err := f(ctx); err != nil {
+		return Result{Status: StatusUnhealthy, Error: err.Error()}
+	}
+	return Result{Status: StatusHealthy}
+}
+
+// Registration describes a named check
+type Registration struct {
+	Name     string
+	Checker  Checker
+	Timeout  time.Duration // Per-check timeout (default: 2s)
+	Critical bool          // A failing critical check makes the service unhealthy; others only degrade it
+	CacheTTL time.Duration // Reuse the last result for this long (0 runs the check on every request)
+	Liveness bool          // Also run the check for /livez (keep these cheap and local)
+}
+
+// Report is the aggregated outcome of a set of checks
+type Report struct {
+	Status Status            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// ChecksMap converts the report into the checks map expected by response.Health
+func (r Report) ChecksMap() map[string]interface{} {
+	checks := make(map[string]interface{}, len(r.Checks))
+	for name, result := range r.Checks {
+		checks[name] = result.toMap()
+	}
+	return checks
+}
+
+// Config configures a Registry
+type Config struct {
+	Registerer prometheus.Registerer // Where check gauges are registered (nil disables metrics)
+}
+
+// entry is a registered check with its cached result
+type entry struct {
+	Registration
+
+	mu     sync.Mutex
+	last   Result
+	hasRun bool
+}
+
+// Registry holds named checks and runs them in parallel
+type Registry struct {
+	mu      sync.RWMutex
+	entries map[string]*entry
+
+	statusGauge   *prometheus.GaugeVec
+	durationGauge *prometheus.GaugeVec
+}
+
+// NewRegistry creates an empty registry. With cfg.Registerer set, the state and duration of
+// every check are exported as the health_check_status and health_check_duration_seconds gauges.
+func NewRegistry(cfg Config) (*Registry, error) {
+	r := &Registry{entries: make(map[string]*entry)}
+	if cfg.Registerer == nil {
+		return r, nil
+	}
+
+	r.statusGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "health_check_status",
+		Help: "Result of the last health check: 1 healthy, 0.5 degraded, 0 unhealthy.",
+	}, []string{"check"})
+	r.durationGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "health_check_duration_seconds",
+		Help: "Duration of the last health check.",
+	}, []string{"check"})
+	if err := cfg.Registerer.Register(r.statusGauge); err != nil {
+		return nil, errors.Wrap(err, errors.ErrCodeInternal, "failed to register health check metrics")
+	}
+	if err := cfg.Registerer.Register(r.durationGauge); err != nil {
+		return nil, errors.Wrap(err, errors.ErrCodeInternal, "failed to register health check metrics")
+	}
+	return r, nil
+}
+
+// Register adds a named check. Names must be unique.
+func (r *Registry) Register(reg Registration) error {
+	if reg.Name == "" {
+		return errors.NewMissingField("name")
+	}
+	if reg.Checker == nil {
+		return errors.NewMissingField("checker")
+	}
+	if reg.Timeout <= 0 {
+		reg.Timeout = DefaultCheckTimeout
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.entries[reg.Name]; exists {
+		return errors.NewDuplicateEntry(fmt.Sprintf("health check %q", reg.Name))
+	}
+	r.entries[reg.Name] = &entry{Registration: reg}
+	return nil
+}
+
+// Names returns the registered check names in sorted order
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.entries))
+	for name := range r.entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Run executes every registered check in parallel and aggregates the results
+func (r *Registry) Run(ctx context.Context) Report {
+	return r.run(ctx, func(*entry) bool { return true })
+}
+
+// RunLiveness executes only the checks registered with Liveness
+func (r *Registry) RunLiveness(ctx context.Context) Report {
+	return r.run(ctx, func(e *entry) bool { return e.Liveness })
+}
+
+// run executes the selected checks in parallel
+func (r *Registry) run(ctx context.Context, include func(*entry) bool) Report {
+	r.mu.RLock()
+	selected := make([]*entry, 0, len(r.entries))
+	for _, e := range r.entries {
+		if include(e) {
+			selected = append(selected, e)
+		}
+	}
+	r.mu.RUnlock()
+
+	results := make([]Result, len(selected))
+	var wg sync.WaitGroup
+	for i, e := range selected {
+		wg.Add(1)
+		go func(i int, e *entry) {
+			defer wg.Done()
+			results[i] = r.runEntry(ctx, e)
+		}(i, e)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusHealthy, Checks: make(map[string]Result, len(selected))}
+	for i, e := range selected {
+		report.Checks[e.Name] = results[i]
+		report.Status = worse(report.Status, effectiveStatus(results[i]))
+	}
+	return report
+}
+
+// runEntry returns the cached result when fresh, otherwise runs the check under its timeout
+func (r *Registry) runEntry(ctx context.Context, e *entry) Result {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.CacheTTL > 0 && e.hasRun && time.Since(e.last.CheckedAt) < e.CacheTTL {
+		cached := e.last
+		cached.Cached = true
+		return cached
+	}
+
+	result := runWithTimeout(ctx, e.Checker, e.Timeout)
+	result.Critical = e.Critical
+	e.last = result
+	e.hasRun = true
+
+	r.observe(e.Name, result)
+	return result
+}
+
+// runWithTimeout runs a checker and gives up once the timeout expires, even if the
+// checker ignores its context
+func runWithTimeout(ctx context.Context, checker Checker, timeout time.Duration) Result {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan Result, 1)
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				done <- Result{Status: StatusUnhealthy, Error: fmt.Sprintf("check panicked: %v", recovered)}
+			}
+		}()
+		done <- checker.Check(ctx)
+	}()
+
+	var result Result
+	select {
+	case result = <-done:
+	case <-ctx.Done():
+		result = Result{Status: StatusUnhealthy, Error: fmt.Sprintf("check timed out after %s", timeout)}
+	}
+	if result.Status == "" {
+		result.Status = StatusHealthy
+	}
+	result.Duration = time.Since(start)
+	result.CheckedAt = time.Now()
+	return result
+}
+
+// observe exports a check result as gauges
+func (r *Registry) observe(name string, result Result) {
+	if r.statusGauge == nil {
+		return
+	}
+	value := 0.0
+	switch result.Status {
+	case StatusHealthy:
+		value = 1
+	case StatusDegraded:
+		value = 0.5
+	}
+	r.statusGauge.WithLabelValues(name).Set(value)
+	r.durationGauge.WithLabelValues(name).Set(result.Duration.Seconds())
+}
+
+// effectiveStatus downgrades a failing non-critical check to degraded
+func effectiveStatus(result Result) Status {
+	if result.Status == StatusUnhealthy && !result.Critical {
+		return StatusDegraded
+	}
+	return result.Status
+}
+
+// worse returns the more severe of two statuses
+func worse(a, b Status) Status {
+	severity := map[Status]int{StatusHealthy: 0, StatusDegraded: 1, StatusUnhealthy: 2}
+	if severity[b] > severity[a] {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,264 @@
+package health
+
+import (
+	"context"
+	stderrors "errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/medbai2/common-go/errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// staticChecker always returns the same status
+func staticChecker(status Status) Checker {
+	return checkerFunc(func(ctx context.Context) Result {
+		return Result{Status: status}
+	})
+}
+
+func newTestRegistry(t *testing.T) *Registry {
+	registry, err := NewRegistry(Config{})
+	require.NoError(t, err)
+	return registry
+}
+
+func TestRegistry_Register(t *testing.T) {
+	registry := newTestRegistry(t)
+
+	require.NoError(t, registry.Register(Registration{Name: "database", Checker: staticChecker(StatusHealthy)}))
+
+	testCases := []struct {
+		Name         string
+		Registration Registration
+		ExpectedCode errors.ErrorCode
+	}{
+		{
+			Name:         "Duplicate Name",
+			Registration: Registration{Name: "database", Checker: staticChecker(StatusHealthy)},
+			ExpectedCode: errors.ErrCodeDuplicateEntry,
+		},
+		{
+			Name:         "Missing Name",
+			Registration: Registration{Checker: staticChecker(StatusHealthy)},
+			ExpectedCode: errors.ErrCodeMissingField,
+		},
+		{
+			Name:         "Missing Checker",
+			Registration: Registration{Name: "cache"},
+			ExpectedCode: errors.ErrCodeMissingField,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := registry.Register(tc.Registration)
+			appErr := errors.GetAppError(err)
+			require.NotNil(t, appErr)
+			assert.Equal(t, tc.ExpectedCode, appErr.Code)
+		})
+	}
+
+	assert.Equal(t, []string{"database"}, registry.Names())
+}
+
+func TestRegistry_Aggregation(t *testing.T) {
+	testCases := []struct {
+		Name           string
+		Registrations  []Registration
+		ExpectedStatus Status
+	}{
+		{
+			Name:           "No Checks",
+			ExpectedStatus: StatusHealthy,
+		},
+		{
+			Name: "All Healthy",
+			Registrations: []Registration{
+				{Name: "a", Checker: staticChecker(StatusHealthy), Critical: true},
+				{Name: "b", Checker: staticChecker(StatusHealthy)},
+			},
+			ExpectedStatus: StatusHealthy,
+		},
+		{
+			Name: "Non-Critical Failure Degrades",
+			Registrations: []Registration{
+				{Name: "a", Checker: staticChecker(StatusHealthy), Critical: true},
+				{Name: "b", Checker: staticChecker(StatusUnhealthy)},
+			},
+			ExpectedStatus: StatusDegraded,
+		},
+		{
+			Name: "Critical Degraded Degrades",
+			Registrations: []Registration{
+				{Name: "a", Checker: staticChecker(StatusDegraded), Critical: true},
+			},
+			ExpectedStatus: StatusDegraded,
+		},
+		{
+			Name: "Critical Failure Is Unhealthy",
+			Registrations: []Registration{
+				{Name: "a", Checker: staticChecker(StatusUnhealthy), Critical: true},
+				{Name: "b", Checker: staticChecker(StatusDegraded)},
+			},
+			ExpectedStatus: StatusUnhealthy,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			registry := newTestRegistry(t)
+			for _, reg := range tc.Registrations {
+				require.NoError(t, registry.Register(reg))
+			}
+
+			report := registry.Run(context.Background())
+
+			assert.Equal(t, tc.ExpectedStatus, report.Status)
+			assert.Len(t, report.Checks, len(tc.Registrations))
+		})
+	}
+}
+
+func TestRegistry_Timeout(t *testing.T) {
+	registry := newTestRegistry(t)
+
+	// The checker ignores its context; the registry still gives up after the timeout
+	require.NoError(t, registry.Register(Registration{
+		Name:     "slow",
+		Critical: true,
+		Timeout:  20 * time.Millisecond,
+		Checker: CheckerFunc(func(ctx context.Context) error {
+			time.Sleep(time.Second)
+			return nil
+		}),
+	}))
+
+	start := time.Now()
+	report := registry.Run(context.Background())
+
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, StatusUnhealthy, report.Status)
+	assert.Contains(t, report.Checks["slow"].Error, "timed out")
+}
+
+func TestRegistry_RunsInParallel(t *testing.T) {
+	registry := newTestRegistry(t)
+
+	for _, name := range []string{"a", "b", "c", "d"} {
+		require.NoError(t, registry.Register(Registration{
+			Name: name,
+			Checker: CheckerFunc(func(ctx context.Context) error {
+				time.Sleep(50 * time.Millisecond)
+				return nil
+			}),
+		}))
+	}
+
+	start := time.Now()
+	report := registry.Run(context.Background())
+
+	assert.Equal(t, StatusHealthy, report.Status)
+	assert.Less(t, time.Since(start), 150*time.Millisecond)
+}
+
+func TestRegistry_CacheTTL(t *testing.T) {
+	registry := newTestRegistry(t)
+
+	var calls atomic.Int32
+	require.NoError(t, registry.Register(Registration{
+		Name:     "cached",
+		CacheTTL: time.Hour,
+		Checker: CheckerFunc(func(ctx context.Context) error {
+			calls.Add(1)
+			return nil
+		}),
+	}))
+
+	first := registry.Run(context.Background())
+	second := registry.Run(context.Background())
+
+	assert.Equal(t, int32(1), calls.Load())
+	assert.False(t, first.Checks["cached"].Cached)
+	assert.True(t, second.Checks["cached"].Cached)
+}
+
+func TestRegistry_PanicIsUnhealthy(t *testing.T) {
+	registry := newTestRegistry(t)
+
+	require.NoError(t, registry.Register(Registration{
+		Name:     "panics",
+		Critical: true,
+		Checker: CheckerFunc(func(ctx context.Context) error {
+			panic("boom")
+		}),
+	}))
+
+	report := registry.Run(context.Background())
+
+	assert.Equal(t, StatusUnhealthy, report.Status)
+	assert.Contains(t, report.Checks["panics"].Error, "boom")
+}
+
+func TestRegistry_RunLiveness(t *testing.T) {
+	registry := newTestRegistry(t)
+
+	require.NoError(t, registry.Register(Registration{Name: "database", Checker: staticChecker(StatusUnhealthy), Critical: true}))
+	require.NoError(t, registry.Register(Registration{Name: "goroutines", Checker: staticChecker(StatusHealthy), Liveness: true}))
+
+	report := registry.RunLiveness(context.Background())
+
+	assert.Equal(t, StatusHealthy, report.Status)
+	assert.Contains(t, report.Checks, "goroutines")
+	assert.NotContains(t, report.Checks, "database")
+}
+
+func TestRegistry_Metrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	registry, err := NewRegistry(Config{Registerer: reg})
+	require.NoError(t, err)
+
+	require.NoError(t, registry.Register(Registration{Name: "database", Checker: staticChecker(StatusHealthy)}))
+	require.NoError(t, registry.Register(Registration{Name: "cache", Checker: staticChecker(StatusDegraded)}))
+	require.NoError(t, registry.Register(Registration{Name: "jwks", Checker: CheckerFunc(func(ctx context.Context) error {
+		return stderrors.New("unreachable")
+	})}))
+
+	registry.Run(context.Background())
+
+	expected := `
+# HELP health_check_status Result of the last health check: 1 healthy, 0.5 degraded, 0 unhealthy.
+# TYPE health_check_status gauge
+health_check_status{check="cache"} 0.5
+health_check_status{check="database"} 1
+health_check_status{check="jwks"} 0
+`
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "health_check_status"))
+
+	count, err := testutil.GatherAndCount(reg, "health_check_duration_seconds")
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+}
+
+func TestReport_ChecksMap(t *testing.T) {
+	report := Report{
+		Status: StatusDegraded,
+		Checks: map[string]Result{
+			"jwks": {Status: StatusUnhealthy, Error: "unreachable", Duration: 2 * time.Millisecond, CheckedAt: time.Now()},
+		},
+	}
+
+	checks := report.ChecksMap()
+
+	jwks, ok := checks["jwks"].(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, "unhealthy", jwks["status"])
+	assert.Equal(t, "unreachable", jwks["error"])
+	assert.Equal(t, 2.0, jwks["durationMs"])
+}
@@ -0,0 +1,27 @@
+// Package backoff computes retry delays shared by the database, jobs and outbox packages
+package backoff
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Exponential returns the delay before retry number attempt (0-based), doubling from
+// initial up to max
+func Exponential(attempt int, initial, max time.Duration) time.Duration {
+	delay := initial
+	for i := 0; i < attempt && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
+
+// Jitter returns Exponential(attempt, initial, max) randomised within the upper half of
+// the interval, so clients retrying together spread out
+func Jitter(attempt int, initial, max time.Duration) time.Duration {
+	half := Exponential(attempt, initial, max) / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
@@ -0,0 +1,36 @@
+package backoff
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExponential(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		Attempt       int
+		ExpectedDelay time.Duration
+	}{
+		{Name: "first retry", Attempt: 0, ExpectedDelay: 10 * time.Millisecond},
+		{Name: "doubles", Attempt: 2, ExpectedDelay: 40 * time.Millisecond},
+		{Name: "capped", Attempt: 10, ExpectedDelay: 50 * time.Millisecond},
+		{Name: "negative attempt", Attempt: -1, ExpectedDelay: 10 * time.Millisecond},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert.Equal(t, tc.ExpectedDelay, Exponential(tc.Attempt, 10*time.Millisecond, 50*time.Millisecond))
+		})
+	}
+}
+
+func TestJitter(t *testing.T) {
+	for attempt := 0; attempt < 10; attempt++ {
+		ceiling := Exponential(attempt, 10*time.Millisecond, 50*time.Millisecond)
+		delay := Jitter(attempt, 10*time.Millisecond, 50*time.Millisecond)
+		assert.GreaterOrEqual(t, delay, ceiling/2)
+		assert.LessOrEqual(t, delay, ceiling)
+	}
+}
@@ -0,0 +1,25 @@
+// Package metricsutil holds Prometheus helpers shared by the packages exposing metrics
+package metricsutil
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Register registers c on reg, returning the existing collector when an identical one
+// is already registered (e.g. by a second database handle or worker)
+func Register[C prometheus.Collector](reg prometheus.Registerer, c C) (C, error) {
+	if err := reg.Register(c); err != nil {
+		var already prometheus.AlreadyRegisteredError
+		if !errors.As(err, &already) {
+			return c, err
+		}
+		existing, ok := already.ExistingCollector.(C)
+		if !ok {
+			return c, err
+		}
+		return existing, nil
+	}
+	return c, nil
+}
@@ -0,0 +1,26 @@
+package metricsutil
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegister(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	opts := prometheus.CounterOpts{Name: "test_total", Help: "Test counter."}
+
+	first, err := Register(reg, prometheus.NewCounter(opts))
+	require.NoError(t, err)
+
+	// An identical collector resolves to the one already registered
+	second, err := Register(reg, prometheus.NewCounter(opts))
+	require.NoError(t, err)
+	assert.Same(t, first, second)
+
+	// A conflicting collector is reported
+	_, err = Register(reg, prometheus.NewGauge(prometheus.GaugeOpts{Name: "test_total", Help: "Other help."}))
+	assert.Error(t, err)
+}
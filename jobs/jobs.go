@@ -0,0 +1,212 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"time"
+
+	"github.com/medbai2/common-go/database"
+	"github.com/medbai2/common-go/errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Job statuses stored in jobs.status
+const (
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusDead      = "dead"
+)
+
+// TableName is the job table created by migrations/jobs
+const TableName = "jobs"
+
+// Enqueue defaults
+const (
+	DefaultQueue       = "default"
+	DefaultMaxAttempts = 25
+)
+
+// EnqueueOptions configures a job. All fields are optional.
+type EnqueueOptions struct {
+	Queue       string        // default: DefaultQueue
+	RunAt       time.Time     // Earliest time the job runs (default: now)
+	Delay       time.Duration // Added to RunAt (or now)
+	UniqueKey   string        // Rejects the job while another pending or running job has the same key
+	MaxAttempts int           // Attempts before the job is dead-lettered (default: DefaultMaxAttempts)
+}
+
+// Job is a stored job handed to a handler
+type Job struct {
+	ID          int64
+	Queue       string
+	Kind        string
+	Payload     json.RawMessage
+	UniqueKey   string
+	Attempt     int // Current attempt, starting at 1
+	MaxAttempts int
+	RunAt       time.Time
+	CreatedAt   time.Time
+}
+
+// record is the GORM model for jobs
+type record struct {
+	ID          int64   `gorm:"primaryKey"`
+	Queue       string  `gorm:"not null"`
+	Kind        string  `gorm:"not null"`
+	Payload     []byte  `gorm:"type:jsonb;not null"`
+	UniqueKey   *string `gorm:""`
+	Status      string  `gorm:"not null"`
+	Attempts    int     `gorm:"not null"`
+	MaxAttempts int     `gorm:"not null"`
+	LastError   *string
+	RunAt       time.Time `gorm:"not null"`
+	LockedBy    *string
+	LockedAt    *time.Time
+	CreatedAt   time.Time `gorm:"not null"`
+	UpdatedAt   time.Time `gorm:"not null"`
+	FinishedAt  *time.Time
+}
+
+// TableName implements gorm's Tabler
+func (record) TableName() string {
+	return TableName
+}
+
+// job converts a stored record into a Job
+func (r record) job() *Job {
+	job := &Job{
+		ID:          r.ID,
+		Queue:       r.Queue,
+		Kind:        r.Kind,
+		Payload:     json.RawMessage(r.Payload),
+		Attempt:     r.Attempts,
+		MaxAttempts: r.MaxAttempts,
+		RunAt:       r.RunAt,
+		CreatedAt:   r.CreatedAt,
+	}
+	if r.UniqueKey != nil {
+		job.UniqueKey = *r.UniqueKey
+	}
+	return job
+}
+
+// Enqueue stores a job of the given kind with args marshalled to JSON. It runs on the
+// transaction carried by ctx when there is one (see database.WithTx), so a job can be
+// enqueued atomically with the change that requires it.
+//
+// When opts.UniqueKey is set and a pending or running job already has that key, Enqueue
+// returns ErrCodeDuplicateEntry and stores nothing.
+//
+// Usage:
+//
+//	job, err := jobs.Enqueue(ctx, db, "email.send", SendEmail{To: user.Email}, &jobs.EnqueueOptions{
+//		Delay:     time.Minute,
+//		UniqueKey: "welcome:" + user.ID,
+//	})
+func Enqueue(ctx context.Context, db *gorm.DB, kind string, args interface{}, opts *EnqueueOptions) (*Job, error) {
+	if db == nil {
+		return nil, errors.New(errors.ErrCodeDatabaseError, "database is nil")
+	}
+	if kind == "" {
+		return nil, errors.NewMissingField("kind")
+	}
+	if opts == nil {
+		opts = &EnqueueOptions{}
+	}
+
+	payload, err := json.Marshal(args)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrCodeInvalidInput, "failed to encode job arguments")
+	}
+
+	now := time.Now().UTC()
+	rec := &record{
+		Queue:       opts.Queue,
+		Kind:        kind,
+		Payload:     payload,
+		Status:      StatusPending,
+		MaxAttempts: opts.MaxAttempts,
+		RunAt:       opts.RunAt,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if rec.Queue == "" {
+		rec.Queue = DefaultQueue
+	}
+	if rec.MaxAttempts <= 0 {
+		rec.MaxAttempts = DefaultMaxAttempts
+	}
+	if rec.RunAt.IsZero() {
+		rec.RunAt = now
+	}
+	rec.RunAt = rec.RunAt.Add(opts.Delay).UTC()
+
+	tx := database.Conn(ctx, db)
+	if opts.UniqueKey != "" {
+		rec.UniqueKey = &opts.UniqueKey
+		tx = tx.Clauses(clause.OnConflict{
+			Columns:     []clause.Column{{Name: "unique_key"}},
+			TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "unique_key IS NOT NULL AND status IN ('pending', 'running')"}}},
+			DoNothing:   true,
+		})
+	}
+
+	result := tx.Create(rec)
+	if result.Error != nil {
+		return nil, database.TranslateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, errors.NewDuplicateEntry("job")
+	}
+	return rec.job(), nil
+}
+
+// Retry moves a dead job back to pending with a fresh set of attempts
+func Retry(ctx context.Context, db *gorm.DB, id int64) error {
+	if db == nil {
+		return errors.New(errors.ErrCodeDatabaseError, "database is nil")
+	}
+	result := database.Conn(ctx, db).Model(&record{}).
+		Where("id = ? AND status = ?", id, StatusDead).
+		Updates(map[string]interface{}{
+			"status":      StatusPending,
+			"attempts":    0,
+			"run_at":      gorm.Expr("NOW()"),
+			"finished_at": nil,
+			"updated_at":  gorm.Expr("NOW()"),
+		})
+	if result.Error != nil {
+		return database.TranslateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.NewNotFound("dead job")
+	}
+	return nil
+}
+
+// permanentError marks a handler error that must not be retried
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so the job is dead-lettered immediately instead of retried, for
+// failures retrying cannot fix (e.g. invalid arguments)
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// isPermanent reports whether err was wrapped with Permanent
+func isPermanent(err error) bool {
+	var permanent *permanentError
+	return stderrors.As(err, &permanent)
+}
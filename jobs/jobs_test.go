@@ -0,0 +1,168 @@
+package jobs
+
+import (
+	"context"
+	stderrors "errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/medbai2/common-go/database"
+	"github.com/medbai2/common-go/errors"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// newTestDB returns a GORM handle backed by sqlmock
+func newTestDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { mockDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: mockDB}), &gorm.Config{
+		Logger:                 gormlogger.Discard,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+	})
+	require.NoError(t, err)
+	return db, mock
+}
+
+type sendEmail struct {
+	To string `json:"to"`
+}
+
+const insertJob = `INSERT INTO "jobs" ("queue","kind","payload","unique_key","status","attempts","max_attempts","last_error","run_at","locked_by","locked_at","created_at","updated_at","finished_at") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14)`
+
+func TestEnqueue(t *testing.T) {
+	db, mock := newTestDB(t)
+
+	mock.ExpectQuery(regexp.QuoteMeta(insertJob+` RETURNING "id"`)).
+		WithArgs(DefaultQueue, "email.send", []byte(`{"to":"a@b.test"}`), nil, StatusPending, 0, DefaultMaxAttempts, nil, sqlmock.AnyArg(), nil, nil, sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9))
+
+	job, err := Enqueue(context.Background(), db, "email.send", sendEmail{To: "a@b.test"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(9), job.ID)
+	assert.Equal(t, DefaultQueue, job.Queue)
+	assert.WithinDuration(t, time.Now(), job.RunAt, time.Second)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEnqueue_Options(t *testing.T) {
+	db, mock := newTestDB(t)
+	runAt := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(regexp.QuoteMeta(insertJob+` ON CONFLICT ("unique_key") WHERE unique_key IS NOT NULL AND status IN ('pending', 'running') DO NOTHING RETURNING "id"`)).
+		WithArgs("mail", "email.send", sqlmock.AnyArg(), "welcome:1", StatusPending, 0, 3, nil, runAt.Add(time.Minute), nil, nil, sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(10))
+
+	job, err := Enqueue(context.Background(), db, "email.send", sendEmail{To: "a@b.test"}, &EnqueueOptions{
+		Queue:       "mail",
+		RunAt:       runAt,
+		Delay:       time.Minute,
+		UniqueKey:   "welcome:1",
+		MaxAttempts: 3,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "welcome:1", job.UniqueKey)
+	assert.Equal(t, runAt.Add(time.Minute), job.RunAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEnqueue_DuplicateUniqueKey(t *testing.T) {
+	db, mock := newTestDB(t)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`ON CONFLICT ("unique_key")`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	_, err := Enqueue(context.Background(), db, "email.send", nil, &EnqueueOptions{UniqueKey: "welcome:1"})
+	require.Error(t, err)
+	assert.Equal(t, errors.ErrCodeDuplicateEntry, errors.GetAppError(err).Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEnqueue_InTransaction(t *testing.T) {
+	db, mock := newTestDB(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "jobs"`)).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	err := database.WithTx(context.Background(), db, nil, func(ctx context.Context, tx *gorm.DB) error {
+		// The transaction is picked up from ctx
+		_, err := Enqueue(ctx, db, "email.send", sendEmail{}, nil)
+		return err
+	})
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEnqueue_Invalid(t *testing.T) {
+	db, _ := newTestDB(t)
+
+	testCases := []struct {
+		Name         string
+		DB           *gorm.DB
+		Kind         string
+		Args         interface{}
+		ExpectedCode errors.ErrorCode
+	}{
+		{Name: "nil database", DB: nil, Kind: "email.send", ExpectedCode: errors.ErrCodeDatabaseError},
+		{Name: "missing kind", DB: db, Kind: "", ExpectedCode: errors.ErrCodeMissingField},
+		{Name: "unencodable arguments", DB: db, Kind: "email.send", Args: make(chan int), ExpectedCode: errors.ErrCodeInvalidInput},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			_, err := Enqueue(context.Background(), tc.DB, tc.Kind, tc.Args, nil)
+			require.Error(t, err)
+			assert.Equal(t, tc.ExpectedCode, errors.GetAppError(err).Code)
+		})
+	}
+}
+
+func TestRetry(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		RowsAffected  int64
+		ExpectedError bool
+	}{
+		{Name: "dead job requeued", RowsAffected: 1},
+		{Name: "no dead job", RowsAffected: 0, ExpectedError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			db, mock := newTestDB(t)
+			mock.ExpectExec(regexp.QuoteMeta(`UPDATE "jobs" SET "attempts"=$1,"finished_at"=$2,"run_at"=NOW(),"status"=$3,"updated_at"=NOW() WHERE id = $4 AND status = $5`)).
+				WithArgs(0, nil, StatusPending, 5, StatusDead).
+				WillReturnResult(sqlmock.NewResult(0, tc.RowsAffected))
+
+			err := Retry(context.Background(), db, 5)
+			if tc.ExpectedError {
+				require.Error(t, err)
+				assert.Equal(t, errors.ErrCodeNotFound, errors.GetAppError(err).Code)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPermanent(t *testing.T) {
+	cause := stderrors.New("bad address")
+
+	assert.Nil(t, Permanent(nil))
+	assert.True(t, isPermanent(Permanent(cause)))
+	assert.True(t, isPermanent(errors.Wrap(Permanent(cause), errors.ErrCodeInternal, "send failed")))
+	assert.False(t, isPermanent(cause))
+	assert.ErrorIs(t, Permanent(cause), cause)
+	assert.Equal(t, "bad address", Permanent(cause).Error())
+}
@@ -0,0 +1,413 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/medbai2/common-go/database"
+	"github.com/medbai2/common-go/errors"
+	"github.com/medbai2/common-go/internal/backoff"
+	"github.com/medbai2/common-go/internal/metricsutil"
+	"github.com/medbai2/common-go/logger"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gorm.io/gorm"
+)
+
+// Default worker settings
+const (
+	DefaultConcurrency     = 10
+	DefaultPollInterval    = time.Second
+	DefaultInitialBackoff  = time.Second
+	DefaultMaxBackoff      = time.Hour
+	DefaultJobTimeout      = 15 * time.Minute
+	DefaultStaleAfter      = time.Hour
+	DefaultShutdownTimeout = 30 * time.Second
+)
+
+// Job outcomes used as the result label of jobs_processed_total
+const (
+	resultCompleted = "completed"
+	resultRetried   = "retried"
+	resultDead      = "dead"
+	resultReleased  = "released"
+)
+
+// maxErrorLength bounds the handler error stored in last_error
+const maxErrorLength = 1000
+
+// finishTimeout bounds the update recording a job outcome, which must succeed even
+// while the worker is shutting down
+const finishTimeout = 10 * time.Second
+
+// HandlerFunc processes a job. Returning an error retries the job with backoff, or
+// dead-letters it when wrapped with Permanent or when its attempts are exhausted.
+type HandlerFunc func(ctx context.Context, job *Job) error
+
+// WorkerConfig configures a Worker; New replaces zero fields with the Default* values
+type WorkerConfig struct {
+	ID              string                // Identifies the worker in jobs.locked_by (default: hostname-pid)
+	Queues          []string              // Queues to process (default: DefaultQueue)
+	Concurrency     int                   // Jobs run in parallel
+	PollInterval    time.Duration         // Wait between polls when no job is available
+	InitialBackoff  time.Duration         // Delay before the first retry of a failed job
+	MaxBackoff      time.Duration         // Upper bound for the delay between retries
+	JobTimeout      time.Duration         // Upper bound for a single handler run
+	StaleAfter      time.Duration         // Running jobs locked longer than this are assumed lost and rescued; keep above JobTimeout
+	ShutdownTimeout time.Duration         // How long Run waits for in-flight jobs after ctx is done
+	Logger          logger.Logger         // default: logger.NewFromEnv("jobs")
+	Registerer      prometheus.Registerer // Where worker metrics are registered (nil disables metrics)
+}
+
+// Worker claims jobs from the jobs table and runs their handlers. Any number of workers
+// can share the table: jobs are claimed with FOR UPDATE SKIP LOCKED.
+type Worker struct {
+	db       *gorm.DB
+	cfg      WorkerConfig
+	metrics  *workerMetrics
+	mu       sync.RWMutex
+	handlers map[string]HandlerFunc
+}
+
+// workerMetrics are the Prometheus collectors of a worker
+type workerMetrics struct {
+	processed *prometheus.CounterVec
+	duration  *prometheus.HistogramVec
+	inFlight  prometheus.Gauge
+}
+
+// NewWorker creates a worker processing jobs stored in db
+func NewWorker(db *gorm.DB, cfg WorkerConfig) (*Worker, error) {
+	if db == nil {
+		return nil, errors.New(errors.ErrCodeDatabaseError, "database is nil")
+	}
+	if cfg.ID == "" {
+		host, _ := os.Hostname()
+		cfg.ID = fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
+	if len(cfg.Queues) == 0 {
+		cfg.Queues = []string{DefaultQueue}
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = DefaultConcurrency
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = DefaultPollInterval
+	}
+	if cfg.InitialBackoff <= 0 {
+		cfg.InitialBackoff = DefaultInitialBackoff
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = DefaultMaxBackoff
+	}
+	if cfg.JobTimeout <= 0 {
+		cfg.JobTimeout = DefaultJobTimeout
+	}
+	if cfg.StaleAfter <= 0 {
+		cfg.StaleAfter = DefaultStaleAfter
+	}
+	if cfg.ShutdownTimeout <= 0 {
+		cfg.ShutdownTimeout = DefaultShutdownTimeout
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = logger.NewFromEnv("jobs")
+	}
+
+	w := &Worker{db: db, cfg: cfg, handlers: map[string]HandlerFunc{}}
+	if cfg.Registerer != nil {
+		metrics, err := newWorkerMetrics(cfg.Registerer)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.ErrCodeInternal, "failed to register job metrics")
+		}
+		w.metrics = metrics
+	}
+	return w, nil
+}
+
+// newWorkerMetrics creates the worker collectors, reusing ones already registered on reg
+func newWorkerMetrics(reg prometheus.Registerer) (*workerMetrics, error) {
+	processed, err := metricsutil.Register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "jobs_processed_total",
+		Help: "Job runs by kind and result (completed, retried, dead, released).",
+	}, []string{"kind", "result"}))
+	if err != nil {
+		return nil, err
+	}
+	duration, err := metricsutil.Register(reg, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "jobs_duration_seconds",
+		Help:    "Duration of job handler runs by kind.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"kind"}))
+	if err != nil {
+		return nil, err
+	}
+	inFlight, err := metricsutil.Register(reg, prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "jobs_in_flight",
+		Help: "Jobs currently running.",
+	}))
+	if err != nil {
+		return nil, err
+	}
+	return &workerMetrics{processed: processed, duration: duration, inFlight: inFlight}, nil
+}
+
+// Handle registers the handler for jobs of the given kind, replacing any previous one
+func (w *Worker) Handle(kind string, handler HandlerFunc) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.handlers[kind] = handler
+}
+
+// Register registers a typed handler: the job payload is decoded into T before handler
+// is called. Payloads that do not decode are dead-lettered.
+//
+// Usage:
+//
+//	jobs.Register(worker, "email.send", func(ctx context.Context, job *jobs.Job, args SendEmail) error {
+//		return mailer.Send(ctx, args.To, args.Template)
+//	})
+func Register[T any](w *Worker, kind string, handler func(ctx context.Context, job *Job, args T) error) {
+	w.Handle(kind, func(ctx context.Context, job *Job) error {
+		var args T
+		if err := json.Unmarshal(job.Payload, &args); err != nil {
+			return Permanent(errors.Wrap(err, errors.ErrCodeInvalidInput, "failed to decode job arguments"))
+		}
+		return handler(ctx, job, args)
+	})
+}
+
+// handler returns the handler registered for kind
+func (w *Worker) handler(kind string) (HandlerFunc, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	handler, ok := w.handlers[kind]
+	return handler, ok
+}
+
+// Run processes jobs until ctx is done, then stops claiming and drains: in-flight jobs
+// get ShutdownTimeout to finish before their contexts are cancelled, and jobs cut short
+// this way are released back to pending without consuming an attempt.
+func (w *Worker) Run(ctx context.Context) error {
+	w.cfg.Logger.Info("Job worker started", map[string]interface{}{
+		"workerId":    w.cfg.ID,
+		"queues":      w.cfg.Queues,
+		"concurrency": w.cfg.Concurrency,
+	})
+
+	// Handlers outlive ctx during the drain
+	jobCtx, cancelJobs := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelJobs()
+
+	slots := make(chan struct{}, w.cfg.Concurrency)
+	freed := make(chan struct{}, 1)
+	var wg sync.WaitGroup
+	var lastRescue time.Time
+
+	for ctx.Err() == nil {
+		if time.Since(lastRescue) >= w.cfg.StaleAfter/2 {
+			w.rescue(ctx)
+			lastRescue = time.Now()
+		}
+
+		free := w.cfg.Concurrency - len(slots)
+		claimed := 0
+		if free > 0 {
+			records, err := w.claim(ctx, free)
+			if err != nil && ctx.Err() == nil {
+				w.cfg.Logger.Error("Failed to claim jobs", err)
+			}
+			claimed = len(records)
+			for _, rec := range records {
+				slots <- struct{}{}
+				wg.Add(1)
+				go func(rec record) {
+					defer wg.Done()
+					w.execute(jobCtx, rec)
+					<-slots
+					select {
+					case freed <- struct{}{}:
+					default:
+					}
+				}(rec)
+			}
+		}
+
+		if free > 0 && claimed == free {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+		case <-freed:
+		case <-time.After(w.cfg.PollInterval):
+		}
+	}
+
+	w.cfg.Logger.Info("Job worker draining", map[string]interface{}{"inFlight": len(slots)})
+	drained := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-time.After(w.cfg.ShutdownTimeout):
+		w.cfg.Logger.Warn("Job worker drain timed out, cancelling in-flight jobs", map[string]interface{}{"inFlight": len(slots)})
+		cancelJobs()
+		<-drained
+	}
+	w.cfg.Logger.Info("Job worker stopped")
+	return nil
+}
+
+// claim marks up to limit available jobs as running by this worker and returns them
+func (w *Worker) claim(ctx context.Context, limit int) ([]record, error) {
+	var records []record
+	err := w.db.WithContext(ctx).Raw(`UPDATE jobs SET status = ?, attempts = attempts + 1, locked_by = ?, locked_at = NOW(), updated_at = NOW()
+WHERE id IN (SELECT id FROM jobs WHERE status = ? AND queue IN ? AND run_at <= NOW() ORDER BY run_at, id LIMIT ? FOR UPDATE SKIP LOCKED)
+RETURNING *`, StatusRunning, w.cfg.ID, StatusPending, w.cfg.Queues, limit).Scan(&records).Error
+	if err != nil {
+		return nil, database.TranslateError(err)
+	}
+	return records, nil
+}
+
+// rescue returns jobs locked for longer than StaleAfter (their worker presumably died)
+// to pending, or dead-letters them when they have no attempts left
+func (w *Worker) rescue(ctx context.Context) {
+	result := w.db.WithContext(ctx).Exec(`UPDATE jobs SET
+status = CASE WHEN attempts >= max_attempts THEN ? ELSE ? END,
+finished_at = CASE WHEN attempts >= max_attempts THEN NOW() END,
+last_error = ?, locked_by = NULL, locked_at = NULL, run_at = NOW(), updated_at = NOW()
+WHERE status = ? AND locked_at < ?`,
+		StatusDead, StatusPending, "worker lost while running the job", StatusRunning, time.Now().UTC().Add(-w.cfg.StaleAfter))
+	if result.Error != nil {
+		if ctx.Err() == nil {
+			w.cfg.Logger.Warn("Failed to rescue stale jobs", map[string]interface{}{"error": result.Error.Error()})
+		}
+		return
+	}
+	if result.RowsAffected > 0 {
+		w.cfg.Logger.Warn("Rescued stale jobs", map[string]interface{}{"count": result.RowsAffected})
+	}
+}
+
+// execute runs the handler of a claimed job and records the outcome
+func (w *Worker) execute(ctx context.Context, rec record) {
+	job := rec.job()
+	if w.metrics != nil {
+		w.metrics.inFlight.Inc()
+		defer w.metrics.inFlight.Dec()
+	}
+
+	start := time.Now()
+	err := w.run(ctx, job)
+	if w.metrics != nil {
+		w.metrics.duration.WithLabelValues(job.Kind).Observe(time.Since(start).Seconds())
+	}
+
+	var result string
+	switch {
+	case err == nil:
+		result = resultCompleted
+	case ctx.Err() != nil:
+		// Cut short by shutdown: not the job's fault
+		result = resultReleased
+	case isPermanent(err) || job.Attempt >= job.MaxAttempts:
+		result = resultDead
+	default:
+		result = resultRetried
+	}
+
+	if finishErr := w.finish(job, result, err); finishErr != nil {
+		w.cfg.Logger.Error("Failed to record job result", finishErr, map[string]interface{}{"jobId": job.ID, "kind": job.Kind, "result": result})
+	}
+	if w.metrics != nil {
+		w.metrics.processed.WithLabelValues(job.Kind, result).Inc()
+	}
+}
+
+// run calls the handler of job, bounded by JobTimeout and recovering panics
+func (w *Worker) run(ctx context.Context, job *Job) (err error) {
+	handler, ok := w.handler(job.Kind)
+	if !ok {
+		// Possibly a newer deployment knows the kind: retry rather than dead-letter
+		return fmt.Errorf("no handler registered for job kind %q", job.Kind)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, w.cfg.JobTimeout)
+	defer cancel()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job handler panicked: %v", r)
+		}
+	}()
+	return handler(ctx, job)
+}
+
+// finish records the outcome of a job run, provided this worker still holds the job
+func (w *Worker) finish(job *Job, result string, runErr error) error {
+	updates := map[string]interface{}{
+		"locked_by":  nil,
+		"locked_at":  nil,
+		"updated_at": gorm.Expr("NOW()"),
+	}
+	fields := map[string]interface{}{
+		"jobId":   job.ID,
+		"kind":    job.Kind,
+		"attempt": job.Attempt,
+	}
+
+	switch result {
+	case resultCompleted:
+		updates["status"] = StatusCompleted
+		updates["finished_at"] = gorm.Expr("NOW()")
+		updates["last_error"] = nil
+	case resultReleased:
+		updates["status"] = StatusPending
+		updates["attempts"] = gorm.Expr("attempts - 1")
+		updates["run_at"] = gorm.Expr("NOW()")
+		w.cfg.Logger.Warn("Job interrupted by shutdown, released", fields)
+	case resultDead:
+		updates["status"] = StatusDead
+		updates["finished_at"] = gorm.Expr("NOW()")
+		updates["last_error"] = truncateError(runErr)
+		w.cfg.Logger.Error("Job failed permanently", runErr, fields)
+	default:
+		delay := w.backoff(job.Attempt)
+		updates["status"] = StatusPending
+		updates["run_at"] = time.Now().UTC().Add(delay)
+		updates["last_error"] = truncateError(runErr)
+		fields["retryIn"] = delay.String()
+		fields["error"] = runErr.Error()
+		w.cfg.Logger.Warn("Job failed, will retry", fields)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), finishTimeout)
+	defer cancel()
+	err := w.db.WithContext(ctx).Model(&record{}).
+		Where("id = ? AND status = ? AND locked_by = ?", job.ID, StatusRunning, w.cfg.ID).
+		Updates(updates).Error
+	if err != nil {
+		return database.TranslateError(err)
+	}
+	return nil
+}
+
+// backoff returns the delay before retrying a job that failed attempts times, doubling
+// from InitialBackoff up to MaxBackoff with jitter in the upper half of the interval
+func (w *Worker) backoff(attempts int) time.Duration {
+	return backoff.Jitter(attempts-1, w.cfg.InitialBackoff, w.cfg.MaxBackoff)
+}
+
+// truncateError returns the message of err bounded to maxErrorLength
+func truncateError(err error) string {
+	message := err.Error()
+	if len(message) > maxErrorLength {
+		message = message[:maxErrorLength]
+	}
+	return message
+}
@@ -0,0 +1,344 @@
+package jobs
+
+import (
+	"context"
+	"database/sql/driver"
+	stderrors "errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/medbai2/common-go/errors"
+	"github.com/medbai2/common-go/logger"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	claimJobs  = `UPDATE jobs SET status = $1, attempts = attempts + 1, locked_by = $2, locked_at = NOW(), updated_at = NOW()`
+	rescueJobs = `UPDATE jobs SET`
+	finishJob  = `UPDATE "jobs" SET`
+)
+
+// Arguments of the finish update for each outcome, followed by the job ID, StatusRunning and the worker ID
+var (
+	completedArgs = []driver.Value{nil, nil, nil, StatusCompleted}
+	retriedArgs   = []driver.Value{sqlmock.AnyArg(), nil, nil, sqlmock.AnyArg(), StatusPending}
+	deadArgs      = []driver.Value{sqlmock.AnyArg(), nil, nil, StatusDead}
+	releasedArgs  = []driver.Value{nil, nil, StatusPending}
+)
+
+func finishArgs(args []driver.Value, id int64) []driver.Value {
+	return append(append([]driver.Value{}, args...), id, StatusRunning, "worker-1")
+}
+
+func newJobRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "queue", "kind", "payload", "unique_key", "status", "attempts", "max_attempts", "last_error", "run_at", "locked_by", "locked_at", "created_at", "updated_at", "finished_at"})
+}
+
+func addJobRow(rows *sqlmock.Rows, id int64, kind, payload string, attempts, maxAttempts int) *sqlmock.Rows {
+	now := time.Now()
+	return rows.AddRow(id, DefaultQueue, kind, []byte(payload), nil, StatusRunning, attempts, maxAttempts, nil, now, "worker-1", now, now, now, nil)
+}
+
+func newTestWorker(t *testing.T, cfg WorkerConfig) (*Worker, sqlmock.Sqlmock) {
+	db, mock := newTestDB(t)
+	cfg.ID = "worker-1"
+	cfg.Logger = logger.NewZapLogger("fatal")
+	if cfg.Registerer == nil {
+		cfg.Registerer = prometheus.NewRegistry()
+	}
+	worker, err := NewWorker(db, cfg)
+	require.NoError(t, err)
+	return worker, mock
+}
+
+func TestNewWorker(t *testing.T) {
+	_, err := NewWorker(nil, WorkerConfig{})
+	assert.Equal(t, errors.ErrCodeDatabaseError, errors.GetAppError(err).Code)
+
+	db, _ := newTestDB(t)
+	worker, err := NewWorker(db, WorkerConfig{Logger: logger.NewZapLogger("fatal")})
+	require.NoError(t, err)
+	assert.NotEmpty(t, worker.cfg.ID)
+	assert.Equal(t, []string{DefaultQueue}, worker.cfg.Queues)
+	assert.Equal(t, DefaultConcurrency, worker.cfg.Concurrency)
+	assert.Equal(t, DefaultPollInterval, worker.cfg.PollInterval)
+	assert.Equal(t, DefaultJobTimeout, worker.cfg.JobTimeout)
+	assert.Equal(t, DefaultStaleAfter, worker.cfg.StaleAfter)
+	assert.Equal(t, DefaultShutdownTimeout, worker.cfg.ShutdownTimeout)
+	assert.Nil(t, worker.metrics)
+}
+
+func TestWorker_Claim(t *testing.T) {
+	worker, mock := newTestWorker(t, WorkerConfig{Queues: []string{"mail", "exports"}})
+
+	mock.ExpectQuery(regexp.QuoteMeta(claimJobs+`
+WHERE id IN (SELECT id FROM jobs WHERE status = $3 AND queue IN ($4,$5) AND run_at <= NOW() ORDER BY run_at, id LIMIT $6 FOR UPDATE SKIP LOCKED)
+RETURNING *`)).
+		WithArgs(StatusRunning, "worker-1", StatusPending, "mail", "exports", 3).
+		WillReturnRows(addJobRow(newJobRows(), 1, "email.send", `{}`, 1, 5))
+
+	records, err := worker.claim(context.Background(), 3)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "email.send", records[0].Kind)
+	assert.Equal(t, 1, records[0].job().Attempt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWorker_Execute(t *testing.T) {
+	testCases := []struct {
+		Name           string
+		Handler        HandlerFunc
+		Attempts       int
+		ExpectedArgs   []driver.Value
+		ExpectedResult string
+	}{
+		{
+			Name:           "completed",
+			Handler:        func(ctx context.Context, job *Job) error { return nil },
+			Attempts:       1,
+			ExpectedArgs:   completedArgs,
+			ExpectedResult: resultCompleted,
+		},
+		{
+			Name:           "retried",
+			Handler:        func(ctx context.Context, job *Job) error { return stderrors.New("smtp unavailable") },
+			Attempts:       1,
+			ExpectedArgs:   retriedArgs,
+			ExpectedResult: resultRetried,
+		},
+		{
+			Name:           "dead after max attempts",
+			Handler:        func(ctx context.Context, job *Job) error { return stderrors.New("smtp unavailable") },
+			Attempts:       3,
+			ExpectedArgs:   deadArgs,
+			ExpectedResult: resultDead,
+		},
+		{
+			Name:           "permanent error",
+			Handler:        func(ctx context.Context, job *Job) error { return Permanent(stderrors.New("bad address")) },
+			Attempts:       1,
+			ExpectedArgs:   deadArgs,
+			ExpectedResult: resultDead,
+		},
+		{
+			Name:           "panic",
+			Handler:        func(ctx context.Context, job *Job) error { panic("boom") },
+			Attempts:       1,
+			ExpectedArgs:   retriedArgs,
+			ExpectedResult: resultRetried,
+		},
+		{
+			Name:           "missing handler",
+			Attempts:       1,
+			ExpectedArgs:   retriedArgs,
+			ExpectedResult: resultRetried,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			worker, mock := newTestWorker(t, WorkerConfig{})
+			if tc.Handler != nil {
+				worker.Handle("email.send", tc.Handler)
+			}
+
+			mock.ExpectQuery(regexp.QuoteMeta(claimJobs)).
+				WillReturnRows(addJobRow(newJobRows(), 4, "email.send", `{}`, tc.Attempts, 3))
+			mock.ExpectExec(regexp.QuoteMeta(finishJob)).
+				WithArgs(finishArgs(tc.ExpectedArgs, 4)...).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+
+			records, err := worker.claim(context.Background(), 1)
+			require.NoError(t, err)
+			worker.execute(context.Background(), records[0])
+
+			assert.Equal(t, 1.0, testutil.ToFloat64(worker.metrics.processed.WithLabelValues("email.send", tc.ExpectedResult)))
+			assert.Equal(t, 0.0, testutil.ToFloat64(worker.metrics.inFlight))
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRegister(t *testing.T) {
+	testCases := []struct {
+		Name           string
+		Payload        string
+		ExpectedTo     string
+		ExpectedResult string
+		ExpectedArgs   []driver.Value
+	}{
+		{Name: "decoded arguments", Payload: `{"to":"a@b.test"}`, ExpectedTo: "a@b.test", ExpectedResult: resultCompleted, ExpectedArgs: completedArgs},
+		{Name: "undecodable payload is dead-lettered", Payload: `[1]`, ExpectedResult: resultDead, ExpectedArgs: deadArgs},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			worker, mock := newTestWorker(t, WorkerConfig{})
+			var got sendEmail
+			Register(worker, "email.send", func(ctx context.Context, job *Job, args sendEmail) error {
+				got = args
+				return nil
+			})
+
+			mock.ExpectQuery(regexp.QuoteMeta(claimJobs)).
+				WillReturnRows(addJobRow(newJobRows(), 4, "email.send", tc.Payload, 1, 3))
+			mock.ExpectExec(regexp.QuoteMeta(finishJob)).
+				WithArgs(finishArgs(tc.ExpectedArgs, 4)...).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+
+			records, err := worker.claim(context.Background(), 1)
+			require.NoError(t, err)
+			worker.execute(context.Background(), records[0])
+
+			assert.Equal(t, tc.ExpectedTo, got.To)
+			assert.Equal(t, 1.0, testutil.ToFloat64(worker.metrics.processed.WithLabelValues("email.send", tc.ExpectedResult)))
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestWorker_JobTimeout(t *testing.T) {
+	worker, mock := newTestWorker(t, WorkerConfig{JobTimeout: 10 * time.Millisecond})
+	worker.Handle("export.build", func(ctx context.Context, job *Job) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	mock.ExpectQuery(regexp.QuoteMeta(claimJobs)).
+		WillReturnRows(addJobRow(newJobRows(), 4, "export.build", `{}`, 1, 3))
+	mock.ExpectExec(regexp.QuoteMeta(finishJob)).
+		WithArgs(finishArgs(retriedArgs, 4)...).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	records, err := worker.claim(context.Background(), 1)
+	require.NoError(t, err)
+	worker.execute(context.Background(), records[0])
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(worker.metrics.processed.WithLabelValues("export.build", resultRetried)))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWorker_Run(t *testing.T) {
+	worker, mock := newTestWorker(t, WorkerConfig{PollInterval: time.Hour})
+	processed := make(chan struct{})
+	worker.Handle("email.send", func(ctx context.Context, job *Job) error {
+		close(processed)
+		return nil
+	})
+
+	mock.ExpectExec(regexp.QuoteMeta(rescueJobs)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(claimJobs)).
+		WithArgs(StatusRunning, "worker-1", StatusPending, DefaultQueue, DefaultConcurrency).
+		WillReturnRows(addJobRow(newJobRows(), 1, "email.send", `{}`, 1, 3))
+	mock.ExpectExec(regexp.QuoteMeta(finishJob)).
+		WithArgs(finishArgs(completedArgs, 1)...).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// The freed slot triggers an immediate poll
+	mock.ExpectQuery(regexp.QuoteMeta(claimJobs)).WillReturnRows(newJobRows())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- worker.Run(ctx) }()
+
+	<-processed
+	require.Eventually(t, func() bool { return mock.ExpectationsWereMet() == nil }, time.Second, 5*time.Millisecond)
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestWorker_RunDrainsInFlightJobs(t *testing.T) {
+	testCases := []struct {
+		Name            string
+		ShutdownTimeout time.Duration
+		FinishOnSignal  bool
+		ExpectedArgs    []driver.Value
+		ExpectedResult  string
+	}{
+		{Name: "job finishes during drain", ShutdownTimeout: time.Minute, FinishOnSignal: true, ExpectedArgs: completedArgs, ExpectedResult: resultCompleted},
+		{Name: "job released after drain timeout", ShutdownTimeout: 10 * time.Millisecond, ExpectedArgs: releasedArgs, ExpectedResult: resultReleased},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			worker, mock := newTestWorker(t, WorkerConfig{PollInterval: time.Hour, ShutdownTimeout: tc.ShutdownTimeout})
+			started := make(chan struct{})
+			finish := make(chan struct{})
+			worker.Handle("export.build", func(ctx context.Context, job *Job) error {
+				close(started)
+				select {
+				case <-finish:
+					return nil
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			})
+
+			mock.ExpectExec(regexp.QuoteMeta(rescueJobs)).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(regexp.QuoteMeta(claimJobs)).
+				WillReturnRows(addJobRow(newJobRows(), 2, "export.build", `{}`, 1, 3))
+			mock.ExpectExec(regexp.QuoteMeta(finishJob)).
+				WithArgs(finishArgs(tc.ExpectedArgs, 2)...).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error, 1)
+			go func() { done <- worker.Run(ctx) }()
+
+			<-started
+			cancel()
+			if tc.FinishOnSignal {
+				close(finish)
+			}
+			require.NoError(t, <-done)
+
+			assert.Equal(t, 1.0, testutil.ToFloat64(worker.metrics.processed.WithLabelValues("export.build", tc.ExpectedResult)))
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestWorker_Rescue(t *testing.T) {
+	worker, mock := newTestWorker(t, WorkerConfig{StaleAfter: time.Minute})
+
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE jobs SET
+status = CASE WHEN attempts >= max_attempts THEN $1 ELSE $2 END,
+finished_at = CASE WHEN attempts >= max_attempts THEN NOW() END,
+last_error = $3, locked_by = NULL, locked_at = NULL, run_at = NOW(), updated_at = NOW()
+WHERE status = $4 AND locked_at < $5`)).
+		WithArgs(StatusDead, StatusPending, sqlmock.AnyArg(), StatusRunning, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	worker.rescue(context.Background())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWorker_Backoff(t *testing.T) {
+	worker := &Worker{cfg: WorkerConfig{InitialBackoff: time.Second, MaxBackoff: 10 * time.Second}}
+
+	testCases := []struct {
+		Name        string
+		Attempts    int
+		ExpectedMax time.Duration
+	}{
+		{Name: "first retry", Attempts: 1, ExpectedMax: time.Second},
+		{Name: "doubles", Attempts: 3, ExpectedMax: 4 * time.Second},
+		{Name: "capped", Attempts: 30, ExpectedMax: 10 * time.Second},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			for i := 0; i < 20; i++ {
+				delay := worker.backoff(tc.Attempts)
+				assert.GreaterOrEqual(t, delay, tc.ExpectedMax/2)
+				assert.LessOrEqual(t, delay, tc.ExpectedMax)
+			}
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package logger
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// LevelOverride is a level set at runtime for a component's loggers
+type LevelOverride struct {
+	Component string    // Service/component name; "" applies to every logger
+	Level     LogLevel  // Level replacing the configured one
+	ExpiresAt time.Time // When the override is removed (zero: until reset)
+}
+
+// componentLevel holds the current override of one component. Loggers keep a pointer to
+// it so checking a level is a single atomic load.
+type componentLevel struct {
+	override atomic.Pointer[LevelOverride]
+	timer    *time.Timer // Pending auto-revert, guarded by LevelRegistry.mu
+}
+
+// LevelRegistry holds runtime level overrides keyed by service/component. A component
+// override takes precedence over the "" override, which takes precedence over the level
+// a logger was created with.
+type LevelRegistry struct {
+	mu         sync.RWMutex
+	components map[string]*componentLevel
+	global     *componentLevel
+}
+
+// Levels is the registry honoured by every logger of this package. The component of a
+// logger is the service passed to New, NewContextLogger or WithService.
+var Levels = NewLevelRegistry()
+
+// NewLevelRegistry returns an empty registry
+func NewLevelRegistry() *LevelRegistry {
+	global := &componentLevel{}
+	return &LevelRegistry{
+		components: map[string]*componentLevel{"": global},
+		global:     global,
+	}
+}
+
+// Set overrides the level of component's loggers ("" for every logger), including
+// loggers already created. With a positive ttl the override is removed after ttl;
+// otherwise it stays until Reset.
+//
+// Usage:
+//
+//	logger.Levels.Set("auth0-middleware", logger.DEBUG, 10*time.Minute)
+func (r *LevelRegistry) Set(component string, level LogLevel, ttl time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry := r.entry(component)
+	override := &LevelOverride{Component: component, Level: level}
+	if entry.timer != nil {
+		entry.timer.Stop()
+		entry.timer = nil
+	}
+	if ttl > 0 {
+		override.ExpiresAt = time.Now().Add(ttl)
+		var timer *time.Timer
+		timer = time.AfterFunc(ttl, func() { r.expire(entry, timer) })
+		entry.timer = timer
+	}
+	entry.override.Store(override)
+}
+
+// expire removes the override of entry scheduled by timer, unless a later Set or Reset
+// already replaced it
+func (r *LevelRegistry) expire(entry *componentLevel, timer *time.Timer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if entry.timer == timer {
+		entry.override.Store(nil)
+		entry.timer = nil
+	}
+}
+
+// Reset removes the override of component ("" for the every-logger override)
+func (r *LevelRegistry) Reset(component string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.components[component]
+	if !ok {
+		return
+	}
+	if entry.timer != nil {
+		entry.timer.Stop()
+		entry.timer = nil
+	}
+	entry.override.Store(nil)
+}
+
+// Get returns the override of component, if any
+func (r *LevelRegistry) Get(component string) (LevelOverride, bool) {
+	r.mu.RLock()
+	entry, ok := r.components[component]
+	r.mu.RUnlock()
+	if !ok {
+		return LevelOverride{}, false
+	}
+	if override := entry.override.Load(); override != nil {
+		return *override, true
+	}
+	return LevelOverride{}, false
+}
+
+// Overrides returns the current overrides sorted by component
+func (r *LevelRegistry) Overrides() []LevelOverride {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	overrides := []LevelOverride{}
+	for _, entry := range r.components {
+		if override := entry.override.Load(); override != nil {
+			overrides = append(overrides, *override)
+		}
+	}
+	sort.Slice(overrides, func(i, j int) bool { return overrides[i].Component < overrides[j].Component })
+	return overrides
+}
+
+// component returns the entry loggers of component check, creating it if needed
+func (r *LevelRegistry) component(component string) *componentLevel {
+	r.mu.RLock()
+	entry, ok := r.components[component]
+	r.mu.RUnlock()
+	if ok {
+		return entry
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.entry(component)
+}
+
+// entry returns the entry of component, creating it if needed. r.mu must be held.
+func (r *LevelRegistry) entry(component string) *componentLevel {
+	entry, ok := r.components[component]
+	if !ok {
+		entry = &componentLevel{}
+		r.components[component] = entry
+	}
+	return entry
+}
+
+// enabled reports whether an entry at level is logged by a logger created at configured
+// for component (nil when the logger has no component)
+func (r *LevelRegistry) enabled(component *componentLevel, configured, level zapcore.Level) bool {
+	if component != nil {
+		if override := component.override.Load(); override != nil {
+			return level >= override.Level.zapLevel()
+		}
+	}
+	if override := r.global.override.Load(); override != nil {
+		return level >= override.Level.zapLevel()
+	}
+	return level >= configured
+}
+
+// SetLevel overrides the level of every logger, including loggers already created, until
+// ResetLevel is called. Component overrides set on Levels still take precedence.
+func SetLevel(level LogLevel) {
+	Levels.Set("", level, 0)
+}
+
+// ResetLevel removes the override set with SetLevel
+func ResetLevel() {
+	Levels.Reset("")
+}
+
+// GetLevel returns the level set with SetLevel, or the level of the root logger (see
+// Default) and false when there is no override
+func GetLevel() (LogLevel, bool) {
+	if override, ok := Levels.Get(""); ok {
+		return override.Level, true
+	}
+	return fromZapLevel(Default().level), false
+}
+
+// LookupLogLevel parses level like ParseLogLevel, reporting whether it is a known level
+// instead of falling back to INFO
+func LookupLogLevel(level string) (LogLevel, bool) {
+	switch strings.ToLower(level) {
+	case "debug":
+		return DEBUG, true
+	case "info":
+		return INFO, true
+	case "warn", "warning":
+		return WARN, true
+	case "error":
+		return ERROR, true
+	default:
+		return INFO, false
+	}
+}
+
+// zapLevel returns the zap level of l
+func (l LogLevel) zapLevel() zapcore.Level {
+	switch l {
+	case DEBUG:
+		return zapcore.DebugLevel
+	case WARN:
+		return zapcore.WarnLevel
+	case ERROR:
+		return zapcore.ErrorLevel
+	default:
+		return zapcore.InfoLevel
+	}
+}
+
+// fromZapLevel returns the LogLevel of a zap level
+func fromZapLevel(level zapcore.Level) LogLevel {
+	switch {
+	case level <= zapcore.DebugLevel:
+		return DEBUG
+	case level == zapcore.InfoLevel:
+		return INFO
+	case level == zapcore.WarnLevel:
+		return WARN
+	default:
+		return ERROR
+	}
+}
@@ -0,0 +1,158 @@
+package logger
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSetLevel(t *testing.T) {
+	t.Cleanup(ResetLevel)
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	zl := &ZapLogger{logger: zap.New(core), level: zapcore.InfoLevel}
+	contextLogger := zl.NewContextLogger(context.Background(), "orders")
+
+	zl.Debug("hidden")
+	contextLogger.Debug("hidden")
+	assert.Equal(t, 0, logs.Len())
+
+	SetLevel(DEBUG)
+	zl.Debug("shown")
+	contextLogger.Debug("shown")
+	assert.Equal(t, 2, logs.Len())
+
+	SetLevel(ERROR)
+	zl.Warn("hidden")
+	zl.WithFields(map[string]interface{}{"k": "v"}).Warn("hidden")
+	assert.Equal(t, 2, logs.Len())
+
+	ResetLevel()
+	zl.Warn("shown")
+	assert.Equal(t, 3, logs.Len())
+}
+
+func TestLevelRegistry_ComponentOverride(t *testing.T) {
+	t.Cleanup(func() {
+		Levels.Reset("")
+		Levels.Reset("auth0-middleware")
+	})
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	zl := &ZapLogger{logger: zap.New(core), level: zapcore.InfoLevel}
+	auth := zl.NewContextLogger(context.Background(), "auth0-middleware")
+	rbac := zl.NewContextLogger(context.Background(), "rbac-require-auth")
+	service := zl.WithService("auth0-middleware").WithFields(map[string]interface{}{"k": "v"})
+
+	Levels.Set("auth0-middleware", DEBUG, 0)
+	auth.Debug("shown")
+	service.Debug("shown")
+	rbac.Debug("hidden")
+	assert.Equal(t, 2, logs.Len())
+
+	// A component override wins over the every-logger override
+	Levels.Set("", ERROR, 0)
+	auth.Debug("shown")
+	rbac.Warn("hidden")
+	assert.Equal(t, 3, logs.Len())
+
+	Levels.Reset("auth0-middleware")
+	auth.Debug("hidden")
+	auth.Error("shown", nil)
+	assert.Equal(t, 4, logs.Len())
+}
+
+func TestLevelRegistry_TTL(t *testing.T) {
+	registry := NewLevelRegistry()
+
+	registry.Set("auth0-middleware", DEBUG, 20*time.Millisecond)
+	override, ok := registry.Get("auth0-middleware")
+	require.True(t, ok)
+	assert.Equal(t, DEBUG, override.Level)
+	assert.WithinDuration(t, time.Now().Add(20*time.Millisecond), override.ExpiresAt, time.Second)
+
+	assert.Eventually(t, func() bool {
+		_, ok := registry.Get("auth0-middleware")
+		return !ok
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestLevelRegistry_SetCancelsPendingRevert(t *testing.T) {
+	registry := NewLevelRegistry()
+
+	registry.Set("auth0-middleware", DEBUG, time.Hour)
+	entry := registry.component("auth0-middleware")
+	stale := entry.timer
+	registry.Set("auth0-middleware", WARN, 0)
+
+	// The replaced timer was stopped, and its revert is ignored should it already be running
+	assert.False(t, stale.Stop())
+	registry.expire(entry, stale)
+
+	override, ok := registry.Get("auth0-middleware")
+	require.True(t, ok)
+	assert.Equal(t, WARN, override.Level)
+	assert.True(t, override.ExpiresAt.IsZero())
+}
+
+func TestLevelRegistry_Overrides(t *testing.T) {
+	registry := NewLevelRegistry()
+	assert.Empty(t, registry.Overrides())
+
+	registry.Set("rbac", WARN, 0)
+	registry.Set("", ERROR, 0)
+	registry.Set("auth0-middleware", DEBUG, 0)
+	registry.component("idle")
+
+	overrides := registry.Overrides()
+	require.Len(t, overrides, 3)
+	assert.Equal(t, []string{"", "auth0-middleware", "rbac"},
+		[]string{overrides[0].Component, overrides[1].Component, overrides[2].Component})
+
+	registry.Reset("rbac")
+	registry.Reset("unknown")
+	assert.Len(t, registry.Overrides(), 2)
+}
+
+func TestGetLevel(t *testing.T) {
+	t.Cleanup(ResetLevel)
+	setRoot(t, &ZapLogger{logger: zap.NewNop(), level: zapcore.WarnLevel})
+
+	level, overridden := GetLevel()
+	assert.Equal(t, WARN, level)
+	assert.False(t, overridden)
+
+	SetLevel(DEBUG)
+	level, overridden = GetLevel()
+	assert.Equal(t, DEBUG, level)
+	assert.True(t, overridden)
+}
+
+func TestLookupLogLevel(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		Level         string
+		ExpectedLevel LogLevel
+		ExpectedOK    bool
+	}{
+		{Name: "lowercase", Level: "debug", ExpectedLevel: DEBUG, ExpectedOK: true},
+		{Name: "uppercase", Level: "ERROR", ExpectedLevel: ERROR, ExpectedOK: true},
+		{Name: "warning alias", Level: "Warning", ExpectedLevel: WARN, ExpectedOK: true},
+		{Name: "unknown level", Level: "verbose", ExpectedLevel: INFO, ExpectedOK: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			level, ok := LookupLogLevel(tc.Level)
+			assert.Equal(t, tc.ExpectedLevel, level)
+			assert.Equal(t, tc.ExpectedOK, ok)
+			assert.Equal(t, tc.ExpectedLevel, ParseLogLevel(tc.Level))
+		})
+	}
+}
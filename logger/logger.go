@@ -2,9 +2,10 @@ package logger
 
 import (
 	"context"
-	"os"
 	"time"
 
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -23,8 +24,9 @@ type Logger interface {
 
 // ZapLogger implements the Logger interface using Zap
 type ZapLogger struct {
-	logger *zap.Logger
-	level  zapcore.Level
+	logger    *zap.Logger
+	level     zapcore.Level
+	component *componentLevel // Runtime level override of the service (see Levels)
 }
 
 // NewZapLogger creates a new Zap-based logger (deprecated - use NewZapLoggerFromConfig)
@@ -34,77 +36,46 @@ func NewZapLogger(level string) *ZapLogger {
 
 // NewContextLogger creates a logger with request context
 func (zl *ZapLogger) NewContextLogger(ctx context.Context, service string) Logger {
-	// Extract request ID from context if available
-	requestID := getRequestIDFromContext(ctx)
-
-	// Create logger with service and request context
-	fields := []zap.Field{
-		zap.String("service", service),
-	}
-
-	if requestID != "" {
-		fields = append(fields, zap.String("requestId", requestID))
-	}
+	// Create logger with service and request context (request ID, trace and span IDs)
+	fields := append([]zap.Field{zap.String("service", service)}, contextFields(ctx)...)
 
 	return &ZapContextLogger{
-		logger: zl.logger.With(fields...),
-		level:  zl.level,
+		logger:    zl.logger.With(fields...),
+		level:     zl.level,
+		component: Levels.component(service),
 	}
 }
 
-// NewContextLogger creates a logger with request context (standalone function)
+// NewContextLogger creates a logger with request context (standalone function). It is a
+// child of the root logger (see Default), so no zap core is built per call.
 func NewContextLogger(ctx context.Context, service string) Logger {
-	// Create a new ZapLogger instance
-	zapLogger := NewFromEnv(service)
-
-	// Extract request ID from context if available
-	requestID := getRequestIDFromContext(ctx)
-
-	// Create logger with service and request context
-	fields := []zap.Field{
-		zap.String("service", service),
-	}
-
-	if requestID != "" {
-		fields = append(fields, zap.String("requestId", requestID))
-	}
-
-	// Cast to ZapLogger to access the underlying zap logger
-	if zl, ok := zapLogger.(*ZapLogger); ok {
-		return &ZapContextLogger{
-			logger: zl.logger.With(fields...),
-			level:  zl.level,
-		}
-	}
-
-	// Fallback to basic logger
-	return zapLogger
+	return Default().NewContextLogger(ctx, service)
 }
 
 // Debug logs a debug message
 func (zl *ZapLogger) Debug(message string, fields ...map[string]interface{}) {
-	if zl.level <= zapcore.DebugLevel {
+	if Levels.enabled(zl.component, zl.level, zapcore.DebugLevel) {
 		zl.logger.Debug(message, convertFields(fields...)...)
 	}
 }
 
 // Info logs an info message
 func (zl *ZapLogger) Info(message string, fields ...map[string]interface{}) {
-	if zl.level <= zapcore.InfoLevel {
+	if Levels.enabled(zl.component, zl.level, zapcore.InfoLevel) {
 		zl.logger.Info(message, convertFields(fields...)...)
 	}
 }
 
 // Warn logs a warning message
 func (zl *ZapLogger) Warn(message string, fields ...map[string]interface{}) {
-	if zl.level <= zapcore.WarnLevel {
+	if Levels.enabled(zl.component, zl.level, zapcore.WarnLevel) {
 		zl.logger.Warn(message, convertFields(fields...)...)
 	}
 }
 
 // Error logs an error message
 func (zl *ZapLogger) Error(message string, err error, fields ...map[string]interface{}) {
-	if zl.level <= zapcore.ErrorLevel {
+	if Levels.enabled(zl.component, zl.level, zapcore.ErrorLevel) {
 		allFields := convertFields(fields...)
 		if err != nil {
 			allFields = append(allFields, zap.Error(err))
@@ -131,57 +102,61 @@ func (zl *ZapLogger) Sync() error {
 func (zl *ZapLogger) WithFields(fields map[string]interface{}) Logger {
 	zapFields := convertFields(fields)
 	return &ZapLogger{
-		logger: zl.logger.With(zapFields...),
-		level:  zl.level,
+		logger:    zl.logger.With(zapFields...),
+		level:     zl.level,
+		component: zl.component,
 	}
 }
 
 // WithRequestID creates a new logger with request ID
 func (zl *ZapLogger) WithRequestID(requestID string) Logger {
 	return &ZapLogger{
-		logger: zl.logger.With(zap.String("requestId", requestID)),
-		level:  zl.level,
+		logger:    zl.logger.With(zap.String("requestId", requestID)),
+		level:     zl.level,
+		component: zl.component,
 	}
 }
 
 // WithService creates a new logger with service name
 func (zl *ZapLogger) WithService(service string) Logger {
 	return &ZapLogger{
-		logger: zl.logger.With(zap.String("service", service)),
-		level:  zl.level,
+		logger:    zl.logger.With(zap.String("service", service)),
+		level:     zl.level,
+		component: Levels.component(service),
 	}
 }
 
 // ZapContextLogger implements Logger for request context
 type ZapContextLogger struct {
-	logger *zap.Logger
-	level  zapcore.Level
+	logger    *zap.Logger
+	level     zapcore.Level
+	component *componentLevel // Runtime level override of the service (see Levels)
 }
 
 // Debug logs a debug message
 func (zcl *ZapContextLogger) Debug(message string, fields ...map[string]interface{}) {
-	if zcl.level <= zapcore.DebugLevel {
+	if Levels.enabled(zcl.component, zcl.level, zapcore.DebugLevel) {
 		zcl.logger.Debug(message, convertFields(fields...)...)
 	}
 }
 
 // Info logs an info message
 func (zcl *ZapContextLogger) Info(message string, fields ...map[string]interface{}) {
-	if zcl.level <= zapcore.InfoLevel {
+	if Levels.enabled(zcl.component, zcl.level, zapcore.InfoLevel) {
 		zcl.logger.Info(message, convertFields(fields...)...)
 	}
 }
 
 // Warn logs a warning message
 func (zcl *ZapContextLogger) Warn(message string, fields ...map[string]interface{}) {
-	if zcl.level <= zapcore.WarnLevel {
+	if Levels.enabled(zcl.component, zcl.level, zapcore.WarnLevel) {
 		zcl.logger.Warn(message, convertFields(fields...)...)
 	}
 }
 
 // Error logs an error message
 func (zcl *ZapContextLogger) Error(message string, err error, fields ...map[string]interface{}) {
-	if zcl.level <= zapcore.ErrorLevel {
+	if Levels.enabled(zcl.component, zcl.level, zapcore.ErrorLevel) {
 		allFields := convertFields(fields...)
 		if err != nil {
 			allFields = append(allFields, zap.Error(err))
@@ -208,24 +183,27 @@ func (zcl *ZapContextLogger) Sync() error {
 func (zcl *ZapContextLogger) WithFields(fields map[string]interface{}) Logger {
 	zapFields := convertFields(fields)
 	return &ZapContextLogger{
-		logger: zcl.logger.With(zapFields...),
-		level:  zcl.level,
+		logger:    zcl.logger.With(zapFields...),
+		level:     zcl.level,
+		component: zcl.component,
 	}
 }
 
 // WithRequestID creates a new logger with request ID
 func (zcl *ZapContextLogger) WithRequestID(requestID string) Logger {
 	return &ZapContextLogger{
-		logger: zcl.logger.With(zap.String("requestId", requestID)),
-		level:  zcl.level,
+		logger:    zcl.logger.With(zap.String("requestId", requestID)),
+		level:     zcl.level,
+		component: zcl.component,
 	}
 }
 
 // WithService creates a new logger with service name
 func (zcl *ZapContextLogger) WithService(service string) Logger {
 	return &ZapContextLogger{
-		logger: zcl.logger.With(zap.String("service", service)),
-		level:  zcl.level,
+		logger:    zcl.logger.With(zap.String("service", service)),
+		level:     zcl.level,
+		component: Levels.component(service),
 	}
 }
 
@@ -244,12 +222,46 @@ func convertFields(fields ...map[string]interface{}) []zap.Field {
 	return zapFields
 }
 
+// contextFields returns the request ID and the trace and span IDs of the active span
+// carried by ctx, so log entries can be joined with traces
+func contextFields(ctx context.Context) []zap.Field {
+	var fields []zap.Field
+	if requestID := getRequestIDFromContext(ctx); requestID != "" {
+		fields = append(fields, zap.String("requestId", requestID))
+	}
+	if ctx != nil {
+		if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+			fields = append(fields,
+				zap.String("traceId", spanContext.TraceID().String()),
+				zap.String("spanId", spanContext.SpanID().String()),
+			)
+		}
+	}
+	return fields
+}
+
+// TraceFields returns the traceId and spanId of the span carried by ctx, or nil when
+// there is none (see the tracing package)
+func TraceFields(ctx context.Context) map[string]interface{} {
+	if ctx == nil {
+		return nil
+	}
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return nil
+	}
+	return map[string]interface{}{
+		"traceId": spanContext.TraceID().String(),
+		"spanId":  spanContext.SpanID().String(),
+	}
+}
+
 // getRequestIDFromContext extracts request ID from context
 func getRequestIDFromContext(ctx context.Context) string {
-	if requestID, ok := ctx.Value("requestId").(string); ok {
-		return requestID
+	if ctx == nil {
+		return ""
 	}
-	return ""
+	return GetRequestID(ctx)
 }
 
 // NewZapLoggerFromConfig creates a logger from configuration
@@ -275,20 +287,10 @@ func NewZapLoggerFromConfig(level string, environment string) *ZapLogger {
 	}
 
 	// Set log level
-	var zapLevel zapcore.Level
-	switch level {
-	case "debug":
-		zapLevel = zapcore.DebugLevel
-	case "info":
-		zapLevel = zapcore.InfoLevel
-	case "warn":
-		zapLevel = zapcore.WarnLevel
-	case "error":
-		zapLevel = zapcore.ErrorLevel
-	default:
-		zapLevel = zapcore.InfoLevel
-	}
-	config.Level = zap.NewAtomicLevelAt(zapLevel)
+	zapLevel := ParseLogLevel(level).zapLevel()
+	// The core logs every level; ZapLogger filters on zapLevel or the SetLevel override so
+	// the level can be changed at runtime
+	config.Level = zap.NewAtomicLevelAt(zapcore.DebugLevel)
 
 	// Build logger
 	logger, err := config.Build()
@@ -331,18 +333,8 @@ func (l LogLevel) String() string {
 
 // ParseLogLevel parses a string to LogLevel
 func ParseLogLevel(level string) LogLevel {
-	switch level {
-	case "debug", "DEBUG":
-		return DEBUG
-	case "info", "INFO":
-		return INFO
-	case "warn", "WARN", "warning", "WARNING":
-		return WARN
-	case "error", "ERROR":
-		return ERROR
-	default:
-		return INFO
-	}
+	parsed, _ := LookupLogLevel(level)
+	return parsed
 }
 
 // New creates a new logger instance (compatibility function)
@@ -352,13 +344,15 @@ func New(level LogLevel, service string) Logger {
 	return zapLogger.WithService(service)
 }
 
-// NewFromEnv creates a logger from environment variables
+// NewLogger creates a logger for service at the given level ("debug", "info", "warn", "error")
+func NewLogger(service, level string) Logger {
+	return New(ParseLogLevel(level), service)
+}
+
+// NewFromEnv returns a child of the root logger (see Default) for service. The name is
+// kept for compatibility: the root logger reads LOG_LEVEL when Init was not called.
 func NewFromEnv(service string) Logger {
-	levelStr := os.Getenv("LOG_LEVEL")
-	if levelStr == "" {
-		levelStr = "info"
-	}
-	return New(ParseLogLevel(levelStr), service)
+	return Default().WithService(service)
 }
 
 // Context keys for request ID and other context values
@@ -387,8 +381,18 @@ func WithRequestID(ctx context.Context, requestID string) context.Context {
 	return context.WithValue(ctx, RequestIDKey, requestID)
 }
 
-// GetRequestID extracts request ID from context
+// GetRequestID extracts the request ID stored with WithRequestID. A *gin.Context is
+// accepted too: the ID set on it by middleware.RequestID is used, then its request's context.
 func GetRequestID(ctx context.Context) string {
+	if gc, ok := ctx.(*gin.Context); ok {
+		if requestID := gc.GetString(string(RequestIDKey)); requestID != "" {
+			return requestID
+		}
+		if gc.Request == nil {
+			return ""
+		}
+		ctx = gc.Request.Context()
+	}
 	if requestID, ok := ctx.Value(RequestIDKey).(string); ok {
 		return requestID
 	}
@@ -1,15 +1,20 @@
 package logger
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/medbai2/common-go/testutils"
 
+	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap/zapcore"
 )
 
@@ -468,3 +473,76 @@ func TestZapLogger_ComplexDataStructures(t *testing.T) {
 		assert.True(t, hasKey, "Log %d should have key %s", i, expectedKey)
 	}
 }
+
+func TestGetRequestID(t *testing.T) {
+	ginContext := func(key string, ctx context.Context) *gin.Context {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+		if key != "" {
+			c.Set(string(RequestIDKey), key)
+		}
+		return c
+	}
+
+	testCases := []struct {
+		Name       string
+		Ctx        context.Context
+		ExpectedID string
+	}{
+		{Name: "canonical key", Ctx: WithRequestID(context.Background(), "req-1"), ExpectedID: "req-1"},
+		{Name: "gin context key", Ctx: ginContext("req-2", context.Background()), ExpectedID: "req-2"},
+		{Name: "gin request context", Ctx: ginContext("", WithRequestID(context.Background(), "req-3")), ExpectedID: "req-3"},
+		{Name: "missing", Ctx: context.Background(), ExpectedID: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert.Equal(t, tc.ExpectedID, GetRequestID(tc.Ctx))
+			assert.Equal(t, tc.ExpectedID, getRequestIDFromContext(tc.Ctx))
+		})
+	}
+}
+
+func TestTraceFields(t *testing.T) {
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	spanCtx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+
+	testCases := []struct {
+		Name           string
+		Ctx            context.Context
+		ExpectedFields map[string]interface{}
+	}{
+		{
+			Name:           "active span",
+			Ctx:            spanCtx,
+			ExpectedFields: map[string]interface{}{"traceId": "4bf92f3577b34da6a3ce929d0e0e4736", "spanId": "00f067aa0ba902b7"},
+		},
+		{Name: "no span", Ctx: context.Background()},
+		{Name: "nil context", Ctx: nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert.Equal(t, tc.ExpectedFields, TraceFields(tc.Ctx))
+		})
+	}
+}
+
+func TestContextFields(t *testing.T) {
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(WithRequestID(context.Background(), "req-1"), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+
+	var keys []string
+	for _, field := range contextFields(ctx) {
+		keys = append(keys, field.Key)
+	}
+	assert.Equal(t, []string{"requestId", "traceId", "spanId"}, keys)
+}
@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// root is the process-wide logger returned by Default
+var (
+	root     atomic.Pointer[ZapLogger]
+	rootOnce sync.Once
+)
+
+// Init configures the process-wide root logger from level ("debug", "info", "warn",
+// "error") and environment ("development" and "local" use console output, anything else
+// JSON). Call it once at startup, before creating loggers: loggers derived from the
+// previous root keep writing through it.
+//
+// Usage:
+//
+//	root := logger.Init(cfg.LogLevel, cfg.Environment)
+//	defer root.Sync()
+func Init(level string, environment string) *ZapLogger {
+	zl := NewZapLoggerFromConfig(level, environment)
+	SetDefault(zl)
+	return zl
+}
+
+// SetDefault replaces the root logger, e.g. with one built elsewhere or, in tests, to
+// restore the logger Default returned before a test called Init. nil is ignored.
+//
+// Usage:
+//
+//	previous := logger.Default()
+//	t.Cleanup(func() { logger.SetDefault(previous) })
+func SetDefault(zl *ZapLogger) {
+	if zl != nil {
+		root.Store(zl)
+	}
+}
+
+// Default returns the process-wide root logger. Without Init it is built once from
+// LOG_LEVEL with JSON output. NewFromEnv, NewContextLogger and FromContext return cheap
+// children of it that share its zap core.
+func Default() *ZapLogger {
+	if zl := root.Load(); zl != nil {
+		return zl
+	}
+	rootOnce.Do(func() {
+		root.CompareAndSwap(nil, NewZapLoggerFromConfig(os.Getenv("LOG_LEVEL"), "production"))
+	})
+	return root.Load()
+}
+
+// Sync flushes buffered entries of the root logger; call it before the process exits
+func Sync() error {
+	return Default().Sync()
+}
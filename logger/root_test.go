@@ -0,0 +1,88 @@
+package logger
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// setRoot replaces the root logger for the duration of the test
+func setRoot(t testing.TB, zl *ZapLogger) {
+	previous := root.Load()
+	root.Store(zl)
+	t.Cleanup(func() { root.Store(previous) })
+}
+
+func TestDefault(t *testing.T) {
+	assert.NotNil(t, Default())
+	assert.Same(t, Default(), Default())
+}
+
+func TestInit(t *testing.T) {
+	previous := Default()
+	t.Cleanup(func() { SetDefault(previous) })
+
+	zl := Init("debug", "production")
+	assert.Same(t, zl, Default())
+	assert.Equal(t, zapcore.DebugLevel, zl.level)
+
+	zl = Init("WARN", "development")
+	assert.Same(t, zl, Default())
+	assert.Equal(t, zapcore.WarnLevel, zl.level)
+}
+
+func TestSetDefault(t *testing.T) {
+	previous := Default()
+	t.Cleanup(func() { SetDefault(previous) })
+
+	zl := &ZapLogger{logger: zap.NewNop(), level: zapcore.ErrorLevel}
+	SetDefault(zl)
+	assert.Same(t, zl, Default())
+
+	SetDefault(nil)
+	assert.Same(t, zl, Default())
+}
+
+func TestDefault_ChildLoggers(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	setRoot(t, &ZapLogger{logger: zap.New(core), level: zapcore.InfoLevel})
+
+	ctx := WithRequestID(context.Background(), "req-1")
+	NewContextLogger(ctx, "auth0-middleware").Info("from context logger")
+	NewFromEnv("jobs").Info("from service logger")
+	FromContext(context.Background()).Info("from fallback logger")
+	NewFromEnv("jobs").Debug("filtered by the root level")
+
+	entries := logs.All()
+	require.Len(t, entries, 3)
+	assert.Equal(t, "auth0-middleware", entries[0].ContextMap()["service"])
+	assert.Equal(t, "req-1", entries[0].ContextMap()["requestId"])
+	assert.Equal(t, "jobs", entries[1].ContextMap()["service"])
+	assert.Equal(t, "unknown", entries[2].ContextMap()["service"])
+}
+
+// BenchmarkNewContextLogger compares building a zap logger per call, as NewContextLogger
+// did before the root logger, with deriving a child of the root logger
+func BenchmarkNewContextLogger(b *testing.B) {
+	ctx := WithRequestID(context.Background(), "req-1")
+
+	b.Run("new zap logger per call", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			NewZapLoggerFromConfig("info", "production").NewContextLogger(ctx, "auth0-middleware")
+		}
+	})
+
+	b.Run("child of the root logger", func(b *testing.B) {
+		setRoot(b, NewZapLoggerFromConfig("info", "production"))
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			NewContextLogger(ctx, "auth0-middleware")
+		}
+	})
+}
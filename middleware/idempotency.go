@@ -0,0 +1,369 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/medbai2/common-go/errors"
+	"github.com/medbai2/common-go/logger"
+	"github.com/medbai2/common-go/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IdempotencyKeyHeader is the request header carrying the client's idempotency key
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotentReplayedHeader is set to "true" on responses replayed from the store
+const IdempotentReplayedHeader = "Idempotent-Replayed"
+
+// idempotentReplayHeaders are the response headers stored and replayed. Request-specific
+// headers (X-Request-ID, RateLimit-*, Date, Set-Cookie, trace headers) come from the
+// request being answered instead.
+var idempotentReplayHeaders = []string{"Content-Type", "Location", "ETag"}
+
+// Idempotency defaults
+const (
+	DefaultIdempotencyTTL         = 24 * time.Hour
+	DefaultIdempotencyLockTimeout = time.Minute
+	DefaultIdempotencyMaxBody     = 1 << 20
+	MaxIdempotencyKeyLength       = 255
+)
+
+// IdempotencyRecord is a stored idempotency key: an in-flight reservation until
+// Completed, then the captured response
+type IdempotencyRecord struct {
+	Key         string
+	Fingerprint string // Hash of method, path and body of the first request
+	Token       string // Identifies the reservation returned by Begin; empty on existing records
+	Completed   bool
+	StatusCode  int
+	Header      http.Header
+	Body        []byte
+}
+
+// IdempotencyStore persists idempotency keys. Implementations must make Begin atomic:
+// of several concurrent calls for the same key, exactly one reserves it.
+type IdempotencyStore interface {
+	// Begin reserves key for a request with the given fingerprint, blocking duplicates for
+	// lockTimeout, and returns the reservation with a fresh Token and reserved=true. When
+	// the key is already reserved or completed it returns the existing record and
+	// reserved=false. Expired keys and reservations older than lockTimeout are taken over.
+	Begin(ctx context.Context, key, fingerprint string, lockTimeout time.Duration) (record *IdempotencyRecord, reserved bool, err error)
+	// Complete stores the response of the reservation matching record's Key, Fingerprint and
+	// Token and keeps it for ttl. It returns a CONFLICT error when the reservation is no
+	// longer held, e.g. because it was taken over after lockTimeout.
+	Complete(ctx context.Context, record *IdempotencyRecord, ttl time.Duration) error
+	// Release drops reservation, if still held, so the request can be retried
+	Release(ctx context.Context, reservation *IdempotencyRecord) error
+}
+
+// errIdempotencyReservationLost is returned by Complete when the reservation was taken over
+func errIdempotencyReservationLost() error {
+	return errors.NewConflict("idempotency key reservation was lost")
+}
+
+// IdempotencyConfig configures the Idempotency middleware
+type IdempotencyConfig struct {
+	Store        IdempotencyStore            // Where keys and responses are kept (required)
+	TTL          time.Duration               // How long responses are replayed (default: 24h)
+	LockTimeout  time.Duration               // How long an unfinished request blocks its key (default: 1m)
+	Methods      []string                    // Methods the middleware applies to (default: POST, PATCH)
+	RequireKey   bool                        // Reject requests of those methods without a key
+	MaxBodyBytes int64                       // Larger request bodies are rejected (default: 1 MiB)
+	Scope        func(c *gin.Context) string // Namespaces keys per client (default: the X-User-ID header)
+}
+
+// Idempotency makes retried requests safe: the first request carrying an Idempotency-Key
+// runs normally and its response is stored; retries with the same key get the stored
+// response back (with Idempotent-Replayed: true) instead of running the handler again.
+//
+// Reusing a key for a different request (method, path or body) is rejected with
+// CONFLICT, as is a retry arriving while the first request is still running. 5xx
+// responses are not stored, so the request can be retried. Only the status, body and the
+// Content-Type, Location and ETag headers are replayed.
+//
+// Usage:
+//
+//	store := middleware.NewPostgresIdempotencyStore(db)
+//	router.Use(middleware.Idempotency(middleware.IdempotencyConfig{Store: store}))
+func Idempotency(cfg IdempotencyConfig) gin.HandlerFunc {
+	if cfg.Store == nil {
+		panic("middleware: Idempotency requires a Store")
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = DefaultIdempotencyTTL
+	}
+	if cfg.LockTimeout <= 0 {
+		cfg.LockTimeout = DefaultIdempotencyLockTimeout
+	}
+	if len(cfg.Methods) == 0 {
+		cfg.Methods = []string{http.MethodPost, http.MethodPatch}
+	}
+	if cfg.MaxBodyBytes <= 0 {
+		cfg.MaxBodyBytes = DefaultIdempotencyMaxBody
+	}
+	if cfg.Scope == nil {
+		cfg.Scope = func(c *gin.Context) string {
+			return strings.TrimSpace(c.GetHeader("X-User-ID"))
+		}
+	}
+	methods := make(map[string]bool, len(cfg.Methods))
+	for _, method := range cfg.Methods {
+		methods[strings.ToUpper(method)] = true
+	}
+
+	return func(c *gin.Context) {
+		if !methods[c.Request.Method] {
+			c.Next()
+			return
+		}
+
+		key := strings.TrimSpace(c.GetHeader(IdempotencyKeyHeader))
+		if key == "" {
+			if cfg.RequireKey {
+				response.Error(c, errors.NewMissingField(IdempotencyKeyHeader))
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+		if len(key) > MaxIdempotencyKeyLength {
+			response.Error(c, errors.NewValueTooLong(IdempotencyKeyHeader, MaxIdempotencyKeyLength))
+			c.Abort()
+			return
+		}
+
+		fingerprint, err := requestFingerprint(c, cfg.MaxBodyBytes)
+		if err != nil {
+			response.Error(c, err)
+			c.Abort()
+			return
+		}
+
+		requestLogger := logger.NewContextLogger(c.Request.Context(), "idempotency")
+		storeKey := key
+		if scope := cfg.Scope(c); scope != "" {
+			storeKey = scope + ":" + key
+		}
+
+		reservation, reserved, err := cfg.Store.Begin(c.Request.Context(), storeKey, fingerprint, cfg.LockTimeout)
+		if err != nil {
+			requestLogger.Error("Failed to reserve idempotency key", err, map[string]interface{}{"path": c.Request.URL.Path})
+			response.Error(c, err)
+			c.Abort()
+			return
+		}
+		if !reserved {
+			replay(c, reservation, fingerprint)
+			return
+		}
+
+		writer := &capturingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		completed := false
+		defer func() {
+			if completed {
+				return
+			}
+			// Handler panicked or failed: free the key so the client can retry
+			if err := cfg.Store.Release(context.WithoutCancel(c.Request.Context()), reservation); err != nil {
+				requestLogger.Error("Failed to release idempotency key", err, map[string]interface{}{"path": c.Request.URL.Path})
+			}
+		}()
+
+		c.Next()
+
+		status := writer.Status()
+		if status >= http.StatusInternalServerError {
+			return
+		}
+		record := &IdempotencyRecord{
+			Key:         storeKey,
+			Fingerprint: fingerprint,
+			Token:       reservation.Token,
+			Completed:   true,
+			StatusCode:  status,
+			Header:      replayHeader(writer.Header()),
+			Body:        writer.body.Bytes(),
+		}
+		if err := cfg.Store.Complete(context.WithoutCancel(c.Request.Context()), record, cfg.TTL); err != nil {
+			// The response is already sent; a retry will run the handler again
+			requestLogger.Error("Failed to store idempotent response", err, map[string]interface{}{"path": c.Request.URL.Path})
+			return
+		}
+		completed = true
+	}
+}
+
+// replay answers a request whose key is already known
+func replay(c *gin.Context, existing *IdempotencyRecord, fingerprint string) {
+	switch {
+	case existing.Fingerprint != fingerprint:
+		response.Error(c, errors.NewConflict("Idempotency-Key was already used for a different request"))
+	case !existing.Completed:
+		c.Header("Retry-After", "1")
+		response.Error(c, errors.NewConflict("a request with this Idempotency-Key is still in progress"))
+	default:
+		header := c.Writer.Header()
+		for name, values := range replayHeader(existing.Header) {
+			header[name] = values
+		}
+		header.Set(IdempotentReplayedHeader, "true")
+		c.Status(existing.StatusCode)
+		c.Writer.Write(existing.Body)
+	}
+	c.Abort()
+}
+
+// replayHeader copies the replayable headers of header
+func replayHeader(header http.Header) http.Header {
+	replayed := http.Header{}
+	for _, name := range idempotentReplayHeaders {
+		if values := header.Values(name); len(values) > 0 {
+			replayed[http.CanonicalHeaderKey(name)] = append([]string(nil), values...)
+		}
+	}
+	return replayed
+}
+
+// newIdempotencyToken returns a random reservation token
+func newIdempotencyToken() string {
+	var token [16]byte
+	rand.Read(token[:])
+	return hex.EncodeToString(token[:])
+}
+
+// requestFingerprint hashes the method, path and body of the request, restoring the
+// body for the handler
+func requestFingerprint(c *gin.Context, maxBodyBytes int64) (string, error) {
+	var body []byte
+	if c.Request.Body != nil {
+		var err error
+		body, err = io.ReadAll(io.LimitReader(c.Request.Body, maxBodyBytes+1))
+		if err != nil {
+			return "", errors.Wrap(err, errors.ErrCodeInvalidInput, "failed to read request body")
+		}
+		if int64(len(body)) > maxBodyBytes {
+			return "", errors.NewInvalidInput(fmt.Sprintf("request body exceeds %d bytes", maxBodyBytes))
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(c.Request.Method))
+	hash.Write([]byte{0})
+	hash.Write([]byte(c.Request.URL.RequestURI()))
+	hash.Write([]byte{0})
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// capturingWriter copies the response body while writing it
+type capturingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *capturingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *capturingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// MemoryIdempotencyStore keeps idempotency keys in process memory, for tests and
+// single-instance services
+type MemoryIdempotencyStore struct {
+	mu        sync.Mutex
+	entries   map[string]*memoryIdempotencyEntry
+	now       func() time.Time
+	lastPurge time.Time
+}
+
+// memoryIdempotencyEntry is a stored key with its expiry
+type memoryIdempotencyEntry struct {
+	record    IdempotencyRecord
+	expiresAt time.Time
+}
+
+// NewMemoryIdempotencyStore creates an empty in-memory store
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{entries: map[string]*memoryIdempotencyEntry{}, now: time.Now}
+}
+
+// Begin implements IdempotencyStore
+func (s *MemoryIdempotencyStore) Begin(ctx context.Context, key, fingerprint string, lockTimeout time.Duration) (*IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if entry, ok := s.entries[key]; ok && now.Before(entry.expiresAt) {
+		record := entry.record
+		record.Token = ""
+		return &record, false, nil
+	}
+	reservation := IdempotencyRecord{Key: key, Fingerprint: fingerprint, Token: newIdempotencyToken()}
+	s.entries[key] = &memoryIdempotencyEntry{record: reservation, expiresAt: now.Add(lockTimeout)}
+	s.purge(now)
+	return &reservation, true, nil
+}
+
+// Complete implements IdempotencyStore
+func (s *MemoryIdempotencyStore) Complete(ctx context.Context, record *IdempotencyRecord, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.holds(record) {
+		return errIdempotencyReservationLost()
+	}
+	stored := *record
+	stored.Completed = true
+	s.entries[record.Key] = &memoryIdempotencyEntry{record: stored, expiresAt: s.now().Add(ttl)}
+	return nil
+}
+
+// Release implements IdempotencyStore
+func (s *MemoryIdempotencyStore) Release(ctx context.Context, reservation *IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.holds(reservation) {
+		delete(s.entries, reservation.Key)
+	}
+	return nil
+}
+
+// holds reports whether reservation is still the pending reservation of its key. s.mu must be held.
+func (s *MemoryIdempotencyStore) holds(reservation *IdempotencyRecord) bool {
+	entry, ok := s.entries[reservation.Key]
+	return ok && !entry.record.Completed &&
+		entry.record.Token == reservation.Token &&
+		entry.record.Fingerprint == reservation.Fingerprint
+}
+
+// purge drops expired entries, at most once a minute
+func (s *MemoryIdempotencyStore) purge(now time.Time) {
+	if now.Sub(s.lastPurge) < time.Minute {
+		return
+	}
+	s.lastPurge = now
+	for key, entry := range s.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/medbai2/common-go/database"
+	"github.com/medbai2/common-go/errors"
+
+	"gorm.io/gorm"
+)
+
+// PostgresIdempotencyStore keeps idempotency keys in the idempotency_keys table created
+// by migrations/idempotency, so replays work across replicas and restarts
+type PostgresIdempotencyStore struct {
+	db *gorm.DB
+}
+
+// idempotencyRow is a row of idempotency_keys
+type idempotencyRow struct {
+	Key         string
+	Fingerprint string
+	Completed   bool
+	StatusCode  *int
+	Headers     []byte
+	Body        []byte
+}
+
+// NewPostgresIdempotencyStore creates a store on db
+func NewPostgresIdempotencyStore(db *gorm.DB) *PostgresIdempotencyStore {
+	return &PostgresIdempotencyStore{db: db}
+}
+
+// Begin implements IdempotencyStore. The reservation is a single upsert, so concurrent
+// requests with the same key cannot both reserve it.
+func (s *PostgresIdempotencyStore) Begin(ctx context.Context, key, fingerprint string, lockTimeout time.Duration) (*IdempotencyRecord, bool, error) {
+	if s.db == nil {
+		return nil, false, errors.New(errors.ErrCodeDatabaseError, "database is nil")
+	}
+
+	// A key deleted between the upsert and the read is simply reserved on the next pass
+	for attempt := 0; attempt < 2; attempt++ {
+		token := newIdempotencyToken()
+		var reserved []string
+		err := s.conn(ctx).Raw(`INSERT INTO idempotency_keys (key, fingerprint, token, completed, created_at, expires_at)
+VALUES (?, ?, ?, FALSE, NOW(), NOW() + ? * INTERVAL '1 millisecond')
+ON CONFLICT (key) DO UPDATE SET fingerprint = EXCLUDED.fingerprint, token = EXCLUDED.token, completed = FALSE,
+status_code = NULL, headers = NULL, body = NULL, created_at = EXCLUDED.created_at, expires_at = EXCLUDED.expires_at
+WHERE idempotency_keys.expires_at <= NOW()
+RETURNING key`, key, fingerprint, token, lockTimeout.Milliseconds()).Scan(&reserved).Error
+		if err != nil {
+			return nil, false, database.TranslateError(err)
+		}
+		if len(reserved) > 0 {
+			return &IdempotencyRecord{Key: key, Fingerprint: fingerprint, Token: token}, true, nil
+		}
+
+		var rows []idempotencyRow
+		err = s.conn(ctx).Raw(`SELECT key, fingerprint, completed, status_code, headers, body FROM idempotency_keys WHERE key = ?`, key).
+			Scan(&rows).Error
+		if err != nil {
+			return nil, false, database.TranslateError(err)
+		}
+		if len(rows) > 0 {
+			record, err := rows[0].record()
+			return record, false, err
+		}
+	}
+	return nil, false, errors.NewConflict("idempotency key changed concurrently")
+}
+
+// Complete implements IdempotencyStore. The update only matches the pending reservation
+// with record's fingerprint and token, so a request whose reservation was taken over cannot
+// overwrite the new owner's response.
+func (s *PostgresIdempotencyStore) Complete(ctx context.Context, record *IdempotencyRecord, ttl time.Duration) error {
+	if s.db == nil {
+		return errors.New(errors.ErrCodeDatabaseError, "database is nil")
+	}
+	headers, err := json.Marshal(record.Header)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrCodeInternal, "failed to encode response headers")
+	}
+	result := s.conn(ctx).Exec(`UPDATE idempotency_keys SET completed = TRUE, status_code = ?, headers = ?, body = ?,
+expires_at = NOW() + ? * INTERVAL '1 millisecond' WHERE key = ? AND fingerprint = ? AND token = ? AND NOT completed`,
+		record.StatusCode, headers, record.Body, ttl.Milliseconds(), record.Key, record.Fingerprint, record.Token)
+	if result.Error != nil {
+		return database.TranslateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errIdempotencyReservationLost()
+	}
+	return nil
+}
+
+// Release implements IdempotencyStore
+func (s *PostgresIdempotencyStore) Release(ctx context.Context, reservation *IdempotencyRecord) error {
+	if s.db == nil {
+		return errors.New(errors.ErrCodeDatabaseError, "database is nil")
+	}
+	err := s.conn(ctx).Exec(`DELETE FROM idempotency_keys WHERE key = ? AND token = ? AND NOT completed`,
+		reservation.Key, reservation.Token).Error
+	if err != nil {
+		return database.TranslateError(err)
+	}
+	return nil
+}
+
+// DeleteExpired removes expired keys and returns how many were deleted. Run it
+// periodically (e.g. as a jobs task) to keep the table small.
+func (s *PostgresIdempotencyStore) DeleteExpired(ctx context.Context) (int64, error) {
+	if s.db == nil {
+		return 0, errors.New(errors.ErrCodeDatabaseError, "database is nil")
+	}
+	result := s.conn(ctx).Exec(`DELETE FROM idempotency_keys WHERE expires_at <= NOW()`)
+	if result.Error != nil {
+		return 0, database.TranslateError(result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// conn returns the handle for ctx, pinned to the primary: a reservation must be read back
+// from where it was written, and a lagging replica would report stale or missing keys
+func (s *PostgresIdempotencyStore) conn(ctx context.Context) *gorm.DB {
+	return database.UsePrimary(s.db.WithContext(ctx))
+}
+
+// record converts a row into an IdempotencyRecord
+func (r idempotencyRow) record() (*IdempotencyRecord, error) {
+	record := &IdempotencyRecord{
+		Key:         r.Key,
+		Fingerprint: r.Fingerprint,
+		Completed:   r.Completed,
+		Body:        r.Body,
+	}
+	if r.StatusCode != nil {
+		record.StatusCode = *r.StatusCode
+	}
+	if len(r.Headers) > 0 {
+		header := http.Header{}
+		if err := json.Unmarshal(r.Headers, &header); err != nil {
+			return nil, errors.Wrap(err, errors.ErrCodeInternal, "failed to decode stored response headers")
+		}
+		record.Header = header
+	}
+	return record, nil
+}
@@ -0,0 +1,230 @@
+package middleware
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/medbai2/common-go/database"
+	"github.com/medbai2/common-go/errors"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+func newIdempotencyTestStore(t *testing.T) (*PostgresIdempotencyStore, sqlmock.Sqlmock) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { mockDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: mockDB}), &gorm.Config{
+		Logger:                 gormlogger.Discard,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+	})
+	require.NoError(t, err)
+	return NewPostgresIdempotencyStore(db), mock
+}
+
+const (
+	reserveIdempotencyKey = `INSERT INTO idempotency_keys (key, fingerprint, token, completed, created_at, expires_at)`
+	selectIdempotencyKey  = `SELECT key, fingerprint, completed, status_code, headers, body FROM idempotency_keys WHERE key = $1`
+)
+
+func TestPostgresIdempotencyStore_Begin(t *testing.T) {
+	testCases := []struct {
+		Name             string
+		Setup            func(mock sqlmock.Sqlmock)
+		ExpectedReserved bool
+		ExpectedRecord   *IdempotencyRecord
+		ExpectedCode     errors.ErrorCode
+	}{
+		{
+			Name: "reserves a new key",
+			Setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(reserveIdempotencyKey)).
+					WithArgs("user-1:key-1", "fp", sqlmock.AnyArg(), int64(60000)).
+					WillReturnRows(sqlmock.NewRows([]string{"key"}).AddRow("user-1:key-1"))
+			},
+			ExpectedReserved: true,
+		},
+		{
+			Name: "returns the completed response",
+			Setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(reserveIdempotencyKey)).
+					WillReturnRows(sqlmock.NewRows([]string{"key"}))
+				mock.ExpectQuery(regexp.QuoteMeta(selectIdempotencyKey)).
+					WithArgs("user-1:key-1").
+					WillReturnRows(sqlmock.NewRows([]string{"key", "fingerprint", "completed", "status_code", "headers", "body"}).
+						AddRow("user-1:key-1", "fp", true, 201, []byte(`{"Location":["/orders/1"]}`), []byte(`{"id":1}`)))
+			},
+			ExpectedRecord: &IdempotencyRecord{
+				Key:         "user-1:key-1",
+				Fingerprint: "fp",
+				Completed:   true,
+				StatusCode:  http.StatusCreated,
+				Header:      http.Header{"Location": {"/orders/1"}},
+				Body:        []byte(`{"id":1}`),
+			},
+		},
+		{
+			Name: "returns the in-flight reservation",
+			Setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(reserveIdempotencyKey)).
+					WillReturnRows(sqlmock.NewRows([]string{"key"}))
+				mock.ExpectQuery(regexp.QuoteMeta(selectIdempotencyKey)).
+					WillReturnRows(sqlmock.NewRows([]string{"key", "fingerprint", "completed", "status_code", "headers", "body"}).
+						AddRow("user-1:key-1", "fp", false, nil, nil, nil))
+			},
+			ExpectedRecord: &IdempotencyRecord{Key: "user-1:key-1", Fingerprint: "fp"},
+		},
+		{
+			Name: "key deleted between upsert and read is reserved on retry",
+			Setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(reserveIdempotencyKey)).
+					WillReturnRows(sqlmock.NewRows([]string{"key"}))
+				mock.ExpectQuery(regexp.QuoteMeta(selectIdempotencyKey)).
+					WillReturnRows(sqlmock.NewRows([]string{"key", "fingerprint", "completed", "status_code", "headers", "body"}))
+				mock.ExpectQuery(regexp.QuoteMeta(reserveIdempotencyKey)).
+					WillReturnRows(sqlmock.NewRows([]string{"key"}).AddRow("user-1:key-1"))
+			},
+			ExpectedReserved: true,
+		},
+		{
+			Name: "database error",
+			Setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(regexp.QuoteMeta(reserveIdempotencyKey)).
+					WillReturnError(stderrors.New("connection reset"))
+			},
+			ExpectedCode: errors.ErrCodeDatabaseError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			store, mock := newIdempotencyTestStore(t)
+			tc.Setup(mock)
+
+			record, reserved, err := store.Begin(context.Background(), "user-1:key-1", "fp", time.Minute)
+			if tc.ExpectedCode != "" {
+				require.Error(t, err)
+				assert.Equal(t, tc.ExpectedCode, errors.GetAppError(err).Code)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.ExpectedReserved, reserved)
+				if tc.ExpectedReserved {
+					require.NotNil(t, record)
+					assert.Equal(t, "user-1:key-1", record.Key)
+					assert.Equal(t, "fp", record.Fingerprint)
+					assert.Len(t, record.Token, 32)
+				} else {
+					assert.Equal(t, tc.ExpectedRecord, record)
+				}
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPostgresIdempotencyStore_BeginWithReplicas(t *testing.T) {
+	// The existing reservation is read back from the primary, never from a lagging replica
+	store, mock := newIdempotencyTestStore(t)
+	replicaDB, replicaMock, err := sqlmock.New()
+	require.NoError(t, err)
+	require.NoError(t, database.RegisterReplicas(store.db, []database.ReplicaPool{{Host: "replica-1", Port: 5432, DB: replicaDB}}, 0, time.Hour))
+	t.Cleanup(func() { database.Close(store.db) })
+	require.True(t, database.ReplicaStates(store.db)[0].Healthy)
+
+	mock.ExpectQuery(regexp.QuoteMeta(reserveIdempotencyKey)).
+		WillReturnRows(sqlmock.NewRows([]string{"key"}))
+	mock.ExpectQuery(regexp.QuoteMeta(selectIdempotencyKey)).
+		WithArgs("user-1:key-1").
+		WillReturnRows(sqlmock.NewRows([]string{"key", "fingerprint", "completed", "status_code", "headers", "body"}).
+			AddRow("user-1:key-1", "fp", false, nil, nil, nil))
+
+	record, reserved, err := store.Begin(context.Background(), "user-1:key-1", "fp", time.Minute)
+
+	require.NoError(t, err)
+	assert.False(t, reserved)
+	assert.Equal(t, &IdempotencyRecord{Key: "user-1:key-1", Fingerprint: "fp"}, record)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.NoError(t, replicaMock.ExpectationsWereMet())
+}
+
+func TestPostgresIdempotencyStore_Complete(t *testing.T) {
+	testCases := []struct {
+		Name         string
+		RowsAffected int64
+		ExpectedCode errors.ErrorCode
+	}{
+		{Name: "stores the response", RowsAffected: 1},
+		{Name: "reservation taken over", RowsAffected: 0, ExpectedCode: errors.ErrCodeConflict},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			store, mock := newIdempotencyTestStore(t)
+
+			mock.ExpectExec(regexp.QuoteMeta(`UPDATE idempotency_keys SET completed = TRUE, status_code = $1, headers = $2, body = $3,
+expires_at = NOW() + $4 * INTERVAL '1 millisecond' WHERE key = $5 AND fingerprint = $6 AND token = $7 AND NOT completed`)).
+				WithArgs(http.StatusCreated, []byte(`{"Location":["/orders/1"]}`), []byte(`{"id":1}`), int64(3600000), "user-1:key-1", "fp", "token-1").
+				WillReturnResult(sqlmock.NewResult(0, tc.RowsAffected))
+
+			err := store.Complete(context.Background(), &IdempotencyRecord{
+				Key:         "user-1:key-1",
+				Fingerprint: "fp",
+				Token:       "token-1",
+				StatusCode:  http.StatusCreated,
+				Header:      http.Header{"Location": {"/orders/1"}},
+				Body:        []byte(`{"id":1}`),
+			}, time.Hour)
+			if tc.ExpectedCode != "" {
+				require.Error(t, err)
+				assert.Equal(t, tc.ExpectedCode, errors.GetAppError(err).Code)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPostgresIdempotencyStore_Release(t *testing.T) {
+	store, mock := newIdempotencyTestStore(t)
+
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM idempotency_keys WHERE key = $1 AND token = $2 AND NOT completed`)).
+		WithArgs("user-1:key-1", "token-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, store.Release(context.Background(), &IdempotencyRecord{Key: "user-1:key-1", Token: "token-1"}))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresIdempotencyStore_DeleteExpired(t *testing.T) {
+	store, mock := newIdempotencyTestStore(t)
+
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM idempotency_keys WHERE expires_at <= NOW()`)).
+		WillReturnResult(sqlmock.NewResult(0, 7))
+
+	deleted, err := store.DeleteExpired(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), deleted)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresIdempotencyStore_NilDatabase(t *testing.T) {
+	store := NewPostgresIdempotencyStore(nil)
+	ctx := context.Background()
+
+	_, _, err := store.Begin(ctx, "key", "fp", time.Minute)
+	assert.Equal(t, errors.ErrCodeDatabaseError, errors.GetAppError(err).Code)
+	assert.Equal(t, errors.ErrCodeDatabaseError, errors.GetAppError(store.Complete(ctx, &IdempotencyRecord{}, time.Hour)).Code)
+	assert.Equal(t, errors.ErrCodeDatabaseError, errors.GetAppError(store.Release(ctx, &IdempotencyRecord{Key: "key"})).Code)
+}
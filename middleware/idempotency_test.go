@@ -0,0 +1,321 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/medbai2/common-go/errors"
+	"github.com/medbai2/common-go/testutils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// idempotencyTestRouter counts handler runs; the handler answers with the given status
+func idempotencyTestRouter(t *testing.T, cfg IdempotencyConfig, status int, block chan struct{}) (*gin.Engine, *atomic.Int32) {
+	hts := testutils.NewHTTPTestSuite(t)
+	calls := &atomic.Int32{}
+	hts.Router.Use(Idempotency(cfg))
+	handler := func(c *gin.Context) {
+		n := calls.Add(1)
+		if block != nil {
+			<-block
+		}
+		c.Header("Location", "/orders/1")
+		c.JSON(status, gin.H{"call": n})
+	}
+	hts.Router.POST("/orders", handler)
+	hts.Router.GET("/orders", handler)
+	return hts.Router, calls
+}
+
+func doIdempotentRequest(router *gin.Engine, method, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/orders", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	req.Header.Set("X-User-ID", "user-1")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotency(t *testing.T) {
+	testCases := []struct {
+		Name                string
+		Config              IdempotencyConfig
+		HandlerStatus       int
+		Method              string
+		FirstKey            string
+		FirstBody           string
+		SecondKey           string
+		SecondBody          string
+		ExpectedStatus      int
+		ExpectedCalls       int32
+		ExpectedReplayed    string
+		ExpectedBodyContain string
+	}{
+		{
+			Name:                "retry replays the stored response",
+			HandlerStatus:       http.StatusCreated,
+			Method:              http.MethodPost,
+			FirstKey:            "key-1",
+			FirstBody:           `{"total":10}`,
+			SecondKey:           "key-1",
+			SecondBody:          `{"total":10}`,
+			ExpectedStatus:      http.StatusCreated,
+			ExpectedCalls:       1,
+			ExpectedReplayed:    "true",
+			ExpectedBodyContain: `"call":1`,
+		},
+		{
+			Name:                "different body with the same key is a conflict",
+			HandlerStatus:       http.StatusCreated,
+			Method:              http.MethodPost,
+			FirstKey:            "key-1",
+			FirstBody:           `{"total":10}`,
+			SecondKey:           "key-1",
+			SecondBody:          `{"total":20}`,
+			ExpectedStatus:      http.StatusConflict,
+			ExpectedCalls:       1,
+			ExpectedBodyContain: "CONFLICT",
+		},
+		{
+			Name:                "different keys run twice",
+			HandlerStatus:       http.StatusCreated,
+			Method:              http.MethodPost,
+			FirstKey:            "key-1",
+			SecondKey:           "key-2",
+			ExpectedStatus:      http.StatusCreated,
+			ExpectedCalls:       2,
+			ExpectedBodyContain: `"call":2`,
+		},
+		{
+			Name:                "requests without a key are not deduplicated",
+			HandlerStatus:       http.StatusCreated,
+			Method:              http.MethodPost,
+			ExpectedStatus:      http.StatusCreated,
+			ExpectedCalls:       2,
+			ExpectedBodyContain: `"call":2`,
+		},
+		{
+			Name:                "missing key rejected when required",
+			Config:              IdempotencyConfig{RequireKey: true},
+			HandlerStatus:       http.StatusCreated,
+			Method:              http.MethodPost,
+			ExpectedStatus:      http.StatusBadRequest,
+			ExpectedCalls:       0,
+			ExpectedBodyContain: "MISSING_FIELD",
+		},
+		{
+			Name:                "server errors are not stored",
+			HandlerStatus:       http.StatusInternalServerError,
+			Method:              http.MethodPost,
+			FirstKey:            "key-1",
+			SecondKey:           "key-1",
+			ExpectedStatus:      http.StatusInternalServerError,
+			ExpectedCalls:       2,
+			ExpectedBodyContain: `"call":2`,
+		},
+		{
+			Name:                "client errors are stored",
+			HandlerStatus:       http.StatusUnprocessableEntity,
+			Method:              http.MethodPost,
+			FirstKey:            "key-1",
+			SecondKey:           "key-1",
+			ExpectedStatus:      http.StatusUnprocessableEntity,
+			ExpectedCalls:       1,
+			ExpectedReplayed:    "true",
+			ExpectedBodyContain: `"call":1`,
+		},
+		{
+			Name:                "other methods pass through",
+			HandlerStatus:       http.StatusOK,
+			Method:              http.MethodGet,
+			FirstKey:            "key-1",
+			SecondKey:           "key-1",
+			ExpectedStatus:      http.StatusOK,
+			ExpectedCalls:       2,
+			ExpectedBodyContain: `"call":2`,
+		},
+		{
+			Name:                "key too long",
+			HandlerStatus:       http.StatusCreated,
+			Method:              http.MethodPost,
+			FirstKey:            "key-1",
+			SecondKey:           strings.Repeat("k", MaxIdempotencyKeyLength+1),
+			ExpectedStatus:      http.StatusBadRequest,
+			ExpectedCalls:       1,
+			ExpectedBodyContain: "VALUE_TOO_LONG",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			cfg := tc.Config
+			cfg.Store = NewMemoryIdempotencyStore()
+			router, calls := idempotencyTestRouter(t, cfg, tc.HandlerStatus, nil)
+
+			doIdempotentRequest(router, tc.Method, tc.FirstKey, tc.FirstBody)
+			w := doIdempotentRequest(router, tc.Method, tc.SecondKey, tc.SecondBody)
+
+			assert.Equal(t, tc.ExpectedStatus, w.Code)
+			assert.Equal(t, tc.ExpectedCalls, calls.Load())
+			assert.Equal(t, tc.ExpectedReplayed, w.Header().Get(IdempotentReplayedHeader))
+			assert.Contains(t, w.Body.String(), tc.ExpectedBodyContain)
+		})
+	}
+}
+
+func TestIdempotency_ReplaysHeaders(t *testing.T) {
+	router, _ := idempotencyTestRouter(t, IdempotencyConfig{Store: NewMemoryIdempotencyStore()}, http.StatusCreated, nil)
+
+	first := doIdempotentRequest(router, http.MethodPost, "key-1", `{}`)
+	second := doIdempotentRequest(router, http.MethodPost, "key-1", `{}`)
+
+	assert.Equal(t, "/orders/1", second.Header().Get("Location"))
+	assert.Equal(t, first.Header().Get("Content-Type"), second.Header().Get("Content-Type"))
+	assert.Equal(t, first.Body.String(), second.Body.String())
+}
+
+func TestIdempotency_ReplayKeepsRequestHeaders(t *testing.T) {
+	hts := testutils.NewHTTPTestSuite(t)
+	hts.Router.Use(RequestID(), Idempotency(IdempotencyConfig{Store: NewMemoryIdempotencyStore()}))
+	hts.Router.POST("/orders", func(c *gin.Context) {
+		c.Header("ETag", `"v1"`)
+		c.Header("Set-Cookie", "session=abc")
+		c.JSON(http.StatusCreated, gin.H{"id": 1})
+	})
+
+	send := func(requestID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{}`))
+		req.Header.Set(IdempotencyKeyHeader, "key-1")
+		req.Header.Set(RequestIDHeader, requestID)
+		w := httptest.NewRecorder()
+		hts.Router.ServeHTTP(w, req)
+		return w
+	}
+	first := send("req-1")
+	second := send("req-2")
+
+	assert.Equal(t, "true", second.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, "req-2", second.Header().Get(RequestIDHeader))
+	assert.Equal(t, `"v1"`, second.Header().Get("ETag"))
+	assert.Equal(t, first.Header().Get("Content-Type"), second.Header().Get("Content-Type"))
+	assert.Empty(t, second.Header().Get("Set-Cookie"))
+}
+
+func TestIdempotency_ScopesKeysPerUser(t *testing.T) {
+	router, calls := idempotencyTestRouter(t, IdempotencyConfig{Store: NewMemoryIdempotencyStore()}, http.StatusCreated, nil)
+
+	doIdempotentRequest(router, http.MethodPost, "key-1", `{}`)
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{}`))
+	req.Header.Set(IdempotencyKeyHeader, "key-1")
+	req.Header.Set("X-User-ID", "user-2")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestIdempotency_ConcurrentDuplicate(t *testing.T) {
+	block := make(chan struct{})
+	router, calls := idempotencyTestRouter(t, IdempotencyConfig{Store: NewMemoryIdempotencyStore()}, http.StatusCreated, block)
+
+	var wg sync.WaitGroup
+	var first *httptest.ResponseRecorder
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		first = doIdempotentRequest(router, http.MethodPost, "key-1", `{}`)
+	}()
+	require.Eventually(t, func() bool { return calls.Load() == 1 }, time.Second, time.Millisecond)
+
+	duplicate := doIdempotentRequest(router, http.MethodPost, "key-1", `{}`)
+	assert.Equal(t, http.StatusConflict, duplicate.Code)
+	assert.Equal(t, "1", duplicate.Header().Get("Retry-After"))
+	assert.Contains(t, duplicate.Body.String(), "in progress")
+
+	close(block)
+	wg.Wait()
+	assert.Equal(t, http.StatusCreated, first.Code)
+
+	replayed := doIdempotentRequest(router, http.MethodPost, "key-1", `{}`)
+	assert.Equal(t, "true", replayed.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestIdempotency_ReleasesKeyOnPanic(t *testing.T) {
+	store := NewMemoryIdempotencyStore()
+	hts := testutils.NewHTTPTestSuite(t)
+	hts.Router.Use(gin.Recovery(), Idempotency(IdempotencyConfig{Store: store}))
+	hts.Router.POST("/orders", func(c *gin.Context) { panic("boom") })
+
+	w := doIdempotentRequest(hts.Router, http.MethodPost, "key-1", `{}`)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	_, reserved, err := store.Begin(context.Background(), "user-1:key-1", "fingerprint", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, reserved)
+}
+
+func TestIdempotency_BodyTooLarge(t *testing.T) {
+	router, calls := idempotencyTestRouter(t, IdempotencyConfig{Store: NewMemoryIdempotencyStore(), MaxBodyBytes: 4}, http.StatusCreated, nil)
+
+	w := doIdempotentRequest(router, http.MethodPost, "key-1", `{"total":10}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, int32(0), calls.Load())
+}
+
+func TestMemoryIdempotencyStore_Expiry(t *testing.T) {
+	store := NewMemoryIdempotencyStore()
+	now := time.Now()
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	abandoned, reserved, err := store.Begin(ctx, "key-1", "a", time.Minute)
+	require.NoError(t, err)
+	require.True(t, reserved)
+
+	// An abandoned reservation is taken over once the lock times out
+	now = now.Add(2 * time.Minute)
+	reservation, reserved, err := store.Begin(ctx, "key-1", "b", time.Minute)
+	require.NoError(t, err)
+	require.True(t, reserved)
+	assert.NotEqual(t, abandoned.Token, reservation.Token)
+
+	// The previous holder can no longer complete or release the key
+	err = store.Complete(ctx, &IdempotencyRecord{Key: "key-1", Fingerprint: "a", Token: abandoned.Token, StatusCode: http.StatusOK}, time.Hour)
+	assert.Equal(t, errors.ErrCodeConflict, errors.GetAppError(err).Code)
+	require.NoError(t, store.Release(ctx, abandoned))
+	_, reserved, _ = store.Begin(ctx, "key-1", "b", time.Minute)
+	assert.False(t, reserved)
+
+	completed := *reservation
+	completed.StatusCode = http.StatusCreated
+	require.NoError(t, store.Complete(ctx, &completed, time.Hour))
+	existing, reserved, err := store.Begin(ctx, "key-1", "b", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, reserved)
+	assert.True(t, existing.Completed)
+	assert.Equal(t, http.StatusCreated, existing.StatusCode)
+	assert.Empty(t, existing.Token)
+
+	// Release does not drop completed responses
+	require.NoError(t, store.Release(ctx, reservation))
+	_, reserved, _ = store.Begin(ctx, "key-1", "b", time.Minute)
+	assert.False(t, reserved)
+
+	now = now.Add(2 * time.Hour)
+	_, reserved, err = store.Begin(ctx, "key-1", "c", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, reserved)
+}
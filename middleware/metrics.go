@@ -1,7 +1,16 @@
 package middleware
 
 import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/medbai2/common-go/errors"
+	"github.com/medbai2/common-go/internal/metricsutil"
+	"github.com/medbai2/common-go/response"
+
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
@@ -17,6 +26,8 @@ import (
 //
 // The endpoint will be available at /metrics (or custom path) and can be scraped
 // by Prometheus when the Kubernetes service has prometheus.io/scrape annotation.
+// The endpoint is public on router; admin.NewServer serves metrics on a separate,
+// optionally authenticated port instead.
 func RegisterMetrics(router *gin.Engine) {
 	RegisterMetricsWithPath(router, "/metrics")
 }
@@ -27,15 +38,130 @@ func RegisterMetricsWithPath(router *gin.Engine, path string) {
 	router.GET(path, gin.WrapH(promhttp.Handler()))
 }
 
+// unmatchedRoute is the route label of requests that matched no route
+const unmatchedRoute = "unmatched"
 
+// MetricsConfig configures the Metrics middleware
+type MetricsConfig struct {
+	Registerer   prometheus.Registerer // default: prometheus.DefaultRegisterer
+	Buckets      []float64             // Latency histogram buckets in seconds (default: prometheus.DefBuckets)
+	ExcludePaths []string              // Route templates or paths not recorded (default: /metrics)
+}
 
+// httpMetrics holds the RED collectors
+type httpMetrics struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	inFlight *prometheus.GaugeVec
+}
 
+// Metrics records RED metrics for every request:
+//   - http_requests_total counter by method, route, status class and error code
+//   - http_request_duration_seconds histogram by method, route, status class and error code
+//   - http_requests_in_flight gauge by method and route
+//
+// The route label is the route template (c.FullPath(), e.g. "/orders/:id") or
+// "unmatched", never the raw path, so IDs in URLs cannot blow up cardinality. The code
+// label is the AppError code of error responses written with the response package (or
+// the last AppError added with c.Error), empty otherwise. Collectors already registered
+// on the Registerer are reused; any other registration failure panics.
+//
+// Usage:
+//
+//	router.Use(middleware.Metrics(middleware.MetricsConfig{ExcludePaths: []string{"/metrics", "/health"}}))
+//	middleware.RegisterMetrics(router)
+func Metrics(cfg MetricsConfig) gin.HandlerFunc {
+	if cfg.Registerer == nil {
+		cfg.Registerer = prometheus.DefaultRegisterer
+	}
+	if len(cfg.Buckets) == 0 {
+		cfg.Buckets = prometheus.DefBuckets
+	}
+	if cfg.ExcludePaths == nil {
+		cfg.ExcludePaths = []string{"/metrics"}
+	}
+	excluded := make(map[string]bool, len(cfg.ExcludePaths))
+	for _, path := range cfg.ExcludePaths {
+		excluded[path] = true
+	}
+
+	metrics, err := newHTTPMetrics(cfg)
+	if err != nil {
+		panic("middleware: failed to register HTTP metrics: " + err.Error())
+	}
+
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if excluded[route] || excluded[c.Request.URL.Path] {
+			c.Next()
+			return
+		}
+		if route == "" {
+			route = unmatchedRoute
+		}
+		method := methodLabel(c.Request.Method)
+
+		inFlight := metrics.inFlight.WithLabelValues(method, route)
+		inFlight.Inc()
+		defer inFlight.Dec()
+		start := time.Now()
+
+		c.Next()
+
+		status := c.Writer.Status()
+		labels := []string{method, route, strconv.Itoa(status/100) + "xx", errorCodeLabel(c)}
+		metrics.requests.WithLabelValues(labels...).Inc()
+		metrics.duration.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+	}
+}
 
+// newHTTPMetrics registers the HTTP collectors on cfg.Registerer
+func newHTTPMetrics(cfg MetricsConfig) (*httpMetrics, error) {
+	labels := []string{"method", "route", "status_class", "code"}
+	requests, err := metricsutil.Register(cfg.Registerer, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests by method, route, status class and error code.",
+	}, labels))
+	if err != nil {
+		return nil, err
+	}
+	duration, err := metricsutil.Register(cfg.Registerer, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency by method, route, status class and error code.",
+		Buckets: cfg.Buckets,
+	}, labels))
+	if err != nil {
+		return nil, err
+	}
+	inFlight, err := metricsutil.Register(cfg.Registerer, prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "http_requests_in_flight",
+		Help: "HTTP requests currently being served by method and route.",
+	}, []string{"method", "route"}))
+	if err != nil {
+		return nil, err
+	}
+	return &httpMetrics{requests: requests, duration: duration, inFlight: inFlight}, nil
+}
 
+// methodLabel maps non-standard methods to "OTHER" so clients cannot create label values
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	}
+	return "OTHER"
+}
 
-
-
-
-
-
-
+// errorCodeLabel returns the AppError code of the response, or "" for successful responses
+func errorCodeLabel(c *gin.Context) string {
+	if code := response.GetErrorCode(c); code != "" {
+		return code
+	}
+	for i := len(c.Errors) - 1; i >= 0; i-- {
+		if appErr := errors.GetAppError(c.Errors[i].Err); appErr != nil {
+			return string(appErr.Code)
+		}
+	}
+	return ""
+}
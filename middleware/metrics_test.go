@@ -3,10 +3,17 @@ package middleware
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/medbai2/common-go/errors"
+	"github.com/medbai2/common-go/response"
+
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRegisterMetrics(t *testing.T) {
@@ -49,15 +56,131 @@ func TestRegisterMetricsWithPathNotFound(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, w.Code)
 }
 
+func TestMetrics(t *testing.T) {
+	testCases := []struct {
+		Name           string
+		Config         MetricsConfig
+		Method         string
+		Path           string
+		ExpectedSeries string
+	}{
+		{
+			Name:           "success is labelled by route template",
+			Method:         http.MethodGet,
+			Path:           "/orders/42",
+			ExpectedSeries: `http_requests_total{code="",method="GET",route="/orders/:id",status_class="2xx"} 1`,
+		},
+		{
+			Name:           "error responses carry the AppError code",
+			Method:         http.MethodGet,
+			Path:           "/orders/missing",
+			ExpectedSeries: `http_requests_total{code="NOT_FOUND",method="GET",route="/orders/:id",status_class="4xx"} 1`,
+		},
+		{
+			Name:           "errors added with c.Error carry their code",
+			Method:         http.MethodPost,
+			Path:           "/orders",
+			ExpectedSeries: `http_requests_total{code="RATE_LIMIT_EXCEEDED",method="POST",route="/orders",status_class="4xx"} 1`,
+		},
+		{
+			Name:           "unmatched paths share one label",
+			Method:         http.MethodGet,
+			Path:           "/does/not/exist/123",
+			ExpectedSeries: `http_requests_total{code="",method="GET",route="unmatched",status_class="4xx"} 1`,
+		},
+		{
+			Name:           "non-standard methods are grouped",
+			Method:         "PURGE",
+			Path:           "/orders/42",
+			ExpectedSeries: `http_requests_total{code="",method="OTHER",route="unmatched",status_class="4xx"} 1`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			reg := prometheus.NewRegistry()
+			tc.Config.Registerer = reg
+			router := metricsTestRouter(tc.Config)
+
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tc.Method, tc.Path, nil))
+
+			expected := "# HELP http_requests_total HTTP requests by method, route, status class and error code.\n" +
+				"# TYPE http_requests_total counter\n" + tc.ExpectedSeries + "\n"
+			require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "http_requests_total"))
+
+			count, err := testutil.GatherAndCount(reg, "http_request_duration_seconds")
+			require.NoError(t, err)
+			assert.Equal(t, 1, count)
+		})
+	}
+}
 
+func TestMetrics_ExcludePaths(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	router := metricsTestRouter(MetricsConfig{Registerer: reg, ExcludePaths: []string{"/orders/:id", "/metrics"}})
 
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders/42", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/metrics", nil))
 
+	count, err := testutil.GatherAndCount(reg, "http_requests_total")
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+}
 
+func TestMetrics_BucketsAndInFlight(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Metrics(MetricsConfig{Registerer: reg, Buckets: []float64{0.1, 1}}))
+
+	inFlight := func(value string) string {
+		return "# HELP http_requests_in_flight HTTP requests currently being served by method and route.\n" +
+			"# TYPE http_requests_in_flight gauge\n" +
+			`http_requests_in_flight{method="GET",route="/slow"} ` + value + "\n"
+	}
+	var duringRequest error
+	router.GET("/slow", func(c *gin.Context) {
+		duringRequest = testutil.GatherAndCompare(reg, strings.NewReader(inFlight("1")), "http_requests_in_flight")
+		c.Status(http.StatusOK)
+	})
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/slow", nil))
+
+	assert.NoError(t, duringRequest)
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(inFlight("0")), "http_requests_in_flight"))
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() == "http_request_duration_seconds" {
+			assert.Len(t, family.GetMetric()[0].GetHistogram().GetBucket(), 2)
+		}
+	}
+}
 
+func TestMetrics_ReusesCollectors(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	assert.NotPanics(t, func() {
+		Metrics(MetricsConfig{Registerer: reg})
+		Metrics(MetricsConfig{Registerer: reg})
+	})
+}
 
-
-
-
-
-
-
+// metricsTestRouter serves /orders routes behind the Metrics middleware
+func metricsTestRouter(cfg MetricsConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Metrics(cfg))
+	router.GET("/orders/:id", func(c *gin.Context) {
+		if c.Param("id") == "missing" {
+			response.Error(c, errors.NewNotFound("order"))
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+	router.POST("/orders", func(c *gin.Context) {
+		c.Error(errors.NewRateLimitExceeded(""))
+		c.Status(http.StatusTooManyRequests)
+	})
+	RegisterMetrics(router)
+	return router
+}
@@ -9,19 +9,33 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// Logger returns a gin.HandlerFunc for logging requests
+// Logger returns a gin.HandlerFunc logging each request through the root logger (see
+// logger.Default) with service "http-request"
 func Logger() gin.HandlerFunc {
-	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
-		// Create a structured log entry
-		appLogger := logger.NewFromEnv("http-request")
+	return LoggerWith(nil)
+}
 
+// LoggerWith returns a gin.HandlerFunc logging each request with appLogger. A nil
+// appLogger behaves like Logger.
+//
+// Usage:
+//
+//	router.Use(middleware.LoggerWith(logger.Default().WithService("orders-api")))
+func LoggerWith(appLogger logger.Logger) gin.HandlerFunc {
+	if appLogger == nil {
+		appLogger = logger.Default().WithService("http-request")
+	}
+	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
 		// Extract request ID if available
 		requestID := ""
-		if id, exists := param.Keys["requestId"]; exists {
+		if id, exists := param.Keys[string(logger.RequestIDKey)]; exists {
 			if idStr, ok := id.(string); ok {
 				requestID = idStr
 			}
 		}
+		if requestID == "" && param.Request != nil {
+			requestID = logger.GetRequestID(param.Request.Context())
+		}
 
 		// Log the request
 		appLogger.Info("HTTP request completed", map[string]interface{}{
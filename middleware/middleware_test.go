@@ -3,15 +3,49 @@ package middleware
 import (
 	"net/http"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/medbai2/common-go/logger"
 	"github.com/medbai2/common-go/testutils"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+// capturedEntry is an Info entry captured by infoRecorder
+type capturedEntry struct {
+	Message string
+	Fields  map[string]interface{}
+}
+
+// infoRecorder is a logger.Logger capturing Info entries
+type infoRecorder struct {
+	mu      sync.Mutex
+	entries []capturedEntry
+}
+
+func (r *infoRecorder) Debug(msg string, fields ...map[string]interface{}) {}
+func (r *infoRecorder) Info(msg string, fields ...map[string]interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry := capturedEntry{Message: msg, Fields: map[string]interface{}{}}
+	for _, f := range fields {
+		for k, v := range f {
+			entry.Fields[k] = v
+		}
+	}
+	r.entries = append(r.entries, entry)
+}
+func (r *infoRecorder) Warn(msg string, fields ...map[string]interface{})             {}
+func (r *infoRecorder) Error(msg string, err error, fields ...map[string]interface{}) {}
+func (r *infoRecorder) Fatal(msg string, err error, fields ...map[string]interface{}) {}
+func (r *infoRecorder) WithFields(fields map[string]interface{}) logger.Logger        { return r }
+func (r *infoRecorder) WithRequestID(requestID string) logger.Logger                  { return r }
+func (r *infoRecorder) WithService(service string) logger.Logger                      { return r }
+
 // MiddlewareTestCase represents a middleware test case
 type MiddlewareTestCase struct {
 	Name             string
@@ -90,6 +124,28 @@ func TestLogger(t *testing.T) {
 	hts.AssertResponseContains("success")
 }
 
+func TestLoggerWith(t *testing.T) {
+	recorder := &infoRecorder{}
+	hts := testutils.NewHTTPTestSuite(t)
+	hts.Router.Use(RequestID())
+	hts.Router.Use(LoggerWith(recorder))
+	hts.Router.GET("/test", func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+
+	req := hts.SetupRequest(http.MethodGet, "/test")
+	req.Header.Set(RequestIDHeader, "req-1")
+	hts.ExecuteRequest(req)
+
+	require.Len(t, recorder.entries, 1)
+	entry := recorder.entries[0]
+	assert.Equal(t, "HTTP request completed", entry.Message)
+	assert.Equal(t, "req-1", entry.Fields["requestId"])
+	assert.Equal(t, http.MethodGet, entry.Fields["method"])
+	assert.Equal(t, "/test", entry.Fields["url"])
+	assert.Equal(t, http.StatusCreated, entry.Fields["statusCode"])
+}
+
 // Test CORS middleware
 func TestCORS(t *testing.T) {
 	testCases := []MiddlewareTestCase{
@@ -0,0 +1,388 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/medbai2/common-go/errors"
+	"github.com/medbai2/common-go/logger"
+	"github.com/medbai2/common-go/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitAlgorithm selects how requests are counted
+type RateLimitAlgorithm string
+
+const (
+	// TokenBucket allows bursts of up to Requests, refilled evenly over Window
+	TokenBucket RateLimitAlgorithm = "token_bucket"
+	// SlidingWindow allows Requests per rolling Window, weighting the previous window by
+	// how much of it still overlaps
+	SlidingWindow RateLimitAlgorithm = "sliding_window"
+)
+
+// Rate limit response headers
+const (
+	RateLimitLimitHeader     = "RateLimit-Limit"
+	RateLimitRemainingHeader = "RateLimit-Remaining"
+	RateLimitResetHeader     = "RateLimit-Reset"
+	RateLimitPolicyHeader    = "RateLimit-Policy"
+)
+
+// RateLimit is a limit of Requests per Window
+type RateLimit struct {
+	Requests  int
+	Window    time.Duration
+	Algorithm RateLimitAlgorithm // default: TokenBucket
+}
+
+// RateLimitResult is the outcome of counting one request
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration // Until the limit is fully available again
+	RetryAfter time.Duration // Until the next request can be allowed (when not Allowed)
+}
+
+// RateLimitStore counts requests per key. Implementations must apply Allow atomically
+// per key.
+type RateLimitStore interface {
+	Allow(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error)
+}
+
+// RateLimitKeyFunc identifies the client a request is counted against. An empty key
+// exempts the request.
+type RateLimitKeyFunc func(c *gin.Context) string
+
+// KeyByIP counts requests per client IP
+func KeyByIP() RateLimitKeyFunc {
+	return func(c *gin.Context) string {
+		return "ip:" + c.ClientIP()
+	}
+}
+
+// KeyByUser counts requests per authenticated user (X-User-ID header), falling back to
+// the client IP for anonymous requests
+func KeyByUser() RateLimitKeyFunc {
+	return func(c *gin.Context) string {
+		if userID := strings.TrimSpace(c.GetHeader("X-User-ID")); userID != "" {
+			return "user:" + userID
+		}
+		return "ip:" + c.ClientIP()
+	}
+}
+
+// KeyByAPIKey counts requests per API key read from header, falling back to the client IP.
+// The key is hashed with SHA-256 so stores and logs never hold the secret itself.
+func KeyByAPIKey(header string) RateLimitKeyFunc {
+	return func(c *gin.Context) string {
+		if apiKey := strings.TrimSpace(c.GetHeader(header)); apiKey != "" {
+			hash := sha256.Sum256([]byte(apiKey))
+			return "key:" + hex.EncodeToString(hash[:])
+		}
+		return "ip:" + c.ClientIP()
+	}
+}
+
+// KeyByRoute counts all requests to a route together, whoever sends them
+func KeyByRoute() RateLimitKeyFunc {
+	return func(c *gin.Context) string {
+		return "route:" + routeName(c)
+	}
+}
+
+// CombineKeys counts requests per combination of keys (e.g. user and route)
+func CombineKeys(keys ...RateLimitKeyFunc) RateLimitKeyFunc {
+	return func(c *gin.Context) string {
+		parts := make([]string, 0, len(keys))
+		for _, key := range keys {
+			part := key(c)
+			if part == "" {
+				return ""
+			}
+			parts = append(parts, part)
+		}
+		return strings.Join(parts, "|")
+	}
+}
+
+// RateLimitConfig configures the RateLimiter middleware
+type RateLimitConfig struct {
+	Limit      RateLimit            // Default limit (required)
+	Routes     map[string]RateLimit // Per-route limits keyed by "METHOD /path" as registered (e.g. "POST /orders/:id"), counted separately
+	Key        RateLimitKeyFunc     // default: KeyByIP()
+	Store      RateLimitStore       // default: NewMemoryRateLimitStore()
+	FailClosed bool                 // Reject requests when the store fails (default: let them through)
+	Skip       func(c *gin.Context) bool
+}
+
+// RateLimiter limits requests per client, answering RATE_LIMIT_EXCEEDED (429) with a
+// Retry-After header once the limit is reached. Every counted response carries the
+// RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset and RateLimit-Policy headers.
+//
+// Usage:
+//
+//	router.Use(middleware.RateLimiter(middleware.RateLimitConfig{
+//		Limit: middleware.RateLimit{Requests: 100, Window: time.Minute},
+//		Routes: map[string]middleware.RateLimit{
+//			"POST /login": {Requests: 5, Window: time.Minute, Algorithm: middleware.SlidingWindow},
+//		},
+//		Key:   middleware.KeyByUser(),
+//		Store: middleware.NewPostgresRateLimitStore(db), // shared by all replicas
+//	}))
+func RateLimiter(cfg RateLimitConfig) gin.HandlerFunc {
+	if err := cfg.Limit.validate(); err != nil {
+		panic("middleware: invalid RateLimiter limit: " + err.Error())
+	}
+	routes := make(map[string]RateLimit, len(cfg.Routes))
+	for route, limit := range cfg.Routes {
+		if err := limit.validate(); err != nil {
+			panic(fmt.Sprintf("middleware: invalid RateLimiter limit for %q: %s", route, err.Error()))
+		}
+		routes[normalizeRoute(route)] = limit
+	}
+	if cfg.Key == nil {
+		cfg.Key = KeyByIP()
+	}
+	if cfg.Store == nil {
+		cfg.Store = NewMemoryRateLimitStore()
+	}
+
+	return func(c *gin.Context) {
+		if cfg.Skip != nil && cfg.Skip(c) {
+			c.Next()
+			return
+		}
+		key := cfg.Key(c)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		limit := cfg.Limit
+		route := routeName(c)
+		if routeLimit, ok := routes[route]; ok {
+			limit = routeLimit
+			key += "|" + route
+		}
+
+		result, err := cfg.Store.Allow(c.Request.Context(), key, limit)
+		if err != nil {
+			requestLogger := logger.NewContextLogger(c.Request.Context(), "rate-limiter")
+			requestLogger.Error("Rate limit store failed", err, map[string]interface{}{
+				"path":       c.Request.URL.Path,
+				"failClosed": cfg.FailClosed,
+			})
+			if cfg.FailClosed {
+				response.Error(c, errors.NewServiceUnavailable("rate limiter"))
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+
+		header := c.Writer.Header()
+		header.Set(RateLimitLimitHeader, strconv.Itoa(result.Limit))
+		header.Set(RateLimitRemainingHeader, strconv.Itoa(result.Remaining))
+		header.Set(RateLimitResetHeader, strconv.Itoa(ceilSeconds(result.Reset)))
+		header.Set(RateLimitPolicyHeader, fmt.Sprintf("%d;w=%d", limit.Requests, ceilSeconds(limit.Window)))
+
+		if !result.Allowed {
+			header.Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			response.Error(c, errors.NewRateLimitExceeded(""))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// validate checks that a limit can be enforced
+func (l RateLimit) validate() error {
+	if l.Requests <= 0 {
+		return fmt.Errorf("requests must be positive")
+	}
+	if l.Window <= 0 {
+		return fmt.Errorf("window must be positive")
+	}
+	switch l.Algorithm {
+	case "", TokenBucket, SlidingWindow:
+		return nil
+	}
+	return fmt.Errorf("unknown algorithm %q", l.Algorithm)
+}
+
+// routeName returns "METHOD /registered/path" for the matched route, or the raw path
+// when no route matched
+func routeName(c *gin.Context) string {
+	path := c.FullPath()
+	if path == "" {
+		path = c.Request.URL.Path
+	}
+	return c.Request.Method + " " + path
+}
+
+// normalizeRoute upper-cases the method of a "METHOD /path" route
+func normalizeRoute(route string) string {
+	method, path, found := strings.Cut(strings.TrimSpace(route), " ")
+	if !found {
+		return route
+	}
+	return strings.ToUpper(method) + " " + strings.TrimSpace(path)
+}
+
+// ceilSeconds rounds d up to whole seconds
+func ceilSeconds(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	return int(math.Ceil(d.Seconds()))
+}
+
+// rateLimitState is the per-key counter shared by the stores. Zero times mean the key
+// has not been seen yet.
+type rateLimitState struct {
+	Tokens        float64
+	UpdatedAt     time.Time // Last token bucket refill
+	WindowStart   time.Time // Start of the current sliding window
+	CurrentCount  int
+	PreviousCount int
+}
+
+// allow counts one request at now under limit, updating the state
+func (s *rateLimitState) allow(limit RateLimit, now time.Time) RateLimitResult {
+	if limit.Algorithm == SlidingWindow {
+		return s.allowSlidingWindow(limit, now)
+	}
+	return s.allowTokenBucket(limit, now)
+}
+
+// allowTokenBucket takes a token from a bucket of limit.Requests tokens refilled at
+// limit.Requests per limit.Window
+func (s *rateLimitState) allowTokenBucket(limit RateLimit, now time.Time) RateLimitResult {
+	capacity := float64(limit.Requests)
+	perToken := limit.Window / time.Duration(limit.Requests)
+
+	if s.UpdatedAt.IsZero() {
+		s.Tokens = capacity
+	} else if elapsed := now.Sub(s.UpdatedAt); elapsed > 0 {
+		s.Tokens = math.Min(capacity, s.Tokens+float64(elapsed)/float64(perToken))
+	}
+	s.UpdatedAt = now
+
+	result := RateLimitResult{Limit: limit.Requests}
+	if s.Tokens >= 1 {
+		s.Tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - s.Tokens) * float64(perToken))
+	}
+	result.Remaining = int(math.Floor(s.Tokens))
+	result.Reset = time.Duration((capacity - s.Tokens) * float64(perToken))
+	return result
+}
+
+// allowSlidingWindow counts the request in the current fixed window and estimates the
+// rolling count as current + previous * (share of the previous window still in range)
+func (s *rateLimitState) allowSlidingWindow(limit RateLimit, now time.Time) RateLimitResult {
+	windowStart := now.Truncate(limit.Window)
+	switch {
+	case s.WindowStart.IsZero() || windowStart.Sub(s.WindowStart) >= 2*limit.Window:
+		s.PreviousCount, s.CurrentCount = 0, 0
+	case windowStart.After(s.WindowStart):
+		s.PreviousCount, s.CurrentCount = s.CurrentCount, 0
+	}
+	s.WindowStart = windowStart
+
+	elapsed := now.Sub(windowStart)
+	overlap := 1 - float64(elapsed)/float64(limit.Window)
+	estimate := float64(s.PreviousCount)*overlap + float64(s.CurrentCount)
+
+	result := RateLimitResult{Limit: limit.Requests, Reset: limit.Window - elapsed}
+	if s.PreviousCount > 0 {
+		// The previous window stops counting one window after the current one started
+		result.Reset = 2*limit.Window - elapsed
+	}
+	if estimate+1 <= float64(limit.Requests) {
+		s.CurrentCount++
+		result.Allowed = true
+		result.Remaining = int(math.Floor(float64(limit.Requests) - estimate - 1))
+		return result
+	}
+
+	// Wait until enough of the previous window has slid out, or for the next window
+	result.RetryAfter = limit.Window - elapsed
+	if s.PreviousCount > 0 && s.CurrentCount+1 <= limit.Requests {
+		needed := float64(limit.Requests-s.CurrentCount-1) / float64(s.PreviousCount)
+		wait := time.Duration((1-needed)*float64(limit.Window)) - elapsed
+		if wait > 0 && wait < result.RetryAfter {
+			result.RetryAfter = wait
+		}
+	}
+	return result
+}
+
+// expiresAt returns when an untouched state no longer affects any limit
+func (s *rateLimitState) expiresAt(limit RateLimit, now time.Time) time.Time {
+	return now.Add(2 * limit.Window)
+}
+
+// MemoryRateLimitStore counts requests in process memory. Each replica counts on its
+// own, so use NewPostgresRateLimitStore when limits must hold across replicas.
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	entries   map[string]*memoryRateLimitEntry
+	now       func() time.Time
+	lastPurge time.Time
+}
+
+// memoryRateLimitEntry is a counter with its expiry
+type memoryRateLimitEntry struct {
+	state     rateLimitState
+	expiresAt time.Time
+}
+
+// NewMemoryRateLimitStore creates an empty in-memory store
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{entries: map[string]*memoryRateLimitEntry{}, now: time.Now}
+}
+
+// Allow implements RateLimitStore
+func (s *MemoryRateLimitStore) Allow(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.purge(now)
+	entry, ok := s.entries[key]
+	if !ok {
+		entry = &memoryRateLimitEntry{}
+		s.entries[key] = entry
+	}
+	result := entry.state.allow(limit, now)
+	entry.expiresAt = entry.state.expiresAt(limit, now)
+	return result, nil
+}
+
+// purge drops expired counters, at most once a minute
+func (s *MemoryRateLimitStore) purge(now time.Time) {
+	if now.Sub(s.lastPurge) < time.Minute {
+		return
+	}
+	s.lastPurge = now
+	for key, entry := range s.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+}
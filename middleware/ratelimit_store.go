@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/medbai2/common-go/database"
+	"github.com/medbai2/common-go/errors"
+
+	"gorm.io/gorm"
+)
+
+// PostgresRateLimitStore keeps counters in the rate_limits table created by
+// migrations/ratelimit, so a limit holds across all replicas of a service
+type PostgresRateLimitStore struct {
+	db *gorm.DB
+}
+
+// rateLimitRow is a row of rate_limits
+type rateLimitRow struct {
+	Tokens        float64
+	UpdatedAt     *time.Time
+	WindowStart   *time.Time
+	CurrentCount  int
+	PreviousCount int
+	Now           time.Time
+}
+
+// NewPostgresRateLimitStore creates a store on db
+func NewPostgresRateLimitStore(db *gorm.DB) *PostgresRateLimitStore {
+	return &PostgresRateLimitStore{db: db}
+}
+
+// Allow implements RateLimitStore. The counter row is locked for the duration of the
+// update, and the database clock is used so replicas agree on time.
+func (s *PostgresRateLimitStore) Allow(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error) {
+	if s.db == nil {
+		return RateLimitResult{}, errors.New(errors.ErrCodeDatabaseError, "database is nil")
+	}
+
+	var result RateLimitResult
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`INSERT INTO rate_limits (key, expires_at) VALUES (?, NOW()) ON CONFLICT (key) DO NOTHING`, key).Error; err != nil {
+			return err
+		}
+
+		var rows []rateLimitRow
+		err := tx.Raw(`SELECT tokens, updated_at, window_start, current_count, previous_count, NOW() AS now
+FROM rate_limits WHERE key = ? FOR UPDATE`, key).Scan(&rows).Error
+		if err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return errors.NewConflict("rate limit counter changed concurrently")
+		}
+
+		row := rows[0]
+		state := rateLimitState{
+			Tokens:        row.Tokens,
+			CurrentCount:  row.CurrentCount,
+			PreviousCount: row.PreviousCount,
+		}
+		if row.UpdatedAt != nil {
+			state.UpdatedAt = *row.UpdatedAt
+		}
+		if row.WindowStart != nil {
+			state.WindowStart = *row.WindowStart
+		}
+		result = state.allow(limit, row.Now)
+
+		return tx.Exec(`UPDATE rate_limits SET tokens = ?, updated_at = ?, window_start = ?, current_count = ?, previous_count = ?, expires_at = ?
+WHERE key = ?`, state.Tokens, nullableTime(state.UpdatedAt), nullableTime(state.WindowStart),
+			state.CurrentCount, state.PreviousCount, state.expiresAt(limit, row.Now), key).Error
+	})
+	if err != nil {
+		return RateLimitResult{}, database.TranslateError(err)
+	}
+	return result, nil
+}
+
+// DeleteExpired removes counters that no longer affect any limit and returns how many
+// were deleted. Run it periodically (e.g. as a jobs task) to keep the table small.
+func (s *PostgresRateLimitStore) DeleteExpired(ctx context.Context) (int64, error) {
+	if s.db == nil {
+		return 0, errors.New(errors.ErrCodeDatabaseError, "database is nil")
+	}
+	result := s.db.WithContext(ctx).Exec(`DELETE FROM rate_limits WHERE expires_at <= NOW()`)
+	if result.Error != nil {
+		return 0, database.TranslateError(result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// nullableTime maps the zero time to NULL
+func nullableTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"context"
+	stderrors "errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/medbai2/common-go/errors"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+func newRateLimitTestStore(t *testing.T) (*PostgresRateLimitStore, sqlmock.Sqlmock) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { mockDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: mockDB}), &gorm.Config{
+		Logger:                 gormlogger.Discard,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+	})
+	require.NoError(t, err)
+	return NewPostgresRateLimitStore(db), mock
+}
+
+const (
+	insertRateLimit = `INSERT INTO rate_limits (key, expires_at) VALUES ($1, NOW()) ON CONFLICT (key) DO NOTHING`
+	selectRateLimit = `SELECT tokens, updated_at, window_start, current_count, previous_count, NOW() AS now`
+	updateRateLimit = `UPDATE rate_limits SET tokens = $1, updated_at = $2, window_start = $3, current_count = $4, previous_count = $5, expires_at = $6`
+)
+
+func TestPostgresRateLimitStore_Allow(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 30, 0, time.UTC)
+	columns := []string{"tokens", "updated_at", "window_start", "current_count", "previous_count", "now"}
+
+	testCases := []struct {
+		Name            string
+		Limit           RateLimit
+		Setup           func(mock sqlmock.Sqlmock)
+		ExpectedAllowed bool
+		ExpectedRemain  int
+		ExpectedCode    errors.ErrorCode
+	}{
+		{
+			Name:  "first request fills the token bucket",
+			Limit: RateLimit{Requests: 10, Window: time.Minute},
+			Setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(insertRateLimit)).WithArgs("ip:1").WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(regexp.QuoteMeta(selectRateLimit)).WithArgs("ip:1").
+					WillReturnRows(sqlmock.NewRows(columns).AddRow(0.0, nil, nil, 0, 0, now))
+				mock.ExpectExec(regexp.QuoteMeta(updateRateLimit)).
+					WithArgs(9.0, now, nil, 0, 0, now.Add(2*time.Minute), "ip:1").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			ExpectedAllowed: true,
+			ExpectedRemain:  9,
+		},
+		{
+			Name:  "full sliding window rejects the request",
+			Limit: RateLimit{Requests: 2, Window: time.Minute, Algorithm: SlidingWindow},
+			Setup: func(mock sqlmock.Sqlmock) {
+				windowStart := now.Truncate(time.Minute)
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(insertRateLimit)).WithArgs("ip:1").WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(regexp.QuoteMeta(selectRateLimit)).WithArgs("ip:1").
+					WillReturnRows(sqlmock.NewRows(columns).AddRow(0.0, nil, windowStart, 2, 0, now))
+				mock.ExpectExec(regexp.QuoteMeta(updateRateLimit)).
+					WithArgs(0.0, nil, windowStart, 2, 0, now.Add(2*time.Minute), "ip:1").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			ExpectedAllowed: false,
+			ExpectedRemain:  0,
+		},
+		{
+			Name:  "database error is translated",
+			Limit: RateLimit{Requests: 10, Window: time.Minute},
+			Setup: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(regexp.QuoteMeta(insertRateLimit)).WithArgs("ip:1").WillReturnError(stderrors.New("connection refused"))
+				mock.ExpectRollback()
+			},
+			ExpectedCode: errors.ErrCodeDatabaseError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			store, mock := newRateLimitTestStore(t)
+			tc.Setup(mock)
+
+			result, err := store.Allow(context.Background(), "ip:1", tc.Limit)
+			if tc.ExpectedCode != "" {
+				require.Error(t, err)
+				assert.Equal(t, tc.ExpectedCode, errors.GetAppError(err).Code)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.ExpectedAllowed, result.Allowed)
+				assert.Equal(t, tc.ExpectedRemain, result.Remaining)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPostgresRateLimitStore_DeleteExpired(t *testing.T) {
+	store, mock := newRateLimitTestStore(t)
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM rate_limits WHERE expires_at <= NOW()`)).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	deleted, err := store.DeleteExpired(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), deleted)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRateLimitStore_NilDB(t *testing.T) {
+	store := NewPostgresRateLimitStore(nil)
+
+	_, err := store.Allow(context.Background(), "ip:1", RateLimit{Requests: 1, Window: time.Minute})
+	assert.Equal(t, errors.ErrCodeDatabaseError, errors.GetAppError(err).Code)
+
+	_, err = store.DeleteExpired(context.Background())
+	assert.Equal(t, errors.ErrCodeDatabaseError, errors.GetAppError(err).Code)
+}
@@ -0,0 +1,291 @@
+package middleware
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/medbai2/common-go/testutils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingRateLimitStore always fails
+type failingRateLimitStore struct{}
+
+func (failingRateLimitStore) Allow(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error) {
+	return RateLimitResult{}, stderrors.New("store down")
+}
+
+func rateLimitTestRouter(t *testing.T, cfg RateLimitConfig) *gin.Engine {
+	hts := testutils.NewHTTPTestSuite(t)
+	hts.Router.Use(RateLimiter(cfg))
+	handler := func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	}
+	hts.Router.GET("/orders", handler)
+	hts.Router.POST("/login", handler)
+	return hts.Router
+}
+
+func doRateLimitedRequest(router *gin.Engine, method, path, userID string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if userID != "" {
+		req.Header.Set("X-User-ID", userID)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimiter(t *testing.T) {
+	type request struct {
+		Method string
+		Path   string
+		UserID string
+	}
+	testCases := []struct {
+		Name             string
+		Config           RateLimitConfig
+		Requests         []request
+		ExpectedStatuses []int
+	}{
+		{
+			Name:   "token bucket rejects requests beyond the burst",
+			Config: RateLimitConfig{Limit: RateLimit{Requests: 2, Window: time.Minute}},
+			Requests: []request{
+				{Method: http.MethodGet, Path: "/orders"},
+				{Method: http.MethodGet, Path: "/orders"},
+				{Method: http.MethodGet, Path: "/orders"},
+			},
+			ExpectedStatuses: []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+		},
+		{
+			Name:   "sliding window rejects requests beyond the limit",
+			Config: RateLimitConfig{Limit: RateLimit{Requests: 1, Window: time.Hour, Algorithm: SlidingWindow}},
+			Requests: []request{
+				{Method: http.MethodGet, Path: "/orders"},
+				{Method: http.MethodGet, Path: "/orders"},
+			},
+			ExpectedStatuses: []int{http.StatusOK, http.StatusTooManyRequests},
+		},
+		{
+			Name:   "users are counted separately",
+			Config: RateLimitConfig{Limit: RateLimit{Requests: 1, Window: time.Minute}, Key: KeyByUser()},
+			Requests: []request{
+				{Method: http.MethodGet, Path: "/orders", UserID: "user-1"},
+				{Method: http.MethodGet, Path: "/orders", UserID: "user-2"},
+				{Method: http.MethodGet, Path: "/orders", UserID: "user-1"},
+			},
+			ExpectedStatuses: []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+		},
+		{
+			Name: "route limits are counted separately from the default limit",
+			Config: RateLimitConfig{
+				Limit:  RateLimit{Requests: 2, Window: time.Minute},
+				Routes: map[string]RateLimit{"post /login": {Requests: 1, Window: time.Minute}},
+			},
+			Requests: []request{
+				{Method: http.MethodPost, Path: "/login"},
+				{Method: http.MethodPost, Path: "/login"},
+				{Method: http.MethodGet, Path: "/orders"},
+				{Method: http.MethodGet, Path: "/orders"},
+			},
+			ExpectedStatuses: []int{http.StatusOK, http.StatusTooManyRequests, http.StatusOK, http.StatusOK},
+		},
+		{
+			Name: "skipped requests are not counted",
+			Config: RateLimitConfig{
+				Limit: RateLimit{Requests: 1, Window: time.Minute},
+				Skip:  func(c *gin.Context) bool { return c.Request.Method == http.MethodGet },
+			},
+			Requests: []request{
+				{Method: http.MethodGet, Path: "/orders"},
+				{Method: http.MethodGet, Path: "/orders"},
+				{Method: http.MethodPost, Path: "/login"},
+			},
+			ExpectedStatuses: []int{http.StatusOK, http.StatusOK, http.StatusOK},
+		},
+		{
+			Name:   "store failure lets requests through by default",
+			Config: RateLimitConfig{Limit: RateLimit{Requests: 1, Window: time.Minute}, Store: failingRateLimitStore{}},
+			Requests: []request{
+				{Method: http.MethodGet, Path: "/orders"},
+			},
+			ExpectedStatuses: []int{http.StatusOK},
+		},
+		{
+			Name: "store failure rejects requests when failing closed",
+			Config: RateLimitConfig{
+				Limit:      RateLimit{Requests: 1, Window: time.Minute},
+				Store:      failingRateLimitStore{},
+				FailClosed: true,
+			},
+			Requests: []request{
+				{Method: http.MethodGet, Path: "/orders"},
+			},
+			ExpectedStatuses: []int{http.StatusServiceUnavailable},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			router := rateLimitTestRouter(t, tc.Config)
+			for i, r := range tc.Requests {
+				w := doRateLimitedRequest(router, r.Method, r.Path, r.UserID)
+				assert.Equal(t, tc.ExpectedStatuses[i], w.Code, "request %d", i)
+			}
+		})
+	}
+}
+
+func TestRateLimiter_Headers(t *testing.T) {
+	router := rateLimitTestRouter(t, RateLimitConfig{Limit: RateLimit{Requests: 2, Window: time.Minute}})
+
+	w := doRateLimitedRequest(router, http.MethodGet, "/orders", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get(RateLimitLimitHeader))
+	assert.Equal(t, "1", w.Header().Get(RateLimitRemainingHeader))
+	assert.Equal(t, "30", w.Header().Get(RateLimitResetHeader))
+	assert.Equal(t, "2;w=60", w.Header().Get(RateLimitPolicyHeader))
+	assert.Empty(t, w.Header().Get("Retry-After"))
+
+	doRateLimitedRequest(router, http.MethodGet, "/orders", "")
+	w = doRateLimitedRequest(router, http.MethodGet, "/orders", "")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "0", w.Header().Get(RateLimitRemainingHeader))
+	assert.Equal(t, "30", w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), "RATE_LIMIT_EXCEEDED")
+}
+
+func TestRateLimiter_InvalidLimit(t *testing.T) {
+	testCases := []struct {
+		Name   string
+		Config RateLimitConfig
+	}{
+		{Name: "missing requests", Config: RateLimitConfig{Limit: RateLimit{Window: time.Minute}}},
+		{Name: "missing window", Config: RateLimitConfig{Limit: RateLimit{Requests: 1}}},
+		{Name: "unknown algorithm", Config: RateLimitConfig{Limit: RateLimit{Requests: 1, Window: time.Minute, Algorithm: "leaky"}}},
+		{
+			Name: "invalid route limit",
+			Config: RateLimitConfig{
+				Limit:  RateLimit{Requests: 1, Window: time.Minute},
+				Routes: map[string]RateLimit{"POST /login": {}},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert.Panics(t, func() { RateLimiter(tc.Config) })
+		})
+	}
+}
+
+func TestRateLimitKeys(t *testing.T) {
+	testCases := []struct {
+		Name        string
+		Key         RateLimitKeyFunc
+		Headers     map[string]string
+		ExpectedKey string
+	}{
+		{Name: "ip", Key: KeyByIP(), ExpectedKey: "ip:192.0.2.1"},
+		{Name: "user", Key: KeyByUser(), Headers: map[string]string{"X-User-ID": "42"}, ExpectedKey: "user:42"},
+		{Name: "anonymous user falls back to ip", Key: KeyByUser(), ExpectedKey: "ip:192.0.2.1"},
+		{Name: "api key", Key: KeyByAPIKey("X-API-Key"), Headers: map[string]string{"X-API-Key": "abc"}, ExpectedKey: "key:ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{Name: "missing api key falls back to ip", Key: KeyByAPIKey("X-API-Key"), ExpectedKey: "ip:192.0.2.1"},
+		{Name: "route", Key: KeyByRoute(), ExpectedKey: "route:GET /orders"},
+		{
+			Name:        "combined",
+			Key:         CombineKeys(KeyByUser(), KeyByRoute()),
+			Headers:     map[string]string{"X-User-ID": "42"},
+			ExpectedKey: "user:42|route:GET /orders",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/orders", nil)
+			for name, value := range tc.Headers {
+				c.Request.Header.Set(name, value)
+			}
+			assert.Equal(t, tc.ExpectedKey, tc.Key(c))
+		})
+	}
+}
+
+func TestRateLimitState_TokenBucket(t *testing.T) {
+	limit := RateLimit{Requests: 2, Window: 2 * time.Second}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	state := rateLimitState{}
+
+	assert.True(t, state.allow(limit, start).Allowed)
+	assert.True(t, state.allow(limit, start).Allowed)
+
+	result := state.allow(limit, start)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, time.Second, result.RetryAfter)
+	assert.Equal(t, 2*time.Second, result.Reset)
+
+	// One token refills per second, never beyond the burst
+	result = state.allow(limit, start.Add(time.Second))
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+
+	result = state.allow(limit, start.Add(time.Hour))
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 1, result.Remaining)
+}
+
+func TestRateLimitState_SlidingWindow(t *testing.T) {
+	limit := RateLimit{Requests: 4, Window: time.Minute, Algorithm: SlidingWindow}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	state := rateLimitState{}
+
+	for i := 0; i < 4; i++ {
+		require.True(t, state.allow(limit, start).Allowed)
+	}
+	result := state.allow(limit, start.Add(30*time.Second))
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 30*time.Second, result.RetryAfter)
+
+	// Halfway through the next window, half of the previous window still counts
+	result = state.allow(limit, start.Add(90*time.Second))
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 1, result.Remaining)
+	assert.Equal(t, 30*time.Second+time.Minute, result.Reset)
+
+	assert.True(t, state.allow(limit, start.Add(90*time.Second)).Allowed)
+	result = state.allow(limit, start.Add(90*time.Second))
+	assert.False(t, result.Allowed)
+	// One more request fits once three quarters of the previous window have slid out
+	assert.Equal(t, 15*time.Second, result.RetryAfter)
+
+	// Two windows later nothing counts any more
+	result = state.allow(limit, start.Add(3*time.Minute))
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 3, result.Remaining)
+}
+
+func TestMemoryRateLimitStore_Purge(t *testing.T) {
+	store := NewMemoryRateLimitStore()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+	limit := RateLimit{Requests: 1, Window: time.Second}
+
+	_, err := store.Allow(context.Background(), "ip:1", limit)
+	require.NoError(t, err)
+	require.Len(t, store.entries, 1)
+
+	now = now.Add(2 * time.Minute)
+	_, err = store.Allow(context.Background(), "ip:2", limit)
+	require.NoError(t, err)
+	assert.Len(t, store.entries, 1)
+	assert.Contains(t, store.entries, "ip:2")
+}
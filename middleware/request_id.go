@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/medbai2/common-go/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader carries the request ID in requests and responses
+const RequestIDHeader = "X-Request-ID"
+
+// MaxRequestIDLength is the longest inbound request ID accepted
+const MaxRequestIDLength = 128
+
+// RequestID assigns every request an ID: a valid inbound X-Request-ID is kept (so IDs
+// follow a request across services), anything else is replaced by a new UUID. The ID is
+// echoed in the X-Request-ID response header and stored under logger.RequestIDKey in
+// both the gin context and the request context, where response.*, middleware.Logger,
+// logger.NewContextLogger and the database query logger pick it up.
+//
+// Register it first so every later middleware sees the ID:
+//
+//	router.Use(middleware.RequestID())
+//	router.Use(middleware.Logger())
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !ValidRequestID(requestID) {
+			requestID = NewRequestID()
+		}
+
+		c.Set(string(logger.RequestIDKey), requestID)
+		c.Request = c.Request.WithContext(logger.WithRequestID(c.Request.Context(), requestID))
+		c.Header(RequestIDHeader, requestID)
+		c.Next()
+	}
+}
+
+// ValidRequestID reports whether an inbound request ID can be trusted in logs and
+// headers: 1 to MaxRequestIDLength characters of letters, digits, '-', '_', '.' or ':'
+func ValidRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > MaxRequestIDLength {
+		return false
+	}
+	for _, r := range requestID {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// NewRequestID returns a random (version 4) UUID
+func NewRequestID() string {
+	var id [16]byte
+	rand.Read(id[:])
+	id[6] = id[6]&0x0f | 0x40
+	id[8] = id[8]&0x3f | 0x80
+
+	buf := make([]byte, 36)
+	hex.Encode(buf[0:8], id[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], id[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], id[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], id[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], id[10:])
+	return string(buf)
+}
@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/medbai2/common-go/logger"
+	"github.com/medbai2/common-go/response"
+	"github.com/medbai2/common-go/testutils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestRequestID(t *testing.T) {
+	testCases := []struct {
+		Name         string
+		Inbound      string
+		ExpectedKept bool
+	}{
+		{Name: "generates an ID when none is sent"},
+		{Name: "keeps a valid inbound ID", Inbound: "trace-123:abc.DEF_9", ExpectedKept: true},
+		{Name: "replaces an ID with invalid characters", Inbound: "bad id\n"},
+		{Name: "replaces an overlong ID", Inbound: strings.Repeat("a", MaxRequestIDLength+1)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			hts := testutils.NewHTTPTestSuite(t)
+			hts.Router.Use(RequestID())
+
+			var ginID, ctxID string
+			hts.Router.GET("/test", func(c *gin.Context) {
+				ginID = c.GetString(string(logger.RequestIDKey))
+				ctxID = logger.GetRequestID(c.Request.Context())
+				response.Success(c, nil)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			if tc.Inbound != "" {
+				req.Header.Set(RequestIDHeader, tc.Inbound)
+			}
+			w := httptest.NewRecorder()
+			hts.Router.ServeHTTP(w, req)
+
+			headerID := w.Header().Get(RequestIDHeader)
+			if tc.ExpectedKept {
+				assert.Equal(t, tc.Inbound, headerID)
+			} else {
+				assert.Regexp(t, uuidPattern, headerID)
+			}
+			assert.Equal(t, headerID, ginID)
+			assert.Equal(t, headerID, ctxID)
+
+			var body response.APIResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, headerID, body.RequestID)
+		})
+	}
+}
+
+func TestValidRequestID(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		ID       string
+		Expected bool
+	}{
+		{Name: "uuid", ID: "0b6f0d4e-5c0e-4c1a-9a57-2f6f1f3c9d10", Expected: true},
+		{Name: "trace style", ID: "00-abc.def_1:2", Expected: true},
+		{Name: "max length", ID: strings.Repeat("a", MaxRequestIDLength), Expected: true},
+		{Name: "empty", ID: "", Expected: false},
+		{Name: "too long", ID: strings.Repeat("a", MaxRequestIDLength+1), Expected: false},
+		{Name: "space", ID: "a b", Expected: false},
+		{Name: "newline", ID: "a\nb", Expected: false},
+		{Name: "non ascii", ID: "é", Expected: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert.Equal(t, tc.Expected, ValidRequestID(tc.ID))
+		})
+	}
+}
+
+func TestNewRequestID(t *testing.T) {
+	first, second := NewRequestID(), NewRequestID()
+	assert.Regexp(t, uuidPattern, first)
+	assert.NotEqual(t, first, second)
+}
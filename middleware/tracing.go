@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/medbai2/common-go/logger"
+	"github.com/medbai2/common-go/tracing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracingConfig configures the Tracing middleware
+type TracingConfig struct {
+	TracerProvider trace.TracerProvider          // default: the global provider (see tracing.Init)
+	Propagator     propagation.TextMapPropagator // default: the global propagator
+	Skip           func(c *gin.Context) bool     // e.g. health checks
+}
+
+// Tracing starts a server span per request, continuing the caller's trace when the
+// request carries traceparent/tracestate headers. The span is named "METHOD /route" and
+// records the route, status code, user (X-User-ID) and request ID; errors added with
+// c.Error are recorded as events and 5xx responses mark it as failed.
+//
+// The span travels in the request context, so database queries (database.RegisterTracing),
+// outbound calls (tracing.NewTransport) and context loggers made with
+// c.Request.Context() join the same trace.
+//
+// Usage:
+//
+//	router.Use(middleware.RequestID())
+//	router.Use(middleware.Tracing(middleware.TracingConfig{}))
+func Tracing(cfg TracingConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if cfg.Skip != nil && cfg.Skip(c) {
+			c.Next()
+			return
+		}
+		propagator := cfg.Propagator
+		if propagator == nil {
+			propagator = otel.GetTextMapPropagator()
+		}
+
+		ctx := propagator.Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+		route := c.FullPath()
+		name := c.Request.Method
+		if route != "" {
+			name += " " + route
+		}
+		attributes := []attribute.KeyValue{
+			semconv.HTTPRequestMethodKey.String(c.Request.Method),
+			semconv.URLPath(c.Request.URL.Path),
+			semconv.ClientAddress(c.ClientIP()),
+			semconv.UserAgentOriginal(c.Request.UserAgent()),
+		}
+		if route != "" {
+			attributes = append(attributes, semconv.HTTPRoute(route))
+		}
+		if requestID := logger.GetRequestID(ctx); requestID != "" {
+			attributes = append(attributes, attribute.String("request.id", requestID))
+		}
+
+		ctx, span := tracing.Tracer(cfg.TracerProvider).Start(ctx, name,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(attributes...),
+		)
+		defer span.End()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if userID := strings.TrimSpace(c.GetHeader("X-User-ID")); userID != "" {
+			span.SetAttributes(semconv.UserID(userID))
+		}
+		for _, err := range c.Errors {
+			span.RecordError(err.Err)
+		}
+		// Client errors (4xx) are the caller's failure, not the server's
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package middleware
+
+import (
+	stderrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/medbai2/common-go/logger"
+	"github.com/medbai2/common-go/testutils"
+	"github.com/medbai2/common-go/tracing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing(t *testing.T) {
+	testCases := []struct {
+		Name            string
+		Path            string
+		Traceparent     string
+		UserID          string
+		HandlerStatus   int
+		HandlerErr      error
+		ExpectedName    string
+		ExpectedStatus  codes.Code
+		ExpectedTraceID string
+		ExpectedEvents  int
+	}{
+		{
+			Name:           "starts a new trace",
+			Path:           "/orders/42",
+			HandlerStatus:  http.StatusOK,
+			ExpectedName:   "GET /orders/:id",
+			ExpectedStatus: codes.Unset,
+		},
+		{
+			Name:            "continues the caller's trace",
+			Path:            "/orders/42",
+			Traceparent:     "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			UserID:          "user-1",
+			HandlerStatus:   http.StatusOK,
+			ExpectedName:    "GET /orders/:id",
+			ExpectedStatus:  codes.Unset,
+			ExpectedTraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+		},
+		{
+			Name:           "client errors do not fail the span",
+			Path:           "/orders/42",
+			HandlerStatus:  http.StatusNotFound,
+			HandlerErr:     stderrors.New("order not found"),
+			ExpectedName:   "GET /orders/:id",
+			ExpectedStatus: codes.Unset,
+			ExpectedEvents: 1,
+		},
+		{
+			Name:           "server errors fail the span",
+			Path:           "/orders/42",
+			HandlerStatus:  http.StatusInternalServerError,
+			ExpectedName:   "GET /orders/:id",
+			ExpectedStatus: codes.Error,
+		},
+		{
+			Name:           "unmatched routes are named by method",
+			Path:           "/missing",
+			HandlerStatus:  http.StatusNotFound,
+			ExpectedName:   "GET",
+			ExpectedStatus: codes.Unset,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			exporter := tracetest.NewInMemoryExporter()
+			tp, err := tracing.NewTracerProvider(tracing.Config{ServiceName: "test", Exporter: exporter, Synchronous: true})
+			require.NoError(t, err)
+
+			hts := testutils.NewHTTPTestSuite(t)
+			hts.Router.Use(RequestID())
+			hts.Router.Use(Tracing(TracingConfig{TracerProvider: tp, Propagator: tracing.Propagator()}))
+
+			var handlerSpan trace.SpanContext
+			hts.Router.GET("/orders/:id", func(c *gin.Context) {
+				handlerSpan = trace.SpanContextFromContext(c.Request.Context())
+				if tc.HandlerErr != nil {
+					c.Error(tc.HandlerErr)
+				}
+				c.Status(tc.HandlerStatus)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, tc.Path, nil)
+			req.Header.Set(RequestIDHeader, "req-1")
+			if tc.Traceparent != "" {
+				req.Header.Set("traceparent", tc.Traceparent)
+			}
+			if tc.UserID != "" {
+				req.Header.Set("X-User-ID", tc.UserID)
+			}
+			hts.Router.ServeHTTP(httptest.NewRecorder(), req)
+
+			spans := exporter.GetSpans()
+			require.Len(t, spans, 1)
+			span := spans[0]
+			assert.Equal(t, tc.ExpectedName, span.Name)
+			assert.Equal(t, trace.SpanKindServer, span.SpanKind)
+			assert.Equal(t, tc.ExpectedStatus, span.Status.Code)
+			assert.Len(t, span.Events, tc.ExpectedEvents)
+			assert.Contains(t, span.Attributes, semconv.HTTPRequestMethodKey.String(http.MethodGet))
+			assert.Contains(t, span.Attributes, semconv.URLPath(tc.Path))
+			assert.Contains(t, span.Attributes, attribute.String("request.id", "req-1"))
+			assert.Contains(t, span.Attributes, semconv.HTTPResponseStatusCode(tc.HandlerStatus))
+			if tc.ExpectedTraceID != "" {
+				assert.Equal(t, tc.ExpectedTraceID, span.SpanContext.TraceID().String())
+				assert.True(t, span.Parent.IsRemote())
+			}
+			if tc.UserID != "" {
+				assert.Contains(t, span.Attributes, semconv.UserID(tc.UserID))
+			}
+			if tc.ExpectedName != "GET" {
+				assert.Contains(t, span.Attributes, semconv.HTTPRoute("/orders/:id"))
+				assert.Equal(t, span.SpanContext.SpanID(), handlerSpan.SpanID())
+			}
+		})
+	}
+}
+
+func TestTracing_Skip(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp, err := tracing.NewTracerProvider(tracing.Config{ServiceName: "test", Exporter: exporter, Synchronous: true})
+	require.NoError(t, err)
+
+	hts := testutils.NewHTTPTestSuite(t)
+	hts.Router.Use(Tracing(TracingConfig{
+		TracerProvider: tp,
+		Skip:           func(c *gin.Context) bool { return c.Request.URL.Path == "/health" },
+	}))
+	hts.Router.GET("/health", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	hts.Router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+	assert.Empty(t, exporter.GetSpans())
+}
+
+func TestTracing_LoggerFields(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp, err := tracing.NewTracerProvider(tracing.Config{ServiceName: "test", Exporter: exporter, Synchronous: true})
+	require.NoError(t, err)
+
+	hts := testutils.NewHTTPTestSuite(t)
+	hts.Router.Use(Tracing(TracingConfig{TracerProvider: tp}))
+
+	var fields map[string]interface{}
+	hts.Router.GET("/orders", func(c *gin.Context) {
+		fields = logger.TraceFields(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+	hts.Router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders", nil))
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, spans[0].SpanContext.TraceID().String(), fields["traceId"])
+	assert.Equal(t, spans[0].SpanContext.SpanID().String(), fields["spanId"])
+}
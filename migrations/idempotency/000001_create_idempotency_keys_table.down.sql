@@ -0,0 +1,6 @@
+-- Rollback: Drop idempotency keys table
+-- WARNING: Retried requests will run again instead of being replayed!
+
+DROP INDEX IF EXISTS idx_idempotency_keys_expires_at;
+
+DROP TABLE IF EXISTS idempotency_keys;
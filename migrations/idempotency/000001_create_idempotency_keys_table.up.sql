@@ -0,0 +1,28 @@
+-- Idempotency Keys Schema
+-- Backs the Postgres store of the Idempotency middleware (common-go/middleware): each
+-- row reserves an Idempotency-Key while its request runs, then keeps the captured
+-- response so retries can be answered without running the handler again.
+--
+-- Usage:
+-- 1. Copy this file to your app's migrations directory
+-- 2. Rename with appropriate timestamp: YYYYMMDDHHMMSS_create_idempotency_keys_table.up.sql
+-- 3. Periodically delete expired rows (PostgresIdempotencyStore.DeleteExpired)
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    key VARCHAR(512) PRIMARY KEY,          -- Client key, prefixed with its scope (e.g. the user ID)
+    fingerprint VARCHAR(64) NOT NULL,      -- SHA-256 of method, path and body of the first request
+    token VARCHAR(32) NOT NULL,            -- Random token of the current reservation; only its holder may complete it
+
+    -- Captured response, set once the request completes
+    completed BOOLEAN NOT NULL DEFAULT FALSE,
+    status_code INTEGER,
+    headers JSONB,
+    body BYTEA,
+
+    -- Timestamps
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL         -- End of the in-flight lock, then of the replay window
+);
+
+-- Deleting expired keys
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
@@ -0,0 +1,9 @@
+-- Rollback: Drop jobs table
+-- WARNING: This will delete all pending and dead jobs!
+
+DROP INDEX IF EXISTS idx_jobs_finished_at;
+DROP INDEX IF EXISTS idx_jobs_unique_key;
+DROP INDEX IF EXISTS idx_jobs_running;
+DROP INDEX IF EXISTS idx_jobs_pending;
+
+DROP TABLE IF EXISTS jobs;
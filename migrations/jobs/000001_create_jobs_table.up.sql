@@ -0,0 +1,52 @@
+-- Background Job Queue Schema
+-- Durable asynchronous work processed by common-go/jobs workers. Workers claim jobs
+-- with FOR UPDATE SKIP LOCKED, so any number of workers can share the table.
+--
+-- Usage:
+-- 1. Copy this file to your app's migrations directory
+-- 2. Rename with appropriate timestamp: YYYYMMDDHHMMSS_create_jobs_table.up.sql
+--
+-- Status lifecycle:
+-- - pending:   waiting to run once run_at has passed (new, delayed or retried jobs)
+-- - running:   claimed by a worker (locked_by, locked_at)
+-- - completed: the handler succeeded
+-- - dead:      gave up after max_attempts or a permanent error (dead letter)
+
+CREATE TABLE IF NOT EXISTS jobs (
+    id BIGSERIAL PRIMARY KEY,
+
+    -- Job definition
+    queue VARCHAR(100) NOT NULL DEFAULT 'default',
+    kind VARCHAR(100) NOT NULL,            -- Handler name, e.g. 'email.send'
+    payload JSONB NOT NULL,
+    unique_key VARCHAR(255),               -- At most one pending or running job per key
+
+    -- Execution state
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL DEFAULT 25,
+    last_error TEXT,
+    run_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),  -- Not claimed before this time (delay, retry backoff)
+    locked_by VARCHAR(255),
+    locked_at TIMESTAMPTZ,
+
+    -- Timestamps
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMPTZ,
+
+    CONSTRAINT chk_jobs_status CHECK (status IN ('pending', 'running', 'completed', 'dead')),
+    CONSTRAINT chk_jobs_max_attempts CHECK (max_attempts > 0)
+);
+
+-- Workers claim pending jobs of their queues in run_at order
+CREATE INDEX IF NOT EXISTS idx_jobs_pending ON jobs (queue, run_at, id) WHERE status = 'pending';
+
+-- Rescuing jobs of crashed workers
+CREATE INDEX IF NOT EXISTS idx_jobs_running ON jobs (locked_at) WHERE status = 'running';
+
+-- Unique jobs: a key can be reused once the previous job has finished
+CREATE UNIQUE INDEX IF NOT EXISTS idx_jobs_unique_key ON jobs (unique_key) WHERE unique_key IS NOT NULL AND status IN ('pending', 'running');
+
+-- Purging finished jobs by age
+CREATE INDEX IF NOT EXISTS idx_jobs_finished_at ON jobs (finished_at) WHERE status IN ('completed', 'dead');
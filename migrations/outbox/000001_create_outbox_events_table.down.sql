@@ -0,0 +1,8 @@
+-- Rollback: Drop outbox table
+-- WARNING: This will delete all unpublished events!
+
+DROP INDEX IF EXISTS idx_outbox_events_aggregate;
+DROP INDEX IF EXISTS idx_outbox_events_published_at;
+DROP INDEX IF EXISTS idx_outbox_events_pending;
+
+DROP TABLE IF EXISTS outbox_events;
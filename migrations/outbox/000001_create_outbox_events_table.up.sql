@@ -0,0 +1,45 @@
+-- Transactional Outbox Schema
+-- Domain events are inserted in the same transaction as the business change and
+-- published afterwards by the outbox relay (common-go/outbox), so an event is
+-- published if and only if its transaction commits.
+--
+-- Usage:
+-- 1. Copy this file to your app's migrations directory
+-- 2. Rename with appropriate timestamp: YYYYMMDDHHMMSS_create_outbox_events_table.up.sql
+--
+-- Status lifecycle:
+-- - pending:   waiting to be published (or retried once available_at has passed)
+-- - published: delivered to the publisher
+-- - failed:    gave up after the relay's maximum number of attempts
+
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id BIGSERIAL PRIMARY KEY,
+
+    -- Event identity
+    aggregate_type VARCHAR(100) NOT NULL,  -- e.g. 'order'
+    aggregate_id VARCHAR(255) NOT NULL,    -- e.g. the order ID
+    event_type VARCHAR(100) NOT NULL,      -- e.g. 'order.created'
+    payload JSONB NOT NULL,
+    headers JSONB NOT NULL DEFAULT '{}',
+
+    -- Delivery state
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    available_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),  -- Not claimed before this time (retry backoff, or a relay's lease)
+
+    -- Timestamps
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    published_at TIMESTAMPTZ,
+
+    CONSTRAINT chk_outbox_events_status CHECK (status IN ('pending', 'published', 'failed'))
+);
+
+-- The relay claims pending events in id order once they are available
+CREATE INDEX IF NOT EXISTS idx_outbox_events_pending ON outbox_events (id) WHERE status = 'pending';
+
+-- Purging published events by age
+CREATE INDEX IF NOT EXISTS idx_outbox_events_published_at ON outbox_events (published_at) WHERE status = 'published';
+
+-- Investigating events of an aggregate
+CREATE INDEX IF NOT EXISTS idx_outbox_events_aggregate ON outbox_events (aggregate_type, aggregate_id);
@@ -0,0 +1,6 @@
+-- Rollback: Drop rate limits table
+-- WARNING: All rate limit counters are reset!
+
+DROP INDEX IF EXISTS idx_rate_limits_expires_at;
+
+DROP TABLE IF EXISTS rate_limits;
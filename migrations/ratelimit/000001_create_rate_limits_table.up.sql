@@ -0,0 +1,27 @@
+-- Rate Limits Schema
+-- Backs the Postgres store of the RateLimiter middleware (common-go/middleware): one
+-- row per rate limit key (client, or client and route) holding its counter, so limits
+-- are shared by all replicas of a service.
+--
+-- Usage:
+-- 1. Copy this file to your app's migrations directory
+-- 2. Rename with appropriate timestamp: YYYYMMDDHHMMSS_create_rate_limits_table.up.sql
+-- 3. Periodically delete expired rows (PostgresRateLimitStore.DeleteExpired)
+
+CREATE TABLE IF NOT EXISTS rate_limits (
+    key VARCHAR(512) PRIMARY KEY,                -- Rate limit key (e.g. "user:42|POST /login")
+
+    -- Token bucket state
+    tokens DOUBLE PRECISION NOT NULL DEFAULT 0,  -- Tokens left at updated_at
+    updated_at TIMESTAMPTZ,                      -- Last refill, NULL until first used
+
+    -- Sliding window state
+    window_start TIMESTAMPTZ,                    -- Start of the current window, NULL until first used
+    current_count INTEGER NOT NULL DEFAULT 0,    -- Requests in the current window
+    previous_count INTEGER NOT NULL DEFAULT 0,   -- Requests in the previous window
+
+    expires_at TIMESTAMPTZ NOT NULL              -- When the counter no longer affects the limit
+);
+
+-- Deleting expired counters
+CREATE INDEX IF NOT EXISTS idx_rate_limits_expires_at ON rate_limits (expires_at);
@@ -0,0 +1,165 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/medbai2/common-go/database"
+	"github.com/medbai2/common-go/errors"
+
+	"gorm.io/gorm"
+)
+
+// Event statuses stored in outbox_events.status
+const (
+	StatusPending   = "pending"
+	StatusPublished = "published"
+	StatusFailed    = "failed"
+)
+
+// TableName is the outbox table created by migrations/outbox
+const TableName = "outbox_events"
+
+// Message is a domain event to enqueue
+type Message struct {
+	AggregateType string            // e.g. "order"
+	AggregateID   string            // e.g. the order ID
+	EventType     string            // e.g. "order.created"
+	Payload       interface{}       // Marshalled to JSON; json.RawMessage and []byte are stored as-is
+	Headers       map[string]string // Optional metadata (e.g. a request ID for correlation)
+}
+
+// Event is a stored event handed to a Publisher
+type Event struct {
+	ID            int64
+	AggregateType string
+	AggregateID   string
+	EventType     string
+	Payload       json.RawMessage
+	Headers       map[string]string
+	Attempts      int // Previous delivery attempts
+	CreatedAt     time.Time
+}
+
+// record is the GORM model for outbox_events
+type record struct {
+	ID            int64     `gorm:"primaryKey"`
+	AggregateType string    `gorm:"not null"`
+	AggregateID   string    `gorm:"not null"`
+	EventType     string    `gorm:"not null"`
+	Payload       []byte    `gorm:"type:jsonb;not null"`
+	Headers       []byte    `gorm:"type:jsonb;not null"`
+	Status        string    `gorm:"not null;default:pending"`
+	Attempts      int       `gorm:"not null;default:0"`
+	LastError     *string   `gorm:""`
+	AvailableAt   time.Time `gorm:"not null"`
+	CreatedAt     time.Time `gorm:"not null"`
+	PublishedAt   *time.Time
+}
+
+// TableName implements gorm's Tabler
+func (record) TableName() string {
+	return TableName
+}
+
+// event converts a stored record into an Event
+func (r record) event() Event {
+	headers := map[string]string{}
+	if len(r.Headers) > 0 {
+		// Headers are written by Enqueue, so they always decode
+		json.Unmarshal(r.Headers, &headers)
+	}
+	return Event{
+		ID:            r.ID,
+		AggregateType: r.AggregateType,
+		AggregateID:   r.AggregateID,
+		EventType:     r.EventType,
+		Payload:       json.RawMessage(r.Payload),
+		Headers:       headers,
+		Attempts:      r.Attempts,
+		CreatedAt:     r.CreatedAt,
+	}
+}
+
+// Enqueue stores msg in the outbox as part of the transaction carried by ctx (see
+// database.WithTx) or tx, so the event is published only if that transaction commits.
+// Calling it outside a transaction is an error: the event could then be published for a
+// change that is rolled back, or lost for one that commits.
+//
+// Usage:
+//
+//	err := database.WithTx(ctx, db, nil, func(ctx context.Context, tx *gorm.DB) error {
+//		if err := tx.Create(&order).Error; err != nil {
+//			return err
+//		}
+//		return outbox.Enqueue(ctx, tx, outbox.Message{AggregateType: "order", AggregateID: order.ID, EventType: "order.created", Payload: order})
+//	})
+func Enqueue(ctx context.Context, tx *gorm.DB, msg Message) error {
+	if tx == nil {
+		return errors.New(errors.ErrCodeDatabaseError, "database is nil")
+	}
+	db := database.Conn(ctx, tx)
+	if _, ok := db.Statement.ConnPool.(gorm.TxCommitter); !ok {
+		return errors.New(errors.ErrCodeInternal, "outbox events must be enqueued inside a transaction")
+	}
+
+	if msg.AggregateType == "" {
+		return errors.NewMissingField("aggregateType")
+	}
+	if msg.AggregateID == "" {
+		return errors.NewMissingField("aggregateId")
+	}
+	if msg.EventType == "" {
+		return errors.NewMissingField("eventType")
+	}
+
+	payload, err := marshalPayload(msg.Payload)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrCodeInvalidInput, "failed to encode event payload")
+	}
+	headers := msg.Headers
+	if headers == nil {
+		headers = map[string]string{}
+	}
+	encodedHeaders, err := json.Marshal(headers)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrCodeInvalidInput, "failed to encode event headers")
+	}
+
+	now := time.Now().UTC()
+	rec := &record{
+		AggregateType: msg.AggregateType,
+		AggregateID:   msg.AggregateID,
+		EventType:     msg.EventType,
+		Payload:       payload,
+		Headers:       encodedHeaders,
+		Status:        StatusPending,
+		AvailableAt:   now,
+		CreatedAt:     now,
+	}
+	if err := db.Create(rec).Error; err != nil {
+		return database.TranslateError(err)
+	}
+	return nil
+}
+
+// marshalPayload encodes a payload as JSON
+func marshalPayload(payload interface{}) ([]byte, error) {
+	switch p := payload.(type) {
+	case json.RawMessage:
+		if !json.Valid(p) {
+			return nil, errors.NewInvalidInput("payload is not valid JSON")
+		}
+		return p, nil
+	case []byte:
+		if !json.Valid(p) {
+			return nil, errors.NewInvalidInput("payload is not valid JSON")
+		}
+		return p, nil
+	case nil:
+		return []byte("null"), nil
+	default:
+		return json.Marshal(payload)
+	}
+}
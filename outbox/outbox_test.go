@@ -0,0 +1,147 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"testing"
+
+	"github.com/medbai2/common-go/database"
+	"github.com/medbai2/common-go/errors"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// newTestDB returns a GORM handle backed by sqlmock
+func newTestDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { mockDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: mockDB}), &gorm.Config{
+		Logger:                 gormlogger.Discard,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+	})
+	require.NoError(t, err)
+	return db, mock
+}
+
+func TestEnqueue(t *testing.T) {
+	db, mock := newTestDB(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "outbox_events"`)).
+		WithArgs("order", "42", "order.created", []byte(`{"total":10}`), []byte(`{"requestId":"req-1"}`), StatusPending, 0, nil, sqlmock.AnyArg(), sqlmock.AnyArg(), nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	err := database.WithTx(context.Background(), db, nil, func(ctx context.Context, tx *gorm.DB) error {
+		return Enqueue(ctx, tx, Message{
+			AggregateType: "order",
+			AggregateID:   "42",
+			EventType:     "order.created",
+			Payload:       map[string]int{"total": 10},
+			Headers:       map[string]string{"requestId": "req-1"},
+		})
+	})
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEnqueue_OutsideTransaction(t *testing.T) {
+	db, mock := newTestDB(t)
+
+	err := Enqueue(context.Background(), db, Message{AggregateType: "order", AggregateID: "42", EventType: "order.created"})
+	require.Error(t, err)
+	assert.Equal(t, errors.ErrCodeInternal, errors.GetAppError(err).Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestEnqueue_Validation(t *testing.T) {
+	testCases := []struct {
+		Name         string
+		Message      Message
+		ExpectedCode errors.ErrorCode
+	}{
+		{
+			Name:         "missing aggregate type",
+			Message:      Message{AggregateID: "42", EventType: "order.created"},
+			ExpectedCode: errors.ErrCodeMissingField,
+		},
+		{
+			Name:         "missing aggregate id",
+			Message:      Message{AggregateType: "order", EventType: "order.created"},
+			ExpectedCode: errors.ErrCodeMissingField,
+		},
+		{
+			Name:         "missing event type",
+			Message:      Message{AggregateType: "order", AggregateID: "42"},
+			ExpectedCode: errors.ErrCodeMissingField,
+		},
+		{
+			Name:         "invalid raw payload",
+			Message:      Message{AggregateType: "order", AggregateID: "42", EventType: "order.created", Payload: json.RawMessage(`{`)},
+			ExpectedCode: errors.ErrCodeInvalidInput,
+		},
+		{
+			Name:         "unencodable payload",
+			Message:      Message{AggregateType: "order", AggregateID: "42", EventType: "order.created", Payload: make(chan int)},
+			ExpectedCode: errors.ErrCodeInvalidInput,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			db, mock := newTestDB(t)
+			mock.ExpectBegin()
+			mock.ExpectRollback()
+
+			err := database.WithTx(context.Background(), db, nil, func(ctx context.Context, tx *gorm.DB) error {
+				return Enqueue(ctx, tx, tc.Message)
+			})
+			require.Error(t, err)
+			assert.Equal(t, tc.ExpectedCode, errors.GetAppError(err).Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestMarshalPayload(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Payload  interface{}
+		Expected string
+	}{
+		{Name: "struct", Payload: struct {
+			ID string `json:"id"`
+		}{ID: "a"}, Expected: `{"id":"a"}`},
+		{Name: "raw message", Payload: json.RawMessage(`{"a":1}`), Expected: `{"a":1}`},
+		{Name: "bytes", Payload: []byte(`[1,2]`), Expected: `[1,2]`},
+		{Name: "nil", Payload: nil, Expected: `null`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			payload, err := marshalPayload(tc.Payload)
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.Expected, string(payload))
+		})
+	}
+}
+
+func TestRecord_Event(t *testing.T) {
+	rec := record{ID: 3, AggregateType: "order", AggregateID: "42", EventType: "order.created", Payload: []byte(`{"a":1}`), Headers: []byte(`{"k":"v"}`), Attempts: 2}
+
+	event := rec.event()
+	assert.Equal(t, int64(3), event.ID)
+	assert.Equal(t, "order.created", event.EventType)
+	assert.JSONEq(t, `{"a":1}`, string(event.Payload))
+	assert.Equal(t, map[string]string{"k": "v"}, event.Headers)
+	assert.Equal(t, 2, event.Attempts)
+}
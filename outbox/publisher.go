@@ -0,0 +1,68 @@
+package outbox
+
+import (
+	"context"
+	"sync"
+)
+
+// Publisher delivers events to a message broker. Delivery is at-least-once: an event
+// may be published again if the relay stops before recording success, so consumers
+// should deduplicate by Event.ID.
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// PublisherFunc adapts a function to a Publisher
+type PublisherFunc func(ctx context.Context, event Event) error
+
+// Publish implements Publisher
+func (f PublisherFunc) Publish(ctx context.Context, event Event) error {
+	return f(ctx, event)
+}
+
+// MemoryPublisher records published events in memory, for tests and local development
+type MemoryPublisher struct {
+	mu     sync.Mutex
+	events []Event
+	fail   func(event Event) error
+}
+
+// NewMemoryPublisher creates an empty in-memory publisher
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{}
+}
+
+// FailWith makes Publish return fail(event) when it is non-nil, to simulate broker errors.
+// Passing nil restores normal behaviour.
+func (p *MemoryPublisher) FailWith(fail func(event Event) error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.fail = fail
+}
+
+// Publish implements Publisher
+func (p *MemoryPublisher) Publish(ctx context.Context, event Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.fail != nil {
+		if err := p.fail(event); err != nil {
+			return err
+		}
+	}
+	p.events = append(p.events, event)
+	return nil
+}
+
+// Events returns a copy of the published events in publishing order
+func (p *MemoryPublisher) Events() []Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Event(nil), p.events...)
+}
+
+// Reset discards the recorded events
+func (p *MemoryPublisher) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = nil
+}
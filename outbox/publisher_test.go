@@ -0,0 +1,61 @@
+package outbox
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryPublisher(t *testing.T) {
+	publisher := NewMemoryPublisher()
+	ctx := context.Background()
+
+	require.NoError(t, publisher.Publish(ctx, Event{ID: 1, EventType: "order.created"}))
+	require.NoError(t, publisher.Publish(ctx, Event{ID: 2, EventType: "order.paid"}))
+
+	events := publisher.Events()
+	require.Len(t, events, 2)
+	assert.Equal(t, int64(1), events[0].ID)
+	assert.Equal(t, int64(2), events[1].ID)
+
+	// Events returns a copy
+	events[0].ID = 99
+	assert.Equal(t, int64(1), publisher.Events()[0].ID)
+
+	publisher.Reset()
+	assert.Empty(t, publisher.Events())
+}
+
+func TestMemoryPublisher_FailWith(t *testing.T) {
+	publisher := NewMemoryPublisher()
+	ctx := context.Background()
+	brokerDown := stderrors.New("broker down")
+
+	publisher.FailWith(func(event Event) error {
+		if event.EventType == "order.paid" {
+			return brokerDown
+		}
+		return nil
+	})
+	assert.NoError(t, publisher.Publish(ctx, Event{ID: 1, EventType: "order.created"}))
+	assert.ErrorIs(t, publisher.Publish(ctx, Event{ID: 2, EventType: "order.paid"}), brokerDown)
+	assert.Len(t, publisher.Events(), 1)
+
+	publisher.FailWith(nil)
+	assert.NoError(t, publisher.Publish(ctx, Event{ID: 2, EventType: "order.paid"}))
+	assert.Len(t, publisher.Events(), 2)
+}
+
+func TestPublisherFunc(t *testing.T) {
+	var got Event
+	publisher := PublisherFunc(func(ctx context.Context, event Event) error {
+		got = event
+		return nil
+	})
+
+	require.NoError(t, publisher.Publish(context.Background(), Event{ID: 5}))
+	assert.Equal(t, int64(5), got.ID)
+}
@@ -0,0 +1,354 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/medbai2/common-go/database"
+	"github.com/medbai2/common-go/errors"
+	"github.com/medbai2/common-go/internal/backoff"
+	"github.com/medbai2/common-go/internal/metricsutil"
+	"github.com/medbai2/common-go/logger"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Default relay settings
+const (
+	DefaultBatchSize      = 100
+	DefaultPollInterval   = time.Second
+	DefaultMaxAttempts    = 10
+	DefaultInitialBackoff = time.Second
+	DefaultMaxBackoff     = 5 * time.Minute
+	DefaultPublishTimeout = 10 * time.Second
+	DefaultLeaseDuration  = 5 * time.Minute
+)
+
+// maxErrorLength bounds the publisher error stored in last_error
+const maxErrorLength = 1000
+
+// RelayConfig configures a Relay. Unset fields take the Default* values.
+type RelayConfig struct {
+	BatchSize      int                   // Events claimed per transaction
+	PollInterval   time.Duration         // Wait between polls when the outbox is empty
+	MaxAttempts    int                   // Attempts before an event is marked failed
+	InitialBackoff time.Duration         // Delay before the first retry of a failed event
+	MaxBackoff     time.Duration         // Upper bound for the delay between retries
+	PublishTimeout time.Duration         // Upper bound for a single Publish call
+	LeaseDuration  time.Duration         // How long claimed events are hidden from other relays (default: 5m, at least 2x PublishTimeout)
+	Logger         logger.Logger         // default: logger.NewFromEnv("outbox")
+	Registerer     prometheus.Registerer // Where relay metrics are registered (nil disables metrics)
+}
+
+// Relay publishes pending outbox events. Several relays (e.g. one per replica of a service)
+// can run against the same table: events are claimed with FOR UPDATE SKIP LOCKED and leased
+// by moving their available_at past the lease, so each event is handled by one relay at a
+// time. Publishing happens outside any transaction; events whose outcome was not recorded
+// (e.g. the relay crashed) are claimed again once their lease expires.
+type Relay struct {
+	db        *gorm.DB
+	publisher Publisher
+	cfg       RelayConfig
+	metrics   *relayMetrics
+}
+
+// relayMetrics are the Prometheus collectors of a relay
+type relayMetrics struct {
+	published prometheus.Counter
+	failures  *prometheus.CounterVec
+	pending   prometheus.Gauge
+	lag       prometheus.Gauge
+}
+
+// NewRelay creates a relay publishing events from db through publisher
+func NewRelay(db *gorm.DB, publisher Publisher, cfg RelayConfig) (*Relay, error) {
+	if db == nil {
+		return nil, errors.New(errors.ErrCodeDatabaseError, "database is nil")
+	}
+	if publisher == nil {
+		return nil, errors.NewMissingField("publisher")
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultBatchSize
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = DefaultPollInterval
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = DefaultMaxAttempts
+	}
+	if cfg.InitialBackoff <= 0 {
+		cfg.InitialBackoff = DefaultInitialBackoff
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = DefaultMaxBackoff
+	}
+	if cfg.PublishTimeout <= 0 {
+		cfg.PublishTimeout = DefaultPublishTimeout
+	}
+	switch {
+	case cfg.LeaseDuration <= 0:
+		cfg.LeaseDuration = max(DefaultLeaseDuration, 2*cfg.PublishTimeout)
+	case cfg.LeaseDuration <= cfg.PublishTimeout:
+		return nil, errors.NewInvalidInput("outbox lease duration must exceed the publish timeout")
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = logger.NewFromEnv("outbox")
+	}
+
+	r := &Relay{db: db, publisher: publisher, cfg: cfg}
+	if cfg.Registerer != nil {
+		metrics, err := newRelayMetrics(cfg.Registerer)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.ErrCodeInternal, "failed to register outbox metrics")
+		}
+		r.metrics = metrics
+	}
+	return r, nil
+}
+
+// newRelayMetrics creates the relay collectors, reusing ones already registered on reg
+func newRelayMetrics(reg prometheus.Registerer) (*relayMetrics, error) {
+	published, err := metricsutil.Register(reg, prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "outbox_events_published_total",
+		Help: "Outbox events published successfully.",
+	}))
+	if err != nil {
+		return nil, err
+	}
+	failures, err := metricsutil.Register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "outbox_publish_failures_total",
+		Help: "Failed outbox publish attempts; final=\"true\" when the event was given up on.",
+	}, []string{"final"}))
+	if err != nil {
+		return nil, err
+	}
+	pending, err := metricsutil.Register(reg, prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "outbox_pending_events",
+		Help: "Outbox events waiting to be published.",
+	}))
+	if err != nil {
+		return nil, err
+	}
+	lag, err := metricsutil.Register(reg, prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "outbox_lag_seconds",
+		Help: "Age of the oldest pending outbox event.",
+	}))
+	if err != nil {
+		return nil, err
+	}
+	return &relayMetrics{published: published, failures: failures, pending: pending, lag: lag}, nil
+}
+
+// Run publishes events until ctx is done. A full batch is followed immediately by the next
+// one; otherwise the relay waits PollInterval. Errors are logged and retried.
+func (r *Relay) Run(ctx context.Context) error {
+	r.cfg.Logger.Info("Outbox relay started", map[string]interface{}{"batchSize": r.cfg.BatchSize})
+	for {
+		processed, err := r.ProcessBatch(ctx)
+		if err != nil && ctx.Err() == nil {
+			r.cfg.Logger.Error("Outbox batch failed", err)
+		}
+		if r.metrics != nil {
+			r.updateBacklog(ctx)
+		}
+
+		wait := r.cfg.PollInterval
+		if err == nil && processed == r.cfg.BatchSize {
+			wait = 0
+		}
+		select {
+		case <-ctx.Done():
+			r.cfg.Logger.Info("Outbox relay stopped")
+			return nil
+		case <-time.After(wait):
+		}
+	}
+}
+
+// ProcessBatch claims up to BatchSize available events, publishes them and records the
+// outcome. Claiming and recording each run in a short transaction; no transaction is held
+// open while publishing. Events the lease leaves no time to publish are released for the
+// next batch. It returns the number of events claimed.
+func (r *Relay) ProcessBatch(ctx context.Context) (int, error) {
+	records, deadline, err := r.claim(ctx)
+	if err != nil {
+		return 0, database.TranslateError(err)
+	}
+	if len(records) == 0 {
+		return 0, nil
+	}
+
+	var published, released []int64
+	failures := map[int64]error{}
+	for _, rec := range records {
+		// Stop before the lease runs out so another relay cannot claim an event being published
+		if ctx.Err() != nil || time.Until(deadline) < r.cfg.PublishTimeout {
+			released = append(released, rec.ID)
+			continue
+		}
+		if err := r.publish(ctx, rec.event()); err != nil {
+			failures[rec.ID] = err
+			continue
+		}
+		published = append(published, rec.ID)
+	}
+
+	// Record the outcome even when ctx is done, so published events are not published again
+	recordCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), r.cfg.PublishTimeout)
+	defer cancel()
+	err = r.db.WithContext(recordCtx).Transaction(func(tx *gorm.DB) error {
+		if len(published) > 0 {
+			err := tx.Model(&record{}).Where("id IN ? AND status = ?", published, StatusPending).Updates(map[string]interface{}{
+				"status":       StatusPublished,
+				"published_at": gorm.Expr("NOW()"),
+				"attempts":     gorm.Expr("attempts + 1"),
+				"last_error":   nil,
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+		for _, rec := range records {
+			if publishErr, ok := failures[rec.ID]; ok {
+				if err := r.recordFailure(tx, rec, publishErr); err != nil {
+					return err
+				}
+			}
+		}
+		if len(released) > 0 {
+			err := tx.Model(&record{}).Where("id IN ? AND status = ?", released, StatusPending).
+				Update("available_at", gorm.Expr("NOW()")).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, database.TranslateError(err)
+	}
+	if r.metrics != nil && len(published) > 0 {
+		r.metrics.published.Add(float64(len(published)))
+	}
+	return len(records), nil
+}
+
+// claim locks up to BatchSize available events and leases them by moving their available_at
+// LeaseDuration ahead. It returns the claimed events and when the lease ends.
+func (r *Relay) claim(ctx context.Context) ([]record, time.Time, error) {
+	// Taken before the claim, so the lease seen by the database never ends earlier
+	deadline := time.Now().Add(r.cfg.LeaseDuration)
+
+	var records []record
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND available_at <= NOW()", StatusPending).
+			Order("id").
+			Limit(r.cfg.BatchSize).
+			Find(&records).Error
+		if err != nil || len(records) == 0 {
+			return err
+		}
+
+		ids := make([]int64, len(records))
+		for i, rec := range records {
+			ids[i] = rec.ID
+		}
+		return tx.Model(&record{}).Where("id IN ?", ids).
+			Update("available_at", gorm.Expr("NOW() + ? * INTERVAL '1 millisecond'", r.cfg.LeaseDuration.Milliseconds())).Error
+	})
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return records, deadline, nil
+}
+
+// publish delivers one event, bounded by PublishTimeout
+func (r *Relay) publish(ctx context.Context, event Event) error {
+	ctx, cancel := context.WithTimeout(ctx, r.cfg.PublishTimeout)
+	defer cancel()
+	return r.publisher.Publish(ctx, event)
+}
+
+// recordFailure schedules a retry with backoff, or marks the event failed once
+// MaxAttempts is reached
+func (r *Relay) recordFailure(tx *gorm.DB, rec record, publishErr error) error {
+	attempts := rec.Attempts + 1
+	final := attempts >= r.cfg.MaxAttempts
+
+	message := publishErr.Error()
+	if len(message) > maxErrorLength {
+		message = message[:maxErrorLength]
+	}
+	updates := map[string]interface{}{
+		"attempts":   attempts,
+		"last_error": message,
+	}
+	fields := map[string]interface{}{
+		"eventId":   rec.ID,
+		"eventType": rec.EventType,
+		"attempts":  attempts,
+	}
+	if final {
+		updates["status"] = StatusFailed
+		r.cfg.Logger.Error("Giving up publishing outbox event", publishErr, fields)
+	} else {
+		delay := r.backoff(attempts)
+		updates["available_at"] = time.Now().UTC().Add(delay)
+		fields["retryIn"] = delay.String()
+		r.cfg.Logger.Warn("Failed to publish outbox event", fields)
+	}
+	if r.metrics != nil {
+		r.metrics.failures.WithLabelValues(boolLabel(final)).Inc()
+	}
+
+	return tx.Model(&record{}).Where("id = ? AND status = ?", rec.ID, StatusPending).Updates(updates).Error
+}
+
+// backoff returns the delay before retrying an event that failed attempts times, doubling
+// from InitialBackoff up to MaxBackoff with jitter in the upper half of the interval
+func (r *Relay) backoff(attempts int) time.Duration {
+	return backoff.Jitter(attempts-1, r.cfg.InitialBackoff, r.cfg.MaxBackoff)
+}
+
+// updateBacklog refreshes the pending and lag gauges
+func (r *Relay) updateBacklog(ctx context.Context) {
+	var backlog struct {
+		Pending int64
+		Lag     float64
+	}
+	err := r.db.WithContext(ctx).Model(&record{}).
+		Select("COUNT(*) AS pending, COALESCE(EXTRACT(EPOCH FROM NOW() - MIN(created_at)), 0) AS lag").
+		Where("status = ?", StatusPending).
+		Scan(&backlog).Error
+	if err != nil {
+		if ctx.Err() == nil {
+			r.cfg.Logger.Warn("Failed to measure outbox backlog", map[string]interface{}{"error": err.Error()})
+		}
+		return
+	}
+	r.metrics.pending.Set(float64(backlog.Pending))
+	r.metrics.lag.Set(backlog.Lag)
+}
+
+// Purge deletes events published before the given time and returns how many were removed
+func (r *Relay) Purge(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("status = ? AND published_at < ?", StatusPublished, before).
+		Delete(&record{})
+	if result.Error != nil {
+		return 0, database.TranslateError(result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// boolLabel formats a boolean metric label
+func boolLabel(value bool) string {
+	if value {
+		return "true"
+	}
+	return "false"
+}
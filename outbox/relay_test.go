@@ -0,0 +1,310 @@
+package outbox
+
+import (
+	"context"
+	"database/sql/driver"
+	stderrors "errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/medbai2/common-go/errors"
+	"github.com/medbai2/common-go/logger"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	claimQuery   = `SELECT * FROM "outbox_events" WHERE status = $1 AND available_at <= NOW() ORDER BY id LIMIT $2 FOR UPDATE SKIP LOCKED`
+	leaseQuery   = `UPDATE "outbox_events" SET "available_at"=NOW() + $1 * INTERVAL '1 millisecond' WHERE id IN (`
+	releaseQuery = `UPDATE "outbox_events" SET "available_at"=NOW() WHERE id IN (`
+)
+
+func newRecordRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "aggregate_type", "aggregate_id", "event_type", "payload", "headers", "status", "attempts", "last_error", "available_at", "created_at", "published_at"})
+}
+
+func newTestRelay(t *testing.T, publisher Publisher, cfg RelayConfig) (*Relay, sqlmock.Sqlmock) {
+	db, mock := newTestDB(t)
+	cfg.Logger = logger.NewZapLogger("fatal")
+	relay, err := NewRelay(db, publisher, cfg)
+	require.NoError(t, err)
+	return relay, mock
+}
+
+func TestNewRelay(t *testing.T) {
+	db, _ := newTestDB(t)
+
+	_, err := NewRelay(nil, NewMemoryPublisher(), RelayConfig{})
+	assert.Equal(t, errors.ErrCodeDatabaseError, errors.GetAppError(err).Code)
+
+	_, err = NewRelay(db, nil, RelayConfig{})
+	assert.Equal(t, errors.ErrCodeMissingField, errors.GetAppError(err).Code)
+
+	relay, err := NewRelay(db, NewMemoryPublisher(), RelayConfig{Logger: logger.NewZapLogger("fatal")})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultBatchSize, relay.cfg.BatchSize)
+	assert.Equal(t, DefaultPollInterval, relay.cfg.PollInterval)
+	assert.Equal(t, DefaultMaxAttempts, relay.cfg.MaxAttempts)
+	assert.Equal(t, DefaultInitialBackoff, relay.cfg.InitialBackoff)
+	assert.Equal(t, DefaultMaxBackoff, relay.cfg.MaxBackoff)
+	assert.Equal(t, DefaultPublishTimeout, relay.cfg.PublishTimeout)
+	assert.Equal(t, DefaultLeaseDuration, relay.cfg.LeaseDuration)
+	assert.Nil(t, relay.metrics)
+
+	// The default lease leaves room for slow publishers
+	relay, err = NewRelay(db, NewMemoryPublisher(), RelayConfig{PublishTimeout: 10 * time.Minute, Logger: logger.NewZapLogger("fatal")})
+	require.NoError(t, err)
+	assert.Equal(t, 20*time.Minute, relay.cfg.LeaseDuration)
+
+	_, err = NewRelay(db, NewMemoryPublisher(), RelayConfig{PublishTimeout: time.Minute, LeaseDuration: time.Minute})
+	assert.Equal(t, errors.ErrCodeInvalidInput, errors.GetAppError(err).Code)
+}
+
+func TestRelay_ProcessBatch(t *testing.T) {
+	publisher := NewMemoryPublisher()
+	reg := prometheus.NewRegistry()
+	relay, mock := newTestRelay(t, publisher, RelayConfig{BatchSize: 10, Registerer: reg})
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(claimQuery)).
+		WithArgs(StatusPending, 10).
+		WillReturnRows(newRecordRows().
+			AddRow(1, "order", "42", "order.created", []byte(`{"a":1}`), []byte(`{"requestId":"req-1"}`), StatusPending, 0, nil, now, now, nil).
+			AddRow(2, "order", "42", "order.paid", []byte(`{"a":2}`), []byte(`{}`), StatusPending, 1, "timeout", now, now, nil))
+	mock.ExpectExec(regexp.QuoteMeta(leaseQuery+`$2,$3)`)).
+		WithArgs(DefaultLeaseDuration.Milliseconds(), 1, 2).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+	// Publishing happens between the claim and the outcome transactions
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "outbox_events" SET "attempts"=attempts + 1,"last_error"=$1,"published_at"=NOW(),"status"=$2 WHERE id IN ($3,$4) AND status = $5`)).
+		WithArgs(nil, StatusPublished, 1, 2, StatusPending).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	claimed, err := relay.ProcessBatch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, claimed)
+
+	events := publisher.Events()
+	require.Len(t, events, 2)
+	assert.Equal(t, "order.created", events[0].EventType)
+	assert.Equal(t, map[string]string{"requestId": "req-1"}, events[0].Headers)
+	assert.JSONEq(t, `{"a":2}`, string(events[1].Payload))
+	assert.Equal(t, 1, events[1].Attempts)
+	assert.Equal(t, 2.0, testutil.ToFloat64(relay.metrics.published))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRelay_ProcessBatch_Empty(t *testing.T) {
+	relay, mock := newTestRelay(t, NewMemoryPublisher(), RelayConfig{})
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(claimQuery)).
+		WithArgs(StatusPending, DefaultBatchSize).
+		WillReturnRows(newRecordRows())
+	mock.ExpectCommit()
+
+	claimed, err := relay.ProcessBatch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, claimed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRelay_ProcessBatch_PublishFailure(t *testing.T) {
+	testCases := []struct {
+		Name           string
+		Attempts       int
+		ExpectedUpdate string
+		ExpectedArgs   []interface{}
+		ExpectedFinal  string
+	}{
+		{
+			Name:           "retry with backoff",
+			Attempts:       0,
+			ExpectedUpdate: `UPDATE "outbox_events" SET "attempts"=$1,"available_at"=$2,"last_error"=$3 WHERE id = $4 AND status = $5`,
+			ExpectedArgs:   []interface{}{1, sqlmock.AnyArg(), "broker down", 7, StatusPending},
+			ExpectedFinal:  "false",
+		},
+		{
+			Name:           "give up after max attempts",
+			Attempts:       2,
+			ExpectedUpdate: `UPDATE "outbox_events" SET "attempts"=$1,"last_error"=$2,"status"=$3 WHERE id = $4 AND status = $5`,
+			ExpectedArgs:   []interface{}{3, "broker down", StatusFailed, 7, StatusPending},
+			ExpectedFinal:  "true",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			publisher := NewMemoryPublisher()
+			publisher.FailWith(func(Event) error { return stderrors.New("broker down") })
+			relay, mock := newTestRelay(t, publisher, RelayConfig{MaxAttempts: 3, Registerer: prometheus.NewRegistry()})
+			now := time.Now()
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta(claimQuery)).
+				WithArgs(StatusPending, DefaultBatchSize).
+				WillReturnRows(newRecordRows().
+					AddRow(7, "order", "42", "order.created", []byte(`{}`), []byte(`{}`), StatusPending, tc.Attempts, nil, now, now, nil))
+			mock.ExpectExec(regexp.QuoteMeta(leaseQuery)).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+			mock.ExpectBegin()
+			mock.ExpectExec(regexp.QuoteMeta(tc.ExpectedUpdate)).
+				WithArgs(toDriverValues(tc.ExpectedArgs)...).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+
+			claimed, err := relay.ProcessBatch(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, 1, claimed)
+			assert.Empty(t, publisher.Events())
+			assert.Equal(t, 1.0, testutil.ToFloat64(relay.metrics.failures.WithLabelValues(tc.ExpectedFinal)))
+			assert.Equal(t, 0.0, testutil.ToFloat64(relay.metrics.published))
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRelay_ProcessBatch_ReleasesWhenLeaseRunsOut(t *testing.T) {
+	publisher := NewMemoryPublisher()
+	publisher.FailWith(func(Event) error {
+		time.Sleep(60 * time.Millisecond)
+		return nil
+	})
+	relay, mock := newTestRelay(t, publisher, RelayConfig{PublishTimeout: 50 * time.Millisecond, LeaseDuration: 100 * time.Millisecond})
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(claimQuery)).
+		WillReturnRows(newRecordRows().
+			AddRow(1, "order", "42", "order.created", []byte(`{}`), []byte(`{}`), StatusPending, 0, nil, now, now, nil).
+			AddRow(2, "order", "42", "order.paid", []byte(`{}`), []byte(`{}`), StatusPending, 0, nil, now, now, nil))
+	mock.ExpectExec(regexp.QuoteMeta(leaseQuery)).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "outbox_events" SET "attempts"=attempts + 1,"last_error"=$1,"published_at"=NOW(),"status"=$2 WHERE id IN ($3) AND status = $4`)).
+		WithArgs(nil, StatusPublished, 1, StatusPending).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// The second event would outlive the lease, so it is handed back instead of published
+	mock.ExpectExec(regexp.QuoteMeta(releaseQuery+`$1) AND status = $2`)).
+		WithArgs(2, StatusPending).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	claimed, err := relay.ProcessBatch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, claimed)
+	require.Len(t, publisher.Events(), 1)
+	assert.Equal(t, int64(1), publisher.Events()[0].ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRelay_ProcessBatch_ClaimError(t *testing.T) {
+	relay, mock := newTestRelay(t, NewMemoryPublisher(), RelayConfig{})
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(claimQuery)).WillReturnError(stderrors.New("connection reset"))
+	mock.ExpectRollback()
+
+	_, err := relay.ProcessBatch(context.Background())
+	require.Error(t, err)
+	assert.NotNil(t, errors.GetAppError(err))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRelay_Backoff(t *testing.T) {
+	relay := &Relay{cfg: RelayConfig{InitialBackoff: time.Second, MaxBackoff: 10 * time.Second}}
+
+	testCases := []struct {
+		Name        string
+		Attempts    int
+		ExpectedMax time.Duration
+	}{
+		{Name: "first retry", Attempts: 1, ExpectedMax: time.Second},
+		{Name: "doubles", Attempts: 3, ExpectedMax: 4 * time.Second},
+		{Name: "capped", Attempts: 10, ExpectedMax: 10 * time.Second},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			for i := 0; i < 20; i++ {
+				delay := relay.backoff(tc.Attempts)
+				assert.GreaterOrEqual(t, delay, tc.ExpectedMax/2)
+				assert.LessOrEqual(t, delay, tc.ExpectedMax)
+			}
+		})
+	}
+}
+
+func TestRelay_Run(t *testing.T) {
+	publisher := NewMemoryPublisher()
+	relay, mock := newTestRelay(t, publisher, RelayConfig{PollInterval: time.Hour, Registerer: prometheus.NewRegistry()})
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(claimQuery)).
+		WillReturnRows(newRecordRows().
+			AddRow(1, "order", "42", "order.created", []byte(`{}`), []byte(`{}`), StatusPending, 0, nil, now, now, nil))
+	mock.ExpectExec(regexp.QuoteMeta(leaseQuery)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "outbox_events"`)).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) AS pending, COALESCE(EXTRACT(EPOCH FROM NOW() - MIN(created_at)), 0) AS lag FROM "outbox_events" WHERE status = $1`)).
+		WithArgs(StatusPending).
+		WillReturnRows(sqlmock.NewRows([]string{"pending", "lag"}).AddRow(4, 12.5))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- relay.Run(ctx) }()
+
+	require.Eventually(t, func() bool { return mock.ExpectationsWereMet() == nil }, time.Second, 5*time.Millisecond)
+	cancel()
+	require.NoError(t, <-done)
+
+	assert.Len(t, publisher.Events(), 1)
+	assert.Equal(t, 4.0, testutil.ToFloat64(relay.metrics.pending))
+	assert.Equal(t, 12.5, testutil.ToFloat64(relay.metrics.lag))
+}
+
+func TestRelay_Purge(t *testing.T) {
+	relay, mock := newTestRelay(t, NewMemoryPublisher(), RelayConfig{})
+	before := time.Now().Add(-24 * time.Hour)
+
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "outbox_events" WHERE status = $1 AND published_at < $2`)).
+		WithArgs(StatusPublished, before).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	purged, err := relay.Purge(context.Background(), before)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), purged)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestNewRelayMetrics_Reuse(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	first, err := newRelayMetrics(reg)
+	require.NoError(t, err)
+	second, err := newRelayMetrics(reg)
+	require.NoError(t, err)
+
+	first.published.Inc()
+	assert.Equal(t, 1.0, testutil.ToFloat64(second.published))
+}
+
+// toDriverValues converts expected arguments for sqlmock
+func toDriverValues(args []interface{}) []driver.Value {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg
+	}
+	return values
+}
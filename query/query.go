@@ -0,0 +1,367 @@
+package query
+
+import (
+	stderrors "errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/medbai2/common-go/errors"
+	"github.com/medbai2/common-go/validation"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Operator is a filter comparison
+type Operator string
+
+const (
+	OpEq   Operator = "eq"   // status=active or status[eq]=active
+	OpIn   Operator = "in"   // status[in]=active,pending
+	OpGt   Operator = "gt"   // created_at[gt]=2024-01-01
+	OpLt   Operator = "lt"   // created_at[lt]=2024-02-01
+	OpLike Operator = "like" // name[like]=smith (substring match; % and _ are matched literally)
+)
+
+// Reserved query parameters that are never treated as filters
+const (
+	ParamSort     = "sort"
+	ParamFields   = "fields"
+	ParamPage     = "page"
+	ParamPageSize = "pageSize"
+)
+
+// Default pagination bounds
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+	MaxPage         = 1000 // Deeper pages make the database skip too many rows; use cursor pagination
+	MaxInValues     = 100
+)
+
+// ignoredParams are consumed elsewhere (e.g. response.ParseCursorParams) and skipped here
+var ignoredParams = map[string]bool{"cursor": true, "limit": true}
+
+// Field describes what clients may do with one query field. Only fields listed in a
+// Spec can be sorted, filtered or selected, which keeps column names out of client control.
+type Field struct {
+	Column     string     // Database column (default: the field name)
+	Sortable   bool       // Allowed in ?sort=
+	Selectable bool       // Allowed in ?fields=
+	Operators  []Operator // Allowed filter operators (none: not filterable)
+	Validate   string     // validator tag applied to every filter value, e.g. "oneof=active inactive" or "datetime=2006-01-02"
+}
+
+// Spec is the per-endpoint allow-list of query fields
+type Spec struct {
+	Fields          map[string]Field
+	DefaultSort     string // Applied when ?sort= is absent, same syntax (e.g. "-created_at,id")
+	DefaultPageSize int    // default: 20
+	MaxPageSize     int    // default: 100
+	MaxPage         int    // default: 1000
+}
+
+// Sort is one ORDER BY term
+type Sort struct {
+	Field  string
+	Column string
+	Desc   bool
+}
+
+// Filter is one WHERE condition
+type Filter struct {
+	Field    string
+	Column   string
+	Operator Operator
+	Values   []string // One value, or several for OpIn
+}
+
+// Query is a parsed and validated list request
+type Query struct {
+	Sorts    []Sort
+	Filters  []Filter
+	Fields   []string // Selected columns (empty: all)
+	Page     int
+	PageSize int
+}
+
+// Offset returns the number of rows skipped before the current page
+func (q *Query) Offset() int {
+	return (q.Page - 1) * q.PageSize
+}
+
+// Parser parses list requests against Specs, validating filter values with a ValidatorService
+type Parser struct {
+	validator *validation.ValidatorService
+}
+
+// NewParser creates a parser; a nil validator uses validation.NewValidatorService()
+func NewParser(validator *validation.ValidatorService) *Parser {
+	if validator == nil {
+		validator = validation.NewValidatorService()
+	}
+	return &Parser{validator: validator}
+}
+
+// Parse parses sort, filter, field selection and pagination parameters, e.g.
+//
+//	?sort=-created_at,name&status[in]=active,pending&name[like]=smi&fields=id,name&page=2&pageSize=50
+//
+// Every problem is reported at once as an INVALID_INPUT AppError whose Details list the
+// offending parameters.
+func (p *Parser) Parse(values url.Values, spec Spec) (*Query, error) {
+	var problems []validation.ValidationError
+	addProblem := func(field, code, format string, args ...interface{}) {
+		problems = append(problems, validation.ValidationError{Field: field, Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+
+	q := &Query{}
+
+	sortParam := values.Get(ParamSort)
+	if sortParam == "" {
+		sortParam = spec.DefaultSort
+	}
+	for _, term := range splitList(sortParam) {
+		name, desc := strings.TrimPrefix(term, "-"), strings.HasPrefix(term, "-")
+		field, ok := spec.Fields[name]
+		if !ok || !field.Sortable {
+			addProblem(ParamSort, "not_sortable", "cannot sort by %q", name)
+			continue
+		}
+		q.Sorts = append(q.Sorts, Sort{Field: name, Column: columnName(name, field), Desc: desc})
+	}
+
+	for _, name := range splitList(values.Get(ParamFields)) {
+		field, ok := spec.Fields[name]
+		if !ok || !field.Selectable {
+			addProblem(ParamFields, "not_selectable", "cannot select %q", name)
+			continue
+		}
+		q.Fields = append(q.Fields, columnName(name, field))
+	}
+
+	q.PageSize = spec.DefaultPageSize
+	if q.PageSize <= 0 {
+		q.PageSize = DefaultPageSize
+	}
+	maxPageSize := spec.MaxPageSize
+	if maxPageSize <= 0 {
+		maxPageSize = MaxPageSize
+	}
+	maxPage := spec.MaxPage
+	if maxPage <= 0 {
+		maxPage = MaxPage
+	}
+	q.Page = 1
+	if raw := values.Get(ParamPage); raw != "" {
+		page, err := strconv.Atoi(raw)
+		switch {
+		case stderrors.Is(err, strconv.ErrRange) || page > maxPage:
+			addProblem(ParamPage, "max", "%s must be at most %d", ParamPage, maxPage)
+		case err != nil || page < 1:
+			addProblem(ParamPage, "min", "%s must be a positive integer", ParamPage)
+		default:
+			q.Page = page
+		}
+	}
+	if raw := values.Get(ParamPageSize); raw != "" {
+		pageSize, err := strconv.Atoi(raw)
+		if err != nil || pageSize < 1 || pageSize > maxPageSize {
+			addProblem(ParamPageSize, "max", "%s must be an integer between 1 and %d", ParamPageSize, maxPageSize)
+		} else {
+			q.PageSize = pageSize
+		}
+	}
+
+	// Sorted so filters (and therefore the generated SQL and error details) are deterministic
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if key == ParamSort || key == ParamFields || key == ParamPage || key == ParamPageSize || ignoredParams[key] {
+			continue
+		}
+		name, op, ok := parseFilterKey(key)
+		if !ok {
+			addProblem(key, "invalid_filter", "malformed filter parameter")
+			continue
+		}
+		field, known := spec.Fields[name]
+		if !known || len(field.Operators) == 0 {
+			addProblem(key, "not_filterable", "cannot filter by %q", name)
+			continue
+		}
+		if !allowsOperator(field, op) {
+			addProblem(key, "invalid_operator", "operator %q is not allowed for %q", op, name)
+			continue
+		}
+
+		for _, raw := range values[key] {
+			filterValues := []string{raw}
+			if op == OpIn {
+				filterValues = splitList(raw)
+				if len(filterValues) == 0 || len(filterValues) > MaxInValues {
+					addProblem(key, "invalid_in", "between 1 and %d values are required", MaxInValues)
+					continue
+				}
+			}
+
+			valid := true
+			if field.Validate != "" {
+				for _, value := range filterValues {
+					if result := p.validator.ValidateField(value, field.Validate); !result.IsValid {
+						for _, fieldErr := range result.Errors {
+							addProblem(key, fieldErr.Code, "%q is not a valid value", value)
+						}
+						valid = false
+					}
+				}
+			}
+			if valid {
+				q.Filters = append(q.Filters, Filter{Field: name, Column: columnName(name, field), Operator: op, Values: filterValues})
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, invalidQueryError(problems)
+	}
+	return q, nil
+}
+
+// Parse parses values against spec with a default parser
+func Parse(values url.Values, spec Spec) (*Query, error) {
+	return NewParser(nil).Parse(values, spec)
+}
+
+// invalidQueryError builds the INVALID_INPUT error listing every problem
+func invalidQueryError(problems []validation.ValidationError) *errors.AppError {
+	messages := make([]string, 0, len(problems))
+	for _, problem := range problems {
+		messages = append(messages, problem.Error())
+	}
+	appErr := errors.NewInvalidInput("invalid query parameters")
+	appErr.Details = strings.Join(messages, "; ")
+	return appErr
+}
+
+// parseFilterKey splits "name[op]" into its parts; a plain "name" is an equality filter
+func parseFilterKey(key string) (string, Operator, bool) {
+	open := strings.IndexByte(key, '[')
+	if open < 0 {
+		return key, OpEq, key != ""
+	}
+	if open == 0 || !strings.HasSuffix(key, "]") {
+		return "", "", false
+	}
+	return key[:open], Operator(key[open+1 : len(key)-1]), true
+}
+
+// allowsOperator reports whether op is allowed for field
+func allowsOperator(field Field, op Operator) bool {
+	for _, allowed := range field.Operators {
+		if allowed == op {
+			return true
+		}
+	}
+	return false
+}
+
+// columnName returns the column of a field
+func columnName(name string, field Field) string {
+	if field.Column != "" {
+		return field.Column
+	}
+	return name
+}
+
+// splitList splits a comma-separated parameter, dropping empty entries
+func splitList(raw string) []string {
+	var items []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// FilterScope applies the filters. Use it on its own for the COUNT(*) behind response.Paginated.
+func (q *Query) FilterScope() func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		for _, f := range q.Filters {
+			column := clause.Column{Name: f.Column}
+			switch f.Operator {
+			case OpEq:
+				db = db.Where(clause.Eq{Column: column, Value: f.Values[0]})
+			case OpIn:
+				values := make([]interface{}, len(f.Values))
+				for i, v := range f.Values {
+					values[i] = v
+				}
+				db = db.Where(clause.IN{Column: column, Values: values})
+			case OpGt:
+				db = db.Where(clause.Gt{Column: column, Value: f.Values[0]})
+			case OpLt:
+				db = db.Where(clause.Lt{Column: column, Value: f.Values[0]})
+			case OpLike:
+				db = db.Where("? LIKE ? ESCAPE '\\'", column, "%"+escapeLike(f.Values[0])+"%")
+			}
+		}
+		return db
+	}
+}
+
+// SortScope applies the sort order
+func (q *Query) SortScope() func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		for _, s := range q.Sorts {
+			db = db.Order(clause.OrderByColumn{Column: clause.Column{Name: s.Column}, Desc: s.Desc})
+		}
+		return db
+	}
+}
+
+// SelectScope restricts the selected columns when ?fields= was given
+func (q *Query) SelectScope() func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if len(q.Fields) == 0 {
+			return db
+		}
+		return db.Select(q.Fields)
+	}
+}
+
+// PageScope applies LIMIT and OFFSET for the requested page
+func (q *Query) PageScope() func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Offset(q.Offset()).Limit(q.PageSize)
+	}
+}
+
+// Scope applies filters, sorting, field selection and pagination. Column names only
+// come from the Spec and values are always bound as parameters.
+//
+// Usage:
+//
+//	q, err := query.Parse(c.Request.URL.Query(), spec)
+//	db.Model(&Order{}).Scopes(q.FilterScope()).Count(&total)
+//	db.Scopes(q.Scope()).Find(&orders)
+//	response.Paginated(c, orders, q.Page, q.PageSize, int(total))
+func (q *Query) Scope() func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Scopes(q.FilterScope(), q.SortScope(), q.SelectScope(), q.PageScope())
+	}
+}
+
+// escapeLike escapes LIKE wildcards so they match literally
+func escapeLike(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return replacer.Replace(value)
+}
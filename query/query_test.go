@@ -0,0 +1,193 @@
+package query
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/medbai2/common-go/errors"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// order is the model used by the scope tests
+type order struct {
+	ID     int64
+	Status string
+	Name   string
+}
+
+// orderSpec is the allow-list used by the tests
+var orderSpec = Spec{
+	Fields: map[string]Field{
+		"id":        {Sortable: true, Selectable: true, Operators: []Operator{OpEq, OpIn}, Validate: "numeric"},
+		"status":    {Selectable: true, Operators: []Operator{OpEq, OpIn}, Validate: "oneof=active pending closed"},
+		"name":      {Sortable: true, Selectable: true, Operators: []Operator{OpEq, OpLike}},
+		"createdAt": {Column: "created_at", Sortable: true, Operators: []Operator{OpGt, OpLt}, Validate: "datetime=2006-01-02"},
+	},
+	DefaultSort: "-createdAt",
+	MaxPageSize: 50,
+}
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		Name             string
+		Query            string
+		ExpectedSorts    []Sort
+		ExpectedFilters  []Filter
+		ExpectedFields   []string
+		ExpectedPage     int
+		ExpectedPageSize int
+	}{
+		{
+			Name:             "Defaults",
+			Query:            "",
+			ExpectedSorts:    []Sort{{Field: "createdAt", Column: "created_at", Desc: true}},
+			ExpectedPage:     1,
+			ExpectedPageSize: DefaultPageSize,
+		},
+		{
+			Name:  "Everything",
+			Query: "sort=-createdAt,name&status=active&id[in]=1,2&name[like]=smi&createdAt[gt]=2024-01-01&fields=id,name&page=2&pageSize=50",
+			ExpectedSorts: []Sort{
+				{Field: "createdAt", Column: "created_at", Desc: true},
+				{Field: "name", Column: "name"},
+			},
+			ExpectedFilters: []Filter{
+				{Field: "createdAt", Column: "created_at", Operator: OpGt, Values: []string{"2024-01-01"}},
+				{Field: "id", Column: "id", Operator: OpIn, Values: []string{"1", "2"}},
+				{Field: "name", Column: "name", Operator: OpLike, Values: []string{"smi"}},
+				{Field: "status", Column: "status", Operator: OpEq, Values: []string{"active"}},
+			},
+			ExpectedFields:   []string{"id", "name"},
+			ExpectedPage:     2,
+			ExpectedPageSize: 50,
+		},
+		{
+			Name:             "Deepest Page",
+			Query:            "page=1000",
+			ExpectedSorts:    []Sort{{Field: "createdAt", Column: "created_at", Desc: true}},
+			ExpectedPage:     1000,
+			ExpectedPageSize: DefaultPageSize,
+		},
+		{
+			Name:             "Cursor Parameters Are Ignored",
+			Query:            "cursor=abc&limit=10&status[eq]=closed",
+			ExpectedSorts:    []Sort{{Field: "createdAt", Column: "created_at", Desc: true}},
+			ExpectedFilters:  []Filter{{Field: "status", Column: "status", Operator: OpEq, Values: []string{"closed"}}},
+			ExpectedPage:     1,
+			ExpectedPageSize: DefaultPageSize,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			values, err := url.ParseQuery(tc.Query)
+			require.NoError(t, err)
+
+			q, err := Parse(values, orderSpec)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.ExpectedSorts, q.Sorts)
+			assert.Equal(t, tc.ExpectedFilters, q.Filters)
+			assert.Equal(t, tc.ExpectedFields, q.Fields)
+			assert.Equal(t, tc.ExpectedPage, q.Page)
+			assert.Equal(t, tc.ExpectedPageSize, q.PageSize)
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	testCases := []struct {
+		Name            string
+		Query           string
+		ExpectedDetails []string
+	}{
+		{Name: "Unknown Sort Field", Query: "sort=password", ExpectedDetails: []string{`field 'sort': cannot sort by "password"`}},
+		{Name: "Not Sortable", Query: "sort=status", ExpectedDetails: []string{`cannot sort by "status"`}},
+		{Name: "Unknown Filter", Query: "password=x", ExpectedDetails: []string{`field 'password': cannot filter by "password"`}},
+		{Name: "Operator Not Allowed", Query: "status[like]=act", ExpectedDetails: []string{`field 'status[like]': operator "like" is not allowed`}},
+		{Name: "Malformed Filter", Query: "status[eq=active", ExpectedDetails: []string{"malformed filter parameter"}},
+		{Name: "Invalid Value", Query: "status=deleted", ExpectedDetails: []string{`field 'status': "deleted" is not a valid value`}},
+		{Name: "Invalid In Value", Query: "id[in]=1,x", ExpectedDetails: []string{`field 'id[in]': "x" is not a valid value`}},
+		{Name: "Empty In", Query: "id[in]=,", ExpectedDetails: []string{"between 1 and 100 values are required"}},
+		{Name: "Not Selectable", Query: "fields=createdAt", ExpectedDetails: []string{`cannot select "createdAt"`}},
+		{Name: "Invalid Page", Query: "page=0", ExpectedDetails: []string{"page must be a positive integer"}},
+		{Name: "Page Too Large", Query: "page=1001", ExpectedDetails: []string{"field 'page': page must be at most 1000"}},
+		{Name: "Page Out Of Range", Query: "page=99999999999999999999", ExpectedDetails: []string{"page must be at most 1000"}},
+		{Name: "Page Size Too Large", Query: "pageSize=51", ExpectedDetails: []string{"pageSize must be an integer between 1 and 50"}},
+		{
+			Name:  "All Problems Reported",
+			Query: "sort=secret&status=deleted&page=x",
+			ExpectedDetails: []string{
+				`cannot sort by "secret"`,
+				`"deleted" is not a valid value`,
+				"page must be a positive integer",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			values, err := url.ParseQuery(tc.Query)
+			require.NoError(t, err)
+
+			q, err := Parse(values, orderSpec)
+
+			assert.Nil(t, q)
+			appErr := errors.GetAppError(err)
+			require.NotNil(t, appErr)
+			assert.Equal(t, errors.ErrCodeInvalidInput, appErr.Code)
+			for _, detail := range tc.ExpectedDetails {
+				assert.Contains(t, appErr.Details, detail)
+			}
+		})
+	}
+}
+
+// newDryRunDB returns a PostgreSQL GORM handle that only builds statements
+func newDryRunDB(t *testing.T) *gorm.DB {
+	mockDB, _, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { mockDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: mockDB}), &gorm.Config{
+		Logger:               gormlogger.Discard,
+		DisableAutomaticPing: true,
+		DryRun:               true,
+	})
+	require.NoError(t, err)
+	return db
+}
+
+func TestQuery_Scope(t *testing.T) {
+	values, err := url.ParseQuery("sort=name&status[in]=active,pending&name[like]=50%25_off&createdAt[lt]=2024-02-01&fields=id,name&page=3&pageSize=10")
+	require.NoError(t, err)
+	q, err := Parse(values, orderSpec)
+	require.NoError(t, err)
+
+	stmt := newDryRunDB(t).Scopes(q.Scope()).Find(&[]order{}).Statement
+
+	assert.Equal(t,
+		`SELECT "id","name" FROM "orders" WHERE "created_at" < $1 AND "name" LIKE $2 ESCAPE '\' AND "status" IN ($3,$4) ORDER BY "name" LIMIT $5 OFFSET $6`,
+		stmt.SQL.String())
+	assert.Equal(t, []interface{}{"2024-02-01", `%50\%\_off%`, "active", "pending", 10, 20}, stmt.Vars)
+}
+
+func TestQuery_FilterScope(t *testing.T) {
+	values, err := url.ParseQuery("id=7&sort=-name&page=2")
+	require.NoError(t, err)
+	q, err := Parse(values, orderSpec)
+	require.NoError(t, err)
+
+	var total int64
+	stmt := newDryRunDB(t).Model(&order{}).Scopes(q.FilterScope()).Count(&total).Statement
+
+	// Counting ignores sort and pagination
+	assert.Equal(t, `SELECT count(*) FROM "orders" WHERE "id" = $1`, stmt.SQL.String())
+	assert.Equal(t, DefaultPageSize, q.Offset())
+}
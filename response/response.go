@@ -1,10 +1,13 @@
 package response
 
 import (
+	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/medbai2/common-go/errors"
+	"github.com/medbai2/common-go/logger"
 
 	"github.com/gin-gonic/gin"
 )
@@ -84,6 +87,7 @@ func Error(c *gin.Context, err error) {
 		RequestID: getRequestID(c),
 	}
 
+	c.Set(ErrorCodeKey, apiError.Code)
 	c.JSON(appErr.HTTPStatus, response)
 }
 
@@ -108,6 +112,7 @@ func ErrorWithMessage(c *gin.Context, err error, message string) {
 		RequestID: getRequestID(c),
 	}
 
+	c.Set(ErrorCodeKey, apiError.Code)
 	c.JSON(appErr.HTTPStatus, response)
 }
 
@@ -125,6 +130,7 @@ func BadRequest(c *gin.Context, message string) {
 		RequestID: getRequestID(c),
 	}
 
+	c.Set(ErrorCodeKey, apiError.Code)
 	c.JSON(http.StatusBadRequest, response)
 }
 
@@ -146,6 +152,7 @@ func Unauthorized(c *gin.Context, message string) {
 		RequestID: getRequestID(c),
 	}
 
+	c.Set(ErrorCodeKey, apiError.Code)
 	c.JSON(http.StatusUnauthorized, response)
 }
 
@@ -167,6 +174,7 @@ func Forbidden(c *gin.Context, message string) {
 		RequestID: getRequestID(c),
 	}
 
+	c.Set(ErrorCodeKey, apiError.Code)
 	c.JSON(http.StatusForbidden, response)
 }
 
@@ -186,6 +194,7 @@ func NotFound(c *gin.Context, resource string) {
 		RequestID: getRequestID(c),
 	}
 
+	c.Set(ErrorCodeKey, apiError.Code)
 	c.JSON(http.StatusNotFound, response)
 }
 
@@ -203,6 +212,7 @@ func Conflict(c *gin.Context, message string) {
 		RequestID: getRequestID(c),
 	}
 
+	c.Set(ErrorCodeKey, apiError.Code)
 	c.JSON(http.StatusConflict, response)
 }
 
@@ -224,6 +234,7 @@ func InternalServerError(c *gin.Context, message string) {
 		RequestID: getRequestID(c),
 	}
 
+	c.Set(ErrorCodeKey, apiError.Code)
 	c.JSON(http.StatusInternalServerError, response)
 }
 
@@ -245,6 +256,7 @@ func ServiceUnavailable(c *gin.Context, message string) {
 		RequestID: getRequestID(c),
 	}
 
+	c.Set(ErrorCodeKey, apiError.Code)
 	c.JSON(http.StatusServiceUnavailable, response)
 }
 
@@ -281,6 +293,78 @@ func Paginated(c *gin.Context, data interface{}, page, pageSize, total int) {
 	c.JSON(http.StatusOK, response)
 }
 
+// Default bounds for cursor pagination query parameters
+const (
+	DefaultCursorLimit = 20
+	MaxCursorLimit     = 100
+)
+
+// CursorParams are the cursor pagination parameters of a request
+type CursorParams struct {
+	Cursor string // Opaque cursor from a previous page (empty for the first page)
+	Limit  int    // Page size, within the bounds given to ParseCursorParams
+}
+
+// ParseCursorParams reads the "cursor" and "limit" query parameters. A missing limit uses
+// defaultLimit and a larger one is capped at maxLimit (non-positive bounds fall back to
+// DefaultCursorLimit and MaxCursorLimit); a limit that is not a positive integer is rejected
+// with an INVALID_INPUT error.
+func ParseCursorParams(c *gin.Context, defaultLimit, maxLimit int) (CursorParams, error) {
+	if maxLimit <= 0 {
+		maxLimit = MaxCursorLimit
+	}
+	if defaultLimit <= 0 {
+		defaultLimit = DefaultCursorLimit
+	}
+	if defaultLimit > maxLimit {
+		defaultLimit = maxLimit
+	}
+
+	params := CursorParams{Cursor: c.Query("cursor"), Limit: defaultLimit}
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			return CursorParams{}, errors.NewWithDetails(errors.ErrCodeInvalidInput, "invalid limit", fmt.Sprintf("limit must be an integer between 1 and %d", maxLimit))
+		}
+		if limit > maxLimit {
+			limit = maxLimit
+		}
+		params.Limit = limit
+	}
+	return params, nil
+}
+
+// CursorPaginated sends a keyset-paginated response. Empty cursors are sent as null.
+func CursorPaginated(c *gin.Context, data interface{}, nextCursor, prevCursor string, hasMore bool) {
+	pagination := map[string]interface{}{
+		"nextCursor": nullableString(nextCursor),
+		"prevCursor": nullableString(prevCursor),
+		"hasMore":    hasMore,
+	}
+
+	responseData := map[string]interface{}{
+		"items":      data,
+		"pagination": pagination,
+	}
+
+	response := APIResponse{
+		Success:   true,
+		Data:      responseData,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		RequestID: getRequestID(c),
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// nullableString returns nil for an empty string so it is encoded as JSON null
+func nullableString(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
 // ValidationError sends a validation error response
 func ValidationError(c *gin.Context, validationErr error) {
 	appErr := errors.GetAppError(validationErr)
@@ -302,16 +386,30 @@ func ValidationError(c *gin.Context, validationErr error) {
 		RequestID: getRequestID(c),
 	}
 
+	c.Set(ErrorCodeKey, apiError.Code)
 	c.JSON(http.StatusBadRequest, response)
 }
 
-// getRequestID extracts request ID from gin context
+// ErrorCodeKey is the gin context key holding the error code of an error response, so
+// middleware running after the handler (e.g. middleware.Metrics) can read it
+const ErrorCodeKey = "errorCode"
+
+// GetErrorCode returns the error code of the error response written for c, or "" when
+// the response is not an error written by this package
+func GetErrorCode(c *gin.Context) string {
+	return c.GetString(ErrorCodeKey)
+}
+
+// getRequestID extracts request ID from gin context, falling back to the request context
 func getRequestID(c *gin.Context) string {
-	if requestID, exists := c.Get("requestId"); exists {
+	if requestID, exists := c.Get(string(logger.RequestIDKey)); exists {
 		if id, ok := requestID.(string); ok {
 			return id
 		}
 	}
+	if c.Request != nil {
+		return logger.GetRequestID(c.Request.Context())
+	}
 	return ""
 }
 
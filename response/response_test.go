@@ -4,9 +4,11 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	appErrors "github.com/medbai2/common-go/errors"
+	"github.com/medbai2/common-go/logger"
 	"github.com/medbai2/common-go/testutils"
 
 	"github.com/gin-gonic/gin"
@@ -391,3 +393,111 @@ func TestRequestIDExtraction(t *testing.T) {
 
 	hts.AssertEqual("test-request-123", response.RequestID)
 }
+
+func TestRequestIDExtraction_FromRequestContext(t *testing.T) {
+	hts := testutils.NewHTTPTestSuite(t)
+
+	hts.Router.GET("/test", func(c *gin.Context) {
+		c.Request = c.Request.WithContext(logger.WithRequestID(c.Request.Context(), "ctx-request-456"))
+		Success(c, map[string]string{"message": "test"})
+	})
+
+	req := hts.SetupRequest(http.MethodGet, "/test")
+	hts.ExecuteRequest(req)
+
+	var response APIResponse
+	err := json.Unmarshal(hts.Recorder.Body.Bytes(), &response)
+	require.NoError(t, err)
+
+	hts.AssertEqual("ctx-request-456", response.RequestID)
+}
+
+func TestGetErrorCode(t *testing.T) {
+	testCases := []struct {
+		Name         string
+		Write        func(c *gin.Context)
+		ExpectedCode string
+	}{
+		{Name: "error", Write: func(c *gin.Context) { Error(c, appErrors.NewNotFound("order")) }, ExpectedCode: string(appErrors.ErrCodeNotFound)},
+		{Name: "non-app error", Write: func(c *gin.Context) { Error(c, errors.New("boom")) }, ExpectedCode: string(appErrors.ErrCodeInternal)},
+		{Name: "bad request", Write: func(c *gin.Context) { BadRequest(c, "bad") }, ExpectedCode: string(appErrors.ErrCodeInvalidInput)},
+		{Name: "service unavailable", Write: func(c *gin.Context) { ServiceUnavailable(c, "") }, ExpectedCode: string(appErrors.ErrCodeServiceUnavailable)},
+		{Name: "success", Write: func(c *gin.Context) { Success(c, nil) }, ExpectedCode: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+			tc.Write(c)
+			assert.Equal(t, tc.ExpectedCode, GetErrorCode(c))
+		})
+	}
+}
+
+func TestParseCursorParams(t *testing.T) {
+	testCases := []struct {
+		Name           string
+		Query          string
+		ExpectedCursor string
+		ExpectedLimit  int
+		ExpectedError  bool
+	}{
+		{Name: "Defaults", Query: "", ExpectedLimit: 20},
+		{Name: "Cursor And Limit", Query: "?cursor=abc.def&limit=5", ExpectedCursor: "abc.def", ExpectedLimit: 5},
+		{Name: "Limit Capped", Query: "?limit=1000", ExpectedLimit: 50},
+		{Name: "Zero Limit", Query: "?limit=0", ExpectedError: true},
+		{Name: "Negative Limit", Query: "?limit=-1", ExpectedError: true},
+		{Name: "Non-Numeric Limit", Query: "?limit=ten", ExpectedError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			hts := testutils.NewHTTPTestSuite(t)
+
+			var params CursorParams
+			var parseErr error
+			hts.Router.GET("/items", func(c *gin.Context) {
+				params, parseErr = ParseCursorParams(c, 20, 50)
+			})
+			hts.ExecuteRequest(hts.SetupRequest(http.MethodGet, "/items"+tc.Query))
+
+			if tc.ExpectedError {
+				appErr := appErrors.GetAppError(parseErr)
+				require.NotNil(t, appErr)
+				assert.Equal(t, appErrors.ErrCodeInvalidInput, appErr.Code)
+				return
+			}
+			require.NoError(t, parseErr)
+			assert.Equal(t, tc.ExpectedCursor, params.Cursor)
+			assert.Equal(t, tc.ExpectedLimit, params.Limit)
+		})
+	}
+}
+
+func TestCursorPaginated(t *testing.T) {
+	hts := testutils.NewHTTPTestSuite(t)
+
+	hts.Router.GET("/test-cursor", func(c *gin.Context) {
+		CursorPaginated(c, []map[string]interface{}{{"id": 1}}, "next-cursor", "", true)
+	})
+
+	hts.ExecuteRequest(hts.SetupRequest(http.MethodGet, "/test-cursor"))
+	hts.AssertResponseStatus(http.StatusOK)
+
+	var response APIResponse
+	err := json.Unmarshal(hts.Recorder.Body.Bytes(), &response)
+	require.NoError(t, err)
+	hts.AssertTrue(response.Success)
+
+	data, ok := response.Data.(map[string]interface{})
+	require.True(t, ok)
+	assert.Len(t, data["items"], 1)
+
+	pagination, ok := data["pagination"].(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, "next-cursor", pagination["nextCursor"])
+	assert.Nil(t, pagination["prevCursor"])
+	assert.Contains(t, pagination, "prevCursor")
+	assert.Equal(t, true, pagination["hasMore"])
+}
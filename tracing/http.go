@@ -0,0 +1,84 @@
+package tracing
+
+import (
+	"net/http"
+	"strconv"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Transport is an http.RoundTripper that records a client span per outbound request and
+// injects the traceparent/tracestate headers, so the called service joins the trace
+type Transport struct {
+	Base           http.RoundTripper             // default: http.DefaultTransport
+	TracerProvider trace.TracerProvider          // default: the global provider
+	Propagator     propagation.TextMapPropagator // default: the global propagator
+}
+
+// NewTransport wraps base (http.DefaultTransport when nil) with tracing.
+//
+// Usage:
+//
+//	client := &http.Client{Transport: tracing.NewTransport(nil), Timeout: 10 * time.Second}
+//	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil) // ctx carries the server span
+//	resp, err := client.Do(req)
+func NewTransport(base http.RoundTripper) *Transport {
+	return &Transport{Base: base}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	propagator := t.Propagator
+	if propagator == nil {
+		propagator = otel.GetTextMapPropagator()
+	}
+
+	attributes := []attribute.KeyValue{
+		semconv.HTTPRequestMethodKey.String(req.Method),
+		semconv.URLFull(redactedURL(req)),
+		semconv.ServerAddress(req.URL.Hostname()),
+	}
+	if port, err := strconv.Atoi(req.URL.Port()); err == nil {
+		attributes = append(attributes, semconv.ServerPort(port))
+	}
+	ctx, span := Tracer(t.TracerProvider).Start(req.Context(), req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attributes...),
+	)
+	defer span.End()
+
+	// RoundTrippers must not modify the caller's request
+	req = req.Clone(ctx)
+	propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+	}
+	return resp, nil
+}
+
+// redactedURL returns the request URL without credentials
+func redactedURL(req *http.Request) string {
+	u := *req.URL
+	u.User = nil
+	if u.Host == "" {
+		u.Host = req.Host
+	}
+	return u.String()
+}
@@ -0,0 +1,149 @@
+// Package tracing sets up OpenTelemetry distributed tracing with W3C Trace Context
+// propagation. Call Init once at startup; the gin middleware (middleware.Tracing), the
+// database query callbacks (database.RegisterTracing), the outbound HTTP transport
+// (NewTransport) and context loggers (logger.NewContextLogger) then share one trace per
+// request across services.
+package tracing
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/medbai2/common-go/errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the instrumentation scope of the spans created by this library
+const TracerName = "github.com/medbai2/common-go"
+
+// Config configures the tracer provider installed by Init
+type Config struct {
+	ServiceName    string                // Reported as service.name (required)
+	ServiceVersion string                // Reported as service.version
+	Environment    string                // Reported as deployment.environment.name
+	Exporter       sdktrace.SpanExporter // Where spans go: NewOTLPExporter, or tracetest.NewInMemoryExporter in tests (required)
+	SampleRatio    float64               // Share of new traces recorded, 0 to 1 (default: 1); traces started upstream follow the caller's decision
+	Synchronous    bool                  // Export each span as it ends instead of in batches (tests, short-lived jobs)
+}
+
+// Init installs a tracer provider and the W3C Trace Context (traceparent/tracestate)
+// and Baggage propagators as the OpenTelemetry globals. Call Shutdown on the returned
+// provider before exiting to flush buffered spans.
+//
+// Usage:
+//
+//	exporter, err := tracing.NewOTLPExporter(ctx, tracing.OTLPConfig{Endpoint: "otel-collector:4318", Insecure: true})
+//	tp, err := tracing.Init(tracing.Config{ServiceName: "orders-api", Exporter: exporter})
+//	defer tp.Shutdown(context.Background())
+func Init(cfg Config) (*sdktrace.TracerProvider, error) {
+	tp, err := NewTracerProvider(cfg)
+	if err != nil {
+		return nil, err
+	}
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(Propagator())
+	return tp, nil
+}
+
+// NewTracerProvider creates a tracer provider without installing it globally, for
+// tests and services that pass providers explicitly
+func NewTracerProvider(cfg Config) (*sdktrace.TracerProvider, error) {
+	if strings.TrimSpace(cfg.ServiceName) == "" {
+		return nil, errors.NewMissingField("serviceName")
+	}
+	if cfg.Exporter == nil {
+		return nil, errors.NewMissingField("exporter")
+	}
+	if cfg.SampleRatio < 0 || cfg.SampleRatio > 1 {
+		return nil, errors.NewInvalidInput("sampleRatio must be between 0 and 1")
+	}
+
+	attributes := []attribute.KeyValue{semconv.ServiceName(cfg.ServiceName)}
+	if cfg.ServiceVersion != "" {
+		attributes = append(attributes, semconv.ServiceVersion(cfg.ServiceVersion))
+	}
+	if cfg.Environment != "" {
+		attributes = append(attributes, semconv.DeploymentEnvironmentName(cfg.Environment))
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL, attributes...))
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrCodeInternal, "failed to build tracing resource")
+	}
+
+	sampler := sdktrace.AlwaysSample()
+	if cfg.SampleRatio > 0 && cfg.SampleRatio < 1 {
+		sampler = sdktrace.TraceIDRatioBased(cfg.SampleRatio)
+	}
+
+	var processor sdktrace.SpanProcessor
+	if cfg.Synchronous {
+		processor = sdktrace.NewSimpleSpanProcessor(cfg.Exporter)
+	} else {
+		processor = sdktrace.NewBatchSpanProcessor(cfg.Exporter)
+	}
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sampler)),
+		sdktrace.WithSpanProcessor(processor),
+	), nil
+}
+
+// Propagator returns the W3C Trace Context and Baggage propagator
+func Propagator() propagation.TextMapPropagator {
+	return propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+}
+
+// Tracer returns this library's tracer from tp, or from the global provider when tp is nil
+func Tracer(tp trace.TracerProvider) trace.Tracer {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	return tp.Tracer(TracerName)
+}
+
+// OTLPConfig configures the OTLP/HTTP exporter. Unset fields fall back to the standard
+// OTEL_EXPORTER_OTLP_* environment variables.
+type OTLPConfig struct {
+	Endpoint string            // host:port of the collector (default: localhost:4318)
+	URLPath  string            // default: /v1/traces
+	Insecure bool              // Use plain HTTP (e.g. a collector sidecar)
+	Headers  map[string]string // Extra headers, e.g. authentication for a hosted backend
+	Timeout  time.Duration     // Per export request (default: 10s)
+}
+
+// NewOTLPExporter creates an exporter sending spans to an OpenTelemetry collector over
+// OTLP/HTTP
+func NewOTLPExporter(ctx context.Context, cfg OTLPConfig) (sdktrace.SpanExporter, error) {
+	var options []otlptracehttp.Option
+	if cfg.Endpoint != "" {
+		options = append(options, otlptracehttp.WithEndpoint(cfg.Endpoint))
+	}
+	if cfg.URLPath != "" {
+		options = append(options, otlptracehttp.WithURLPath(cfg.URLPath))
+	}
+	if cfg.Insecure {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+	if len(cfg.Headers) > 0 {
+		options = append(options, otlptracehttp.WithHeaders(cfg.Headers))
+	}
+	if cfg.Timeout > 0 {
+		options = append(options, otlptracehttp.WithTimeout(cfg.Timeout))
+	}
+
+	exporter, err := otlptracehttp.New(ctx, options...)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrCodeInternal, "failed to create OTLP trace exporter")
+	}
+	return exporter, nil
+}
@@ -0,0 +1,119 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/medbai2/common-go/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestNewTracerProvider(t *testing.T) {
+	testCases := []struct {
+		Name         string
+		Config       Config
+		ExpectedCode errors.ErrorCode
+	}{
+		{
+			Name:   "valid config",
+			Config: Config{ServiceName: "orders-api", ServiceVersion: "1.2.3", Environment: "test", Exporter: tracetest.NewInMemoryExporter()},
+		},
+		{
+			Name:         "missing service name",
+			Config:       Config{Exporter: tracetest.NewInMemoryExporter()},
+			ExpectedCode: errors.ErrCodeMissingField,
+		},
+		{
+			Name:         "missing exporter",
+			Config:       Config{ServiceName: "orders-api"},
+			ExpectedCode: errors.ErrCodeMissingField,
+		},
+		{
+			Name:         "sample ratio out of range",
+			Config:       Config{ServiceName: "orders-api", Exporter: tracetest.NewInMemoryExporter(), SampleRatio: 1.5},
+			ExpectedCode: errors.ErrCodeInvalidInput,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			tp, err := NewTracerProvider(tc.Config)
+			if tc.ExpectedCode != "" {
+				require.Error(t, err)
+				assert.Equal(t, tc.ExpectedCode, errors.GetAppError(err).Code)
+				return
+			}
+			require.NoError(t, err)
+			assert.NoError(t, tp.Shutdown(context.Background()))
+		})
+	}
+}
+
+func TestNewTracerProvider_ExportsSpans(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp, err := NewTracerProvider(Config{ServiceName: "orders-api", Exporter: exporter, Synchronous: true})
+	require.NoError(t, err)
+
+	_, span := Tracer(tp).Start(context.Background(), "work")
+	span.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "work", spans[0].Name)
+	assert.Equal(t, TracerName, spans[0].InstrumentationScope.Name)
+	assert.Contains(t, spans[0].Resource.Attributes(), semconv.ServiceName("orders-api"))
+}
+
+func TestInit(t *testing.T) {
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp, err := Init(Config{ServiceName: "orders-api", Exporter: exporter, Synchronous: true})
+	require.NoError(t, err)
+	defer tp.Shutdown(context.Background())
+
+	ctx, span := Tracer(nil).Start(context.Background(), "work")
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	span.End()
+
+	assert.Len(t, exporter.GetSpans(), 1)
+	assert.Contains(t, carrier["traceparent"], span.SpanContext().TraceID().String())
+}
+
+func TestPropagator(t *testing.T) {
+	traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	carrier := propagation.MapCarrier{"traceparent": traceparent, "tracestate": "vendor=value"}
+
+	ctx := Propagator().Extract(context.Background(), carrier)
+	spanContext := trace.SpanContextFromContext(ctx)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spanContext.TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", spanContext.SpanID().String())
+	assert.Equal(t, "vendor=value", spanContext.TraceState().String())
+
+	injected := propagation.MapCarrier{}
+	Propagator().Inject(ctx, injected)
+	assert.Equal(t, traceparent, injected["traceparent"])
+	assert.Equal(t, "vendor=value", injected["tracestate"])
+}
+
+func TestNewOTLPExporter(t *testing.T) {
+	exporter, err := NewOTLPExporter(context.Background(), OTLPConfig{
+		Endpoint: "localhost:4318",
+		Insecure: true,
+		Headers:  map[string]string{"Authorization": "Bearer token"},
+	})
+	require.NoError(t, err)
+	assert.NoError(t, exporter.Shutdown(context.Background()))
+}
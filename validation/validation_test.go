@@ -311,7 +311,7 @@ func TestValidationResult_EdgeCases(t *testing.T) {
 		t.Run(tc.Name, func(t *testing.T) {
 			if tc.ExpectPanic {
 				vts.AssertPanics(func() {
-					tc.Result.Error()
+					_ = tc.Result.Error()
 				})
 			} else {
 				vts.AssertNotPanics(func() {